@@ -159,11 +159,20 @@ var database_models []interface{} = []interface{}{
 	&models.MediaURL{},
 	&models.Album{},
 	&models.MediaEXIF{},
+	&models.MediaXMP{},
+	&models.MediaOverride{},
+	&models.MediaSearchDocument{},
 	&models.VideoMetadata{},
 	&models.ShareToken{},
 	&models.UserMediaData{},
 	&models.UserAlbums{},
 	&models.UserPreferences{},
+	&models.ScannerJob{},
+	&models.MediaScanError{},
+	&models.MediaStack{},
+	&models.Tag{},
+	&models.MediaTag{},
+	&models.SmartAlbum{},
 
 	// Face detection
 	&models.FaceGroup{},
@@ -201,11 +210,24 @@ func MigrateDatabase(db *gorm.DB) error {
 		db.Migrator().DropColumn(&models.SiteInfo{}, "thumbnail_method")
 	}
 
+	if err := setupSearchIndex(db); err != nil {
+		log.Printf("Failed to set up search index: %v\n", err)
+	}
+
 	return nil
 }
 
 func ClearDatabase(db *gorm.DB) error {
 	var errs []error
+
+	// The full-text search table of SQLite isn't a model, and can only be dropped if SQLite has FTS5
+	if drivers.SQLITE.MatchDatabase(db) && models.SqliteFTS5Enabled(db) {
+		if err := db.Migrator().DropTable(models.MediaSearchFTSTable); err != nil {
+			errs = append(errs, err)
+		}
+		models.SetMediaSearchFTS(false)
+	}
+
 	for _, model := range database_models {
 		if err := db.Migrator().DropTable(model); err != nil {
 			errs = append(errs, err)
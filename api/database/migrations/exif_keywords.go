@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"fmt"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/log"
+	"github.com/photoview/photoview/api/scanner/externaltools/exif"
+	"gorm.io/gorm"
+)
+
+// MigrateExifKeywords saves the keywords of the media whose EXIF metadata was parsed before keywords were extracted,
+// their metadata has no keywords, not even an empty list. The media files are parsed,
+// so the exif parser must be initialized. Media whose files can't be parsed are tried again the next time.
+func MigrateExifKeywords(db *gorm.DB) error {
+	withoutKeywords := db.Model(&models.MediaEXIF{}).Select("id").Where("keywords IS NULL")
+
+	var media []*models.Media
+	result := db.Where("exif_id IN (?)", withoutKeywords).FindInBatches(&media, 100, func(tx *gorm.DB, batch int) error {
+		for _, m := range media {
+			exifData, err := exif.Parse(m.Path)
+			if err != nil {
+				log.Warn(db.Statement.Context, "Failed to parse keywords of media", "path", m.Path, "error", err)
+				continue
+			}
+
+			keywords := []string{}
+			if exifData != nil && exifData.Keywords != nil {
+				keywords = exifData.Keywords
+			}
+
+			if err := db.Model(&models.MediaEXIF{}).Where("id = ?", *m.ExifID).Select("keywords").
+				Updates(&models.MediaEXIF{Keywords: keywords}).Error; err != nil {
+				return fmt.Errorf("failed to save keywords of %q to media_exif table: %w", m.Path, err)
+			}
+
+			if err := models.SetMediaKeywords(db, m, keywords); err != nil {
+				return fmt.Errorf("failed to save keywords of %q: %w", m.Path, err)
+			}
+		}
+		return nil
+	})
+
+	return result.Error
+}
@@ -0,0 +1,149 @@
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/photoview/photoview/api/database/drivers"
+	"github.com/photoview/photoview/api/graphql/models"
+	"gorm.io/gorm"
+)
+
+// setupSearchIndex creates the full-text index of the media search documents for the database driver,
+// and builds the search documents of media that don't have one yet
+func setupSearchIndex(db *gorm.DB) error {
+	switch drivers.GetDatabaseDriverType(db) {
+	case drivers.SQLITE:
+		if err := setupSqliteSearchIndex(db); err != nil {
+			return err
+		}
+	case drivers.POSTGRES:
+		if err := setupPostgresSearchIndex(db); err != nil {
+			return err
+		}
+	case drivers.MYSQL:
+		if err := setupMysqlSearchIndex(db); err != nil {
+			return err
+		}
+	}
+
+	return indexUnsearchableMedia(db)
+}
+
+// setupSqliteSearchIndex creates an FTS5 table with the search documents as external content,
+// that is kept in sync by triggers. Without FTS5 the search documents are scanned instead.
+func setupSqliteSearchIndex(db *gorm.DB) error {
+	triggers := []string{"media_search_documents_insert", "media_search_documents_delete", "media_search_documents_update"}
+
+	if !models.SqliteFTS5Enabled(db) {
+		models.SetMediaSearchFTS(false)
+		log.Printf("WARN: SQLite was built without FTS5, search will not use a full-text index\n")
+
+		// The triggers of a previous build with FTS5 would fail to update the full-text search table
+		for _, trigger := range triggers {
+			if err := db.Exec("DROP TRIGGER IF EXISTS " + trigger).Error; err != nil {
+				return fmt.Errorf("drop full-text search trigger: %w", err)
+			}
+		}
+
+		return nil
+	}
+
+	// Documents saved while the triggers didn't exist, before the table was created or by a build without FTS5,
+	// are missing from the full-text search table
+	var syncedTriggers int64
+	if err := db.Table("sqlite_master").Where("type = 'trigger' AND name IN ?", triggers).Count(&syncedTriggers).Error; err != nil {
+		return fmt.Errorf("get full-text search triggers: %w", err)
+	}
+
+	err := db.Exec(fmt.Sprintf("CREATE VIRTUAL TABLE IF NOT EXISTS %s USING fts5(title, content, "+
+		"content='media_search_documents', content_rowid='media_id', tokenize='porter unicode61')",
+		models.MediaSearchFTSTable)).Error
+	if err != nil {
+		return fmt.Errorf("create full-text search table: %w", err)
+	}
+
+	statements := []string{
+		`CREATE TRIGGER IF NOT EXISTS media_search_documents_insert AFTER INSERT ON media_search_documents BEGIN
+			INSERT INTO media_search_fts(rowid, title, content) VALUES (new.media_id, new.title, new.content);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS media_search_documents_delete AFTER DELETE ON media_search_documents BEGIN
+			INSERT INTO media_search_fts(media_search_fts, rowid, title, content) VALUES ('delete', old.media_id, old.title, old.content);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS media_search_documents_update AFTER UPDATE ON media_search_documents BEGIN
+			INSERT INTO media_search_fts(media_search_fts, rowid, title, content) VALUES ('delete', old.media_id, old.title, old.content);
+			INSERT INTO media_search_fts(rowid, title, content) VALUES (new.media_id, new.title, new.content);
+		END`,
+	}
+
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("create full-text search trigger: %w", err)
+		}
+	}
+
+	if syncedTriggers < int64(len(triggers)) {
+		if err := db.Exec(fmt.Sprintf("INSERT INTO %[1]s(%[1]s) VALUES ('rebuild')", models.MediaSearchFTSTable)).Error; err != nil {
+			return fmt.Errorf("rebuild full-text search table: %w", err)
+		}
+	}
+
+	models.SetMediaSearchFTS(true)
+	return nil
+}
+
+// setupPostgresSearchIndex adds a generated tsvector column with a GIN index to the search documents
+func setupPostgresSearchIndex(db *gorm.DB) error {
+	err := db.Exec(fmt.Sprintf("ALTER TABLE media_search_documents ADD COLUMN IF NOT EXISTS search_vector tsvector "+
+		"GENERATED ALWAYS AS (setweight(to_tsvector('%[1]s', title), 'A') || setweight(to_tsvector('%[1]s', content), 'B')) STORED",
+		models.MediaSearchConfiguration)).Error
+	if err != nil {
+		return fmt.Errorf("add full-text search column: %w", err)
+	}
+
+	err = db.Exec("CREATE INDEX IF NOT EXISTS idx_media_search_documents_search_vector " +
+		"ON media_search_documents USING GIN (search_vector)").Error
+	if err != nil {
+		return fmt.Errorf("create full-text search index: %w", err)
+	}
+
+	return nil
+}
+
+// setupMysqlSearchIndex adds a FULLTEXT index to the search documents
+func setupMysqlSearchIndex(db *gorm.DB) error {
+	const indexName = "idx_media_search_documents_fulltext"
+
+	if db.Migrator().HasIndex(&models.MediaSearchDocument{}, indexName) {
+		return nil
+	}
+
+	err := db.Exec("CREATE FULLTEXT INDEX " + indexName + " ON media_search_documents (title, content)").Error
+	if err != nil {
+		return fmt.Errorf("create full-text search index: %w", err)
+	}
+
+	return nil
+}
+
+// indexUnsearchableMedia builds the search documents of media that don't have one,
+// like media scanned before the search index existed
+func indexUnsearchableMedia(db *gorm.DB) error {
+	for {
+		var mediaIDs []int
+		if err := db.Model(&models.Media{}).
+			Where("id NOT IN (?)", db.Model(&models.MediaSearchDocument{}).Select("media_id")).
+			Limit(500).
+			Pluck("id", &mediaIDs).Error; err != nil {
+			return fmt.Errorf("get media without search document: %w", err)
+		}
+
+		if len(mediaIDs) == 0 {
+			return nil
+		}
+
+		if err := models.UpdateMediaSearchDocuments(db, mediaIDs); err != nil {
+			return err
+		}
+	}
+}
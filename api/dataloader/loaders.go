@@ -16,8 +16,16 @@ type Loaders struct {
 	MediaThumbnail      *MediaURLLoader
 	MediaHighres        *MediaURLLoader
 	MediaVideoWeb       *MediaURLLoader
+	MediaVideoHLS       *MediaURLLoader
+	MediaVideoPreview   *MediaURLLoader
+	MediaMotionVideo    *MediaURLLoader
+	MediaScaledURLs     *MediaURLsLoader
 	UserFromAccessToken *UserLoader
 	UserMediaFavorite   *UserFavoritesLoader
+	UserMediaRating     *UserMediaDataLoader
+	MediaOverride       *MediaOverrideLoader
+	MediaStack          *MediaStackLoader
+	MediaTags           *MediaTagsLoader
 }
 
 func Middleware(db *gorm.DB) mux.MiddlewareFunc {
@@ -28,8 +36,16 @@ func Middleware(db *gorm.DB) mux.MiddlewareFunc {
 				MediaThumbnail:      NewThumbnailMediaURLLoader(db),
 				MediaHighres:        NewHighresMediaURLLoader(db),
 				MediaVideoWeb:       NewVideoWebMediaURLLoader(db),
+				MediaVideoHLS:       NewVideoHLSMediaURLLoader(db),
+				MediaVideoPreview:   NewVideoPreviewMediaURLLoader(db),
+				MediaMotionVideo:    NewMotionVideoMediaURLLoader(db),
+				MediaScaledURLs:     NewScaledMediaURLsLoader(db),
 				UserFromAccessToken: NewUserLoaderByToken(db),
 				UserMediaFavorite:   NewUserFavoriteLoader(db),
+				UserMediaRating:     NewUserMediaRatingLoader(db),
+				MediaOverride:       NewMediaOverrideByIDLoader(db),
+				MediaStack:          NewMediaStackByIDLoader(db),
+				MediaTags:           NewMediaTagsByMediaIDLoader(db),
 			})
 
 			r = r.WithContext(ctx)
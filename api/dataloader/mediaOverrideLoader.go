@@ -0,0 +1,34 @@
+package dataloader
+
+import (
+	"time"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"gorm.io/gorm"
+)
+
+// NewMediaOverrideByIDLoader loads the metadata overrides of media by the media id, nil if the media has none
+func NewMediaOverrideByIDLoader(db *gorm.DB) *MediaOverrideLoader {
+	return &MediaOverrideLoader{
+		maxBatch: 100,
+		wait:     5 * time.Millisecond,
+		fetch: func(mediaIDs []int) ([]*models.MediaOverride, []error) {
+			var overrides []*models.MediaOverride
+			if err := db.Where("media_id IN ?", mediaIDs).Find(&overrides).Error; err != nil {
+				return nil, []error{err}
+			}
+
+			overridesByID := make(map[int]*models.MediaOverride, len(overrides))
+			for _, override := range overrides {
+				overridesByID[override.MediaID] = override
+			}
+
+			result := make([]*models.MediaOverride, len(mediaIDs))
+			for i, id := range mediaIDs {
+				result[i] = overridesByID[id]
+			}
+
+			return result, nil
+		},
+	}
+}
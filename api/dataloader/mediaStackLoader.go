@@ -0,0 +1,54 @@
+package dataloader
+
+import (
+	"time"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"gorm.io/gorm"
+)
+
+// NewMediaStackByIDLoader loads media stacks by their id,
+// nil if the stack has less than two media, as manual stacks can be left with a single media after they have been split
+func NewMediaStackByIDLoader(db *gorm.DB) *MediaStackLoader {
+	return &MediaStackLoader{
+		maxBatch: 100,
+		wait:     5 * time.Millisecond,
+		fetch: func(stackIDs []int) ([]*models.MediaStack, []error) {
+			var stacks []*models.MediaStack
+			if err := db.Where("id IN ?", stackIDs).Find(&stacks).Error; err != nil {
+				return nil, []error{err}
+			}
+
+			var counts []struct {
+				StackID int
+				Count   int
+			}
+			if err := db.Model(&models.Media{}).
+				Select("stack_id, COUNT(*) AS count").
+				Where("stack_id IN ?", stackIDs).
+				Group("stack_id").
+				Find(&counts).Error; err != nil {
+				return nil, []error{err}
+			}
+
+			countsByID := make(map[int]int, len(counts))
+			for _, count := range counts {
+				countsByID[count.StackID] = count.Count
+			}
+
+			stacksByID := make(map[int]*models.MediaStack, len(stacks))
+			for _, stack := range stacks {
+				if countsByID[stack.ID] >= 2 {
+					stacksByID[stack.ID] = stack
+				}
+			}
+
+			result := make([]*models.MediaStack, len(stackIDs))
+			for i, id := range stackIDs {
+				result[i] = stacksByID[id]
+			}
+
+			return result, nil
+		},
+	}
+}
@@ -0,0 +1,42 @@
+package dataloader
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// NewMediaTagsByMediaIDLoader loads the tags of media by the media id, ordered by name.
+// The keywords and the tags of all users are loaded, the tags of other users must be filtered out.
+func NewMediaTagsByMediaIDLoader(db *gorm.DB) *MediaTagsLoader {
+	return &MediaTagsLoader{
+		maxBatch: 100,
+		wait:     5 * time.Millisecond,
+		fetch: func(mediaIDs []int) ([][]*models.Tag, []error) {
+			var links []*models.MediaTag
+			if err := db.Preload("Tag").Where("media_id IN (?)", mediaIDs).Find(&links).Error; err != nil {
+				return nil, []error{errors.Wrap(err, "media tags loader database query")}
+			}
+
+			resultMap := make(map[int][]*models.Tag, len(mediaIDs))
+			for _, link := range links {
+				resultMap[link.MediaID] = append(resultMap[link.MediaID], &link.Tag)
+			}
+
+			result := make([][]*models.Tag, len(mediaIDs))
+			for i, mediaID := range mediaIDs {
+				tags := resultMap[mediaID]
+				slices.SortFunc(tags, func(a, b *models.Tag) int {
+					return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+				})
+				result[i] = tags
+			}
+
+			return result, nil
+		},
+	}
+}
@@ -80,3 +80,62 @@ func NewVideoWebMediaURLLoader(db *gorm.DB) *MediaURLLoader {
 		}),
 	}
 }
+
+func NewVideoHLSMediaURLLoader(db *gorm.DB) *MediaURLLoader {
+	return &MediaURLLoader{
+		maxBatch: 100,
+		wait:     5 * time.Millisecond,
+		fetch: makeMediaURLLoader(db, func(query *gorm.DB) *gorm.DB {
+			return query.Where("purpose = ?", models.VideoHLS)
+		}),
+	}
+}
+
+func NewVideoPreviewMediaURLLoader(db *gorm.DB) *MediaURLLoader {
+	return &MediaURLLoader{
+		maxBatch: 100,
+		wait:     5 * time.Millisecond,
+		fetch: makeMediaURLLoader(db, func(query *gorm.DB) *gorm.DB {
+			return query.Where("purpose = ?", models.VideoPreview)
+		}),
+	}
+}
+
+func NewMotionVideoMediaURLLoader(db *gorm.DB) *MediaURLLoader {
+	return &MediaURLLoader{
+		maxBatch: 100,
+		wait:     5 * time.Millisecond,
+		fetch: makeMediaURLLoader(db, func(query *gorm.DB) *gorm.DB {
+			return query.Where("purpose = ?", models.MotionVideo)
+		}),
+	}
+}
+
+// NewScaledMediaURLsLoader loads the thumbnails and renditions of media, from the smallest to the largest
+func NewScaledMediaURLsLoader(db *gorm.DB) *MediaURLsLoader {
+	return &MediaURLsLoader{
+		maxBatch: 100,
+		wait:     5 * time.Millisecond,
+		fetch: func(mediaIDs []int) ([][]*models.MediaURL, []error) {
+			var urls []*models.MediaURL
+			if err := db.Where("media_id IN (?)", mediaIDs).
+				Where("purpose IN ? OR purpose LIKE ?", []models.MediaPurpose{models.PhotoThumbnail, models.VideoThumbnail}, models.RenditionPurpose("%")).
+				Order("width * height ASC").
+				Find(&urls).Error; err != nil {
+				return nil, []error{errors.Wrap(err, "scaled media urls loader database query")}
+			}
+
+			resultMap := make(map[int][]*models.MediaURL, len(mediaIDs))
+			for _, url := range urls {
+				resultMap[url.MediaID] = append(resultMap[url.MediaID], url)
+			}
+
+			result := make([][]*models.MediaURL, len(mediaIDs))
+			for i, mediaID := range mediaIDs {
+				result[i] = resultMap[mediaID]
+			}
+
+			return result, nil
+		},
+	}
+}
@@ -0,0 +1,78 @@
+package dataloader
+
+import (
+	"time"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"gorm.io/gorm"
+)
+
+// NewUserMediaRatingLoader loads the rating and colour label of media for users,
+// with the values of the XMP sidecars of the media where the users haven't set them
+func NewUserMediaRatingLoader(db *gorm.DB) *UserMediaDataLoader {
+	return &UserMediaDataLoader{
+		maxBatch: 100,
+		wait:     5 * time.Millisecond,
+		fetch: func(keys []*models.UserMediaData) ([]*models.UserMediaData, []error) {
+
+			userIDMap := make(map[int]struct{}, len(keys))
+			mediaIDMap := make(map[int]struct{}, len(keys))
+			for _, key := range keys {
+				userIDMap[key.UserID] = struct{}{}
+				mediaIDMap[key.MediaID] = struct{}{}
+			}
+
+			uniqueUserIDs := make([]int, 0, len(userIDMap))
+			for id := range userIDMap {
+				uniqueUserIDs = append(uniqueUserIDs, id)
+			}
+
+			uniqueMediaIDs := make([]int, 0, len(mediaIDMap))
+			for id := range mediaIDMap {
+				uniqueMediaIDs = append(uniqueMediaIDs, id)
+			}
+
+			var userMediaData []*models.UserMediaData
+			err := db.Where("user_id IN (?)", uniqueUserIDs).Where("media_id IN (?)", uniqueMediaIDs).Find(&userMediaData).Error
+			if err != nil {
+				return nil, []error{err}
+			}
+
+			var xmpRows []struct {
+				MediaID int
+				Rating  *int
+				Label   *string
+			}
+			err = db.Table("media").
+				Select("media.id AS media_id, media_xmp.rating, media_xmp.label").
+				Joins("JOIN media_xmp ON media_xmp.id = media.xmp_id").
+				Where("media.id IN (?)", uniqueMediaIDs).
+				Scan(&xmpRows).Error
+			if err != nil {
+				return nil, []error{err}
+			}
+
+			result := make([]*models.UserMediaData, len(keys))
+			for i, key := range keys {
+				data := &models.UserMediaData{UserID: key.UserID, MediaID: key.MediaID}
+				for _, row := range userMediaData {
+					if row.UserID == key.UserID && row.MediaID == key.MediaID {
+						data = row
+						break
+					}
+				}
+
+				for _, xmp := range xmpRows {
+					if xmp.MediaID == key.MediaID {
+						data.ApplyXMP(xmp.Rating, xmp.Label)
+						break
+					}
+				}
+
+				result[i] = data
+			}
+
+			return result, nil
+		},
+	}
+}
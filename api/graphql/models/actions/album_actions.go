@@ -72,7 +72,9 @@ func favoritesQuery(showEmpty *bool, db *gorm.DB, onlyWithFavorites *bool, user
 				Where("user_media_data.media_id = media.id").
 				Where("user_media_data.favorite = true")
 
-			subQuery = subQuery.Where("EXISTS (?)", favoritesSubquery)
+			// Favorites hidden inside a stack aren't listed in the album
+			subQuery = subQuery.Where("EXISTS (?)", favoritesSubquery).
+				Where("media.id NOT IN (?)", models.HiddenStackMediaQuery(db))
 		}
 
 		query = query.Where("EXISTS (?)", subQuery)
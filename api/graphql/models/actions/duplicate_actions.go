@@ -0,0 +1,241 @@
+package actions
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultDuplicateThreshold is the max number of differing bits between the perceptual hashes of two near-duplicate photos
+const DefaultDuplicateThreshold = 4
+
+// MaxDuplicateThreshold is the highest allowed threshold, above it most unrelated photos would be grouped together
+const MaxDuplicateThreshold = 16
+
+// perceptualHashDistance returns the number of differing bits between two perceptual hashes
+func perceptualHashDistance(a, b int64) int {
+	return bits.OnesCount64(uint64(a ^ b))
+}
+
+// hashTree is a BK-tree of perceptual hashes, used to find all hashes within a distance without comparing every pair
+type hashTree struct {
+	hash     int64
+	index    int
+	children map[int]*hashTree
+}
+
+func (tree *hashTree) add(hash int64, index int) {
+	node := tree
+	for {
+		distance := perceptualHashDistance(node.hash, hash)
+		child, found := node.children[distance]
+		if !found {
+			node.children[distance] = &hashTree{hash: hash, index: index, children: make(map[int]*hashTree)}
+			return
+		}
+		node = child
+	}
+}
+
+func (tree *hashTree) search(hash int64, threshold int, found func(index int, distance int)) {
+	distance := perceptualHashDistance(tree.hash, hash)
+	if distance <= threshold {
+		found(tree.index, distance)
+	}
+
+	for childDistance, child := range tree.children {
+		if childDistance >= distance-threshold && childDistance <= distance+threshold {
+			child.search(hash, threshold, found)
+		}
+	}
+}
+
+// DuplicateGroups returns groups of photos owned by the user that are exact or near-duplicates of each other.
+// Two photos are near-duplicates if their perceptual hashes differ by at most threshold bits,
+// and groups are formed from all photos that are transitively near-duplicates.
+func DuplicateGroups(db *gorm.DB, user *models.User, threshold *int, paginate *models.Pagination) ([]*models.DuplicateGroup, error) {
+	maxDistance := DefaultDuplicateThreshold
+	if threshold != nil {
+		maxDistance = *threshold
+	}
+
+	if maxDistance < 0 || maxDistance > MaxDuplicateThreshold {
+		return nil, fmt.Errorf("threshold must be between 0 and %d", MaxDuplicateThreshold)
+	}
+
+	var hashes []struct {
+		ID             int
+		PerceptualHash int64
+	}
+	if err := db.Model(&models.Media{}).
+		Select("id, perceptual_hash").
+		Where("media.album_id IN (?)", db.Table("user_albums").Select("user_albums.album_id").Where("user_id = ?", user.ID)).
+		Where("media.perceptual_hash IS NOT NULL").
+		Order("media.id").
+		Scan(&hashes).Error; err != nil {
+		return nil, errors.Wrap(err, "get perceptual hashes of media")
+	}
+
+	if len(hashes) == 0 {
+		return []*models.DuplicateGroup{}, nil
+	}
+
+	// Union-find of the media that are near-duplicates
+	parents := make([]int, len(hashes))
+	for i := range parents {
+		parents[i] = i
+	}
+
+	var findRoot func(i int) int
+	findRoot = func(i int) int {
+		if parents[i] != i {
+			parents[i] = findRoot(parents[i])
+		}
+		return parents[i]
+	}
+
+	// The largest distance between near-duplicates of each group, by the index of its root
+	distances := make(map[int]int)
+
+	tree := &hashTree{hash: hashes[0].PerceptualHash, index: 0, children: make(map[int]*hashTree)}
+	for i := 1; i < len(hashes); i++ {
+		tree.search(hashes[i].PerceptualHash, maxDistance, func(index int, distance int) {
+			rootA, rootB := findRoot(i), findRoot(index)
+			// Always keep the smallest index as root, so groups are ordered by their oldest media
+			root := min(rootA, rootB)
+			parents[max(rootA, rootB)] = root
+			distances[root] = max(distances[rootA], distances[rootB], distance)
+		})
+		tree.add(hashes[i].PerceptualHash, i)
+	}
+
+	// Indices of the hashes in each group
+	groupMembers := make(map[int][]int)
+	for i := range hashes {
+		root := findRoot(i)
+		groupMembers[root] = append(groupMembers[root], i)
+	}
+
+	roots := make([]int, 0)
+	for root, members := range groupMembers {
+		if len(members) > 1 {
+			roots = append(roots, root)
+		}
+	}
+	sort.Ints(roots)
+
+	if paginate != nil {
+		if paginate.Offset != nil {
+			roots = roots[min(max(*paginate.Offset, 0), len(roots)):]
+		}
+		if paginate.Limit != nil {
+			roots = roots[:min(max(*paginate.Limit, 0), len(roots))]
+		}
+	}
+
+	mediaIDs := make([]int, 0)
+	for _, root := range roots {
+		for _, i := range groupMembers[root] {
+			mediaIDs = append(mediaIDs, hashes[i].ID)
+		}
+	}
+
+	var media []*models.Media
+	if len(mediaIDs) > 0 {
+		if err := db.Where("id IN ?", mediaIDs).Find(&media).Error; err != nil {
+			return nil, errors.Wrap(err, "get duplicate media")
+		}
+	}
+
+	mediaByID := make(map[int]*models.Media, len(media))
+	for _, m := range media {
+		mediaByID[m.ID] = m
+	}
+
+	groups := make([]*models.DuplicateGroup, 0, len(roots))
+	for _, root := range roots {
+		members := groupMembers[root]
+		group := &models.DuplicateGroup{
+			Media: make([]*models.Media, 0, len(members)),
+		}
+
+		// Photos of a group are only linked through near-duplicates, so the distance is the largest between them,
+		// the photos at the ends of a chain may differ by more
+		group.Distance = distances[root]
+		for _, i := range members {
+			if m, found := mediaByID[hashes[i].ID]; found {
+				group.Media = append(group.Media, m)
+			}
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
+// HideDuplicateMedia hides the given copies of a photo from the timeline of the user,
+// and makes sure the preferred copy is shown
+func HideDuplicateMedia(db *gorm.DB, user *models.User, preferredMediaID int, mediaIDs []int) ([]*models.Media, error) {
+	allIDs := append([]int{preferredMediaID}, mediaIDs...)
+
+	var media []*models.Media
+	if err := db.
+		Where("media.id IN ?", allIDs).
+		Where("media.album_id IN (?)", db.Table("user_albums").Select("user_albums.album_id").Where("user_id = ?", user.ID)).
+		Find(&media).Error; err != nil {
+		return nil, errors.Wrap(err, "get duplicate media")
+	}
+
+	ownedIDs := make(map[int]bool, len(media))
+	for _, m := range media {
+		ownedIDs[m.ID] = true
+	}
+
+	for _, id := range allIDs {
+		if !ownedIDs[id] {
+			return nil, fmt.Errorf("media (%d) not found", id)
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, id := range allIDs {
+			userMediaData := models.UserMediaData{
+				UserID:  user.ID,
+				MediaID: id,
+				Hidden:  id != preferredMediaID,
+			}
+
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "media_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"hidden", "updated_at"}),
+			}).Create(&userMediaData).Error; err != nil {
+				return errors.Wrap(err, "update hidden media in database")
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return media, nil
+}
+
+// UnhideMedia shows the given media in the timeline of the user again
+func UnhideMedia(db *gorm.DB, user *models.User, mediaIDs []int) (int, error) {
+	result := db.Model(&models.UserMediaData{}).
+		Where("user_id = ? AND media_id IN ?", user.ID, mediaIDs).
+		Where("hidden").
+		Update("hidden", false)
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, "unhide media in database")
+	}
+
+	return int(result.RowsAffected), nil
+}
@@ -0,0 +1,132 @@
+package actions_test
+
+import (
+	"testing"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/graphql/models/actions"
+	"github.com/photoview/photoview/api/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuplicateGroups(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	password := "1234"
+	user, err := models.RegisterUser(db, "user", &password, false)
+	require.NoError(t, err)
+
+	album := models.Album{
+		Title: "album",
+		Path:  "/photos",
+	}
+	require.NoError(t, db.Save(&album).Error)
+	require.NoError(t, db.Model(&user).Association("Albums").Append(&album))
+
+	hash := func(value int64) *int64 { return &value }
+
+	media := []models.Media{
+		{Title: "original", Path: "/photos/original", AlbumID: album.ID, PerceptualHash: hash(0b1111_0000)},
+		{Title: "copy", Path: "/photos/copy", AlbumID: album.ID, PerceptualHash: hash(0b1111_0000)},
+		{Title: "edited", Path: "/photos/edited", AlbumID: album.ID, PerceptualHash: hash(0b1111_0111)},
+		{Title: "other", Path: "/photos/other", AlbumID: album.ID, PerceptualHash: hash(-1)},
+		{Title: "unhashed", Path: "/photos/unhashed", AlbumID: album.ID},
+	}
+	require.NoError(t, db.Save(&media).Error)
+
+	mediaTitles := func(group *models.DuplicateGroup) []string {
+		titles := make([]string, 0, len(group.Media))
+		for _, m := range group.Media {
+			titles = append(titles, m.Title)
+		}
+		return titles
+	}
+
+	t.Run("exact duplicates", func(t *testing.T) {
+		threshold := 0
+		groups, err := actions.DuplicateGroups(db, user, &threshold, nil)
+		require.NoError(t, err)
+		require.Len(t, groups, 1)
+		assert.Equal(t, []string{"original", "copy"}, mediaTitles(groups[0]))
+		assert.Equal(t, 0, groups[0].Distance)
+	})
+
+	t.Run("near duplicates", func(t *testing.T) {
+		groups, err := actions.DuplicateGroups(db, user, nil, nil)
+		require.NoError(t, err)
+		require.Len(t, groups, 1)
+		assert.Equal(t, []string{"original", "copy", "edited"}, mediaTitles(groups[0]))
+		assert.Equal(t, 3, groups[0].Distance)
+	})
+
+	t.Run("distance of a chain of near duplicates", func(t *testing.T) {
+		chain := models.Album{Title: "chain", Path: "/chain"}
+		require.NoError(t, db.Save(&chain).Error)
+		require.NoError(t, db.Model(&user).Association("Albums").Append(&chain))
+		defer db.Model(&user).Association("Albums").Delete(&chain)
+
+		// Far from the hashes of the other photos, each photo differs from the next by 4 bits
+		base := int64(0xFF) << 32
+		chainMedia := []models.Media{
+			{Title: "first", Path: "/chain/first", AlbumID: chain.ID, PerceptualHash: hash(base)},
+			{Title: "second", Path: "/chain/second", AlbumID: chain.ID, PerceptualHash: hash(base | 0x0F<<40)},
+			{Title: "third", Path: "/chain/third", AlbumID: chain.ID, PerceptualHash: hash(base | 0xFF<<40)},
+		}
+		require.NoError(t, db.Save(&chainMedia).Error)
+
+		groups, err := actions.DuplicateGroups(db, user, nil, nil)
+		require.NoError(t, err)
+		require.Len(t, groups, 2)
+		assert.Equal(t, []string{"first", "second", "third"}, mediaTitles(groups[1]))
+		assert.Equal(t, 4, groups[1].Distance, "distance should be the largest between near duplicates, not the ends of the chain")
+	})
+
+	t.Run("invalid threshold", func(t *testing.T) {
+		threshold := actions.MaxDuplicateThreshold + 1
+		_, err := actions.DuplicateGroups(db, user, &threshold, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("other users have no duplicates", func(t *testing.T) {
+		anotherUser, err := models.RegisterUser(db, "user2", &password, false)
+		require.NoError(t, err)
+
+		groups, err := actions.DuplicateGroups(db, anotherUser, nil, nil)
+		require.NoError(t, err)
+		assert.Empty(t, groups)
+
+		_, err = actions.HideDuplicateMedia(db, anotherUser, media[0].ID, []int{media[1].ID})
+		assert.Error(t, err, "users should not be able to hide media they do not own")
+	})
+
+	t.Run("hide duplicates from timeline", func(t *testing.T) {
+		_, err := user.FavoriteMedia(db, media[1].ID, true)
+		require.NoError(t, err)
+
+		_, err = actions.HideDuplicateMedia(db, user, media[0].ID, []int{media[1].ID, media[2].ID})
+		require.NoError(t, err)
+
+		timelineMedia, err := actions.MyTimeline(db, user, nil, actions.TimelineFilter{}, nil)
+		require.NoError(t, err)
+		assert.Len(t, timelineMedia, 3)
+		for _, m := range timelineMedia {
+			assert.NotContains(t, []string{"copy", "edited"}, m.Title)
+		}
+
+		_, err = user.FavoriteMedia(db, media[1].ID, false)
+		require.NoError(t, err)
+
+		timelineMedia, err = actions.MyTimeline(db, user, nil, actions.TimelineFilter{}, nil)
+		require.NoError(t, err)
+		assert.Len(t, timelineMedia, 3, "changing the favorite should keep the media hidden")
+
+		count, err := actions.UnhideMedia(db, user, []int{media[1].ID, media[2].ID})
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		timelineMedia, err = actions.MyTimeline(db, user, nil, actions.TimelineFilter{}, nil)
+		require.NoError(t, err)
+		assert.Len(t, timelineMedia, 5)
+	})
+}
@@ -11,7 +11,8 @@ func MyMedia(db *gorm.DB, user *models.User, order *models.Ordering, paginate *m
 	}
 
 	query := db.Where("media.album_id IN (SELECT user_albums.album_id FROM user_albums WHERE user_albums.user_id = ?)",
-		user.ID)
+		user.ID).
+		Where("media.id NOT IN (?)", models.HiddenStackMediaQuery(db))
 	query = models.FormatSQL(query, order, paginate)
 
 	var media []*models.Media
@@ -21,3 +22,20 @@ func MyMedia(db *gorm.DB, user *models.User, order *models.Ordering, paginate *m
 
 	return media, nil
 }
+
+// MyMediaConnection returns a page of the media of the user, the newest media first unless ordered ascending
+func MyMediaConnection(db *gorm.DB, user *models.User, args models.ConnectionArgs,
+	orderDirection *models.OrderDirection) (*models.MediaConnection, error) {
+
+	query := db.Model(&models.Media{}).
+		Where("media.album_id IN (SELECT user_albums.album_id FROM user_albums WHERE user_albums.user_id = ?)", user.ID).
+		Where("media.id NOT IN (?)", models.HiddenStackMediaQuery(db))
+
+	desc := orderDirection == nil || *orderDirection == models.OrderDirectionDesc
+	media, pageInfo, err := models.PaginateConnection(query, models.MediaDateShotColumn, models.MediaIDColumn, desc, args, models.MediaCursor)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewMediaConnection(media, pageInfo), nil
+}
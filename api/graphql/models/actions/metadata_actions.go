@@ -0,0 +1,422 @@
+package actions
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/scanner/externaltools/exiftool"
+	"github.com/photoview/photoview/api/scanner/filename_date"
+	"github.com/photoview/photoview/api/scanner/geocoding"
+	"github.com/photoview/photoview/api/scanner/xmp_sidecar"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// localDateShot converts the time to the local time of its timezone, labelled as UTC like the dates of MediaEXIF,
+// and returns the offset of the timezone to UTC in seconds
+func localDateShot(t time.Time) (time.Time, int) {
+	_, offset := t.Zone()
+	local := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return local, offset
+}
+
+// metadataUpdates returns the columns of the media overrides to update for the given metadata
+func metadataUpdates(metadata models.MediaMetadataInput) (map[string]any, error) {
+	updates := make(map[string]any)
+
+	if metadata.Title != nil {
+		title := strings.TrimSpace(*metadata.Title)
+		if title == "" {
+			return nil, errors.New("title must not be empty")
+		}
+		updates["title"] = title
+	}
+
+	if metadata.Description != nil {
+		updates["description"] = *metadata.Description
+	}
+
+	if metadata.DateShot != nil {
+		dateShot, offset := localDateShot(*metadata.DateShot)
+		updates["date_shot"] = dateShot
+		updates["offset_sec_shot"] = offset
+	}
+
+	if coords := metadata.Coordinates; coords != nil {
+		if coords.Latitude < -90 || coords.Latitude > 90 {
+			return nil, fmt.Errorf("latitude %f out of range", coords.Latitude)
+		}
+		if coords.Longitude < -180 || coords.Longitude > 180 {
+			return nil, fmt.Errorf("longitude %f out of range", coords.Longitude)
+		}
+		updates["gps_latitude"] = coords.Latitude
+		updates["gps_longitude"] = coords.Longitude
+
+		var place *models.Place
+		if geocoding.GlobalGeocoder != nil {
+			place = geocoding.GlobalGeocoder.Lookup(coords.Latitude, coords.Longitude)
+		}
+		addPlaceUpdates(updates, place)
+	}
+
+	if len(updates) == 0 {
+		return nil, errors.New("no metadata given")
+	}
+
+	return updates, nil
+}
+
+// addPlaceUpdates adds the place columns of the media overrides to the updates, a nil place clears them
+func addPlaceUpdates(updates map[string]any, place *models.Place) {
+	override := models.MediaOverride{}
+	override.SetPlace(place)
+
+	updates["country_code"] = override.CountryCode
+	updates["country"] = override.Country
+	updates["region"] = override.Region
+	updates["city"] = override.City
+}
+
+// EditMediaMetadata corrects the metadata of the given media, the corrections are stored apart from the metadata
+// of the media files so they are kept when the media is scanned again
+func EditMediaMetadata(db *gorm.DB, user *models.User, mediaIDs []int, metadata models.MediaMetadataInput) ([]*models.Media, error) {
+	updates, err := metadataUpdates(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkUserMedia(db, user, mediaIDs); err != nil {
+		return nil, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		rows := make([]models.MediaOverride, 0, len(mediaIDs))
+		for _, mediaID := range mediaIDs {
+			rows = append(rows, models.MediaOverride{MediaID: mediaID})
+		}
+
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+			return errors.Wrap(err, "create media overrides")
+		}
+
+		updates["updated_at"] = time.Now()
+		if err := tx.Model(&models.MediaOverride{}).
+			Where("media_id IN ?", mediaIDs).
+			Updates(updates).Error; err != nil {
+			return errors.Wrap(err, "update media overrides")
+		}
+
+		// The date of the media itself is used for sorting, so it is kept in sync with the corrected date
+		if dateShot, found := updates["date_shot"]; found {
+			if err := tx.Model(&models.Media{}).
+				Where("id IN ?", mediaIDs).
+				UpdateColumn("date_shot", dateShot).Error; err != nil {
+				return errors.Wrap(err, "update date of media")
+			}
+		}
+
+		return models.UpdateMediaSearchDocuments(tx, mediaIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	xmp_sidecar.WriteBackMedia(db, mediaIDs, func(*models.Media) (exiftool.XMPUpdate, error) {
+		update := exiftool.XMPUpdate{
+			Title:       metadata.Title,
+			Description: metadata.Description,
+		}
+
+		if metadata.DateShot != nil {
+			dateShot, offset := localDateShot(*metadata.DateShot)
+			update.DateShot = &dateShot
+			update.OffsetSecShot = &offset
+		}
+
+		if coords := metadata.Coordinates; coords != nil {
+			update.GPSLatitude = &coords.Latitude
+			update.GPSLongitude = &coords.Longitude
+		}
+
+		return update, nil
+	})
+
+	return mediaByIDs(db, mediaIDs)
+}
+
+// ResetMediaMetadata removes the corrections of the given fields, so the metadata of the media files is used again.
+// Values written back to XMP sidecars are left as they are.
+func ResetMediaMetadata(db *gorm.DB, user *models.User, mediaIDs []int, fields []models.MediaMetadataField) ([]*models.Media, error) {
+	if len(fields) == 0 {
+		return nil, errors.New("no metadata fields given")
+	}
+
+	if err := checkUserMedia(db, user, mediaIDs); err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]any)
+	resetDate := false
+	for _, field := range fields {
+		switch field {
+		case models.MediaMetadataFieldTitle:
+			updates["title"] = nil
+		case models.MediaMetadataFieldDescription:
+			updates["description"] = nil
+		case models.MediaMetadataFieldDateShot:
+			updates["date_shot"] = nil
+			updates["offset_sec_shot"] = nil
+			resetDate = true
+		case models.MediaMetadataFieldCoordinates:
+			updates["gps_latitude"] = nil
+			updates["gps_longitude"] = nil
+			addPlaceUpdates(updates, nil)
+		default:
+			return nil, fmt.Errorf("invalid metadata field %q", field.String())
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		updates["updated_at"] = time.Now()
+		if err := tx.Model(&models.MediaOverride{}).
+			Where("media_id IN ?", mediaIDs).
+			Updates(updates).Error; err != nil {
+			return errors.Wrap(err, "reset media overrides")
+		}
+
+		if err := tx.Where("media_id IN ?", mediaIDs).
+			Where("title IS NULL AND description IS NULL AND date_shot IS NULL").
+			Where("gps_latitude IS NULL AND gps_longitude IS NULL").
+			Delete(&models.MediaOverride{}).Error; err != nil {
+			return errors.Wrap(err, "delete empty media overrides")
+		}
+
+		if resetDate {
+			if err := restoreMediaDateShot(tx, mediaIDs); err != nil {
+				return err
+			}
+		}
+
+		return models.UpdateMediaSearchDocuments(tx, mediaIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mediaByIDs(db, mediaIDs)
+}
+
+// restoreMediaDateShot sets the date of the media back to the date of the exif data, or else the date
+// in the names of the file and folders or the modification time of the file, the same way the scanner does
+func restoreMediaDateShot(tx *gorm.DB, mediaIDs []int) error {
+	var media []*models.Media
+	if err := tx.Preload("Exif").Where("id IN ?", mediaIDs).Find(&media).Error; err != nil {
+		return errors.Wrap(err, "get media to restore date")
+	}
+
+	matcher, err := filename_date.SiteMatcher(tx)
+	if err != nil {
+		return err
+	}
+
+	rootPaths := make(map[int]string)
+	for _, m := range media {
+		rootPath, found := rootPaths[m.AlbumID]
+		if !found {
+			if rootPath, err = models.RootAlbumPath(tx, m.AlbumID); err != nil {
+				return errors.Wrapf(err, "get root album of media (%s)", m.Path)
+			}
+			rootPaths[m.AlbumID] = rootPath
+		}
+
+		var dateShot time.Time
+		if m.Exif != nil && m.Exif.DateShot != nil {
+			dateShot = *m.Exif.DateShot
+		} else if filenameDate := matcher.Match(m.Path, rootPath); filenameDate != nil {
+			dateShot = *filenameDate
+		} else {
+			stat, err := os.Stat(m.Path)
+			if err != nil {
+				return errors.Wrapf(err, "read file info of media (%s)", m.Path)
+			}
+			dateShot = stat.ModTime()
+		}
+
+		if err := tx.Model(m).UpdateColumn("date_shot", dateShot).Error; err != nil {
+			return errors.Wrapf(err, "restore date of media (%d)", m.ID)
+		}
+	}
+
+	return nil
+}
+
+func mediaByIDs(db *gorm.DB, mediaIDs []int) ([]*models.Media, error) {
+	var media []*models.Media
+	if err := db.Where("id IN ?", mediaIDs).Find(&media).Error; err != nil {
+		return nil, errors.Wrap(err, "get media from database after update")
+	}
+
+	return media, nil
+}
+
+// maxTimezoneOffset is the largest offset of a timezone to UTC in seconds
+const maxTimezoneOffset = 14 * 60 * 60
+
+// timeShiftBatchSize is the number of media whose time is shifted with a single statement
+const timeShiftBatchSize = 500
+
+// timeShiftMedia returns the media of the user matching the filter, ordered by the date they were shot
+func timeShiftMedia(db *gorm.DB, user *models.User, filter models.TimeShiftFilter) ([]*models.Media, error) {
+	if filter.MediaIds == nil && filter.AlbumID == nil && filter.Camera == nil {
+		return nil, errors.New("media, an album or a camera must be given")
+	}
+
+	query := db.Preload("Exif").
+		Joins("LEFT JOIN media_exif ON media.exif_id = media_exif.id").
+		Where("media.album_id IN (?)", userAlbumIDsQuery(db, user))
+
+	if filter.MediaIds != nil {
+		if err := checkUserMedia(db, user, filter.MediaIds); err != nil {
+			return nil, err
+		}
+		query = query.Where("media.id IN ?", filter.MediaIds)
+	}
+
+	if filter.AlbumID != nil {
+		albums, err := models.GetChildrenFromAlbums(db, nil, []int{*filter.AlbumID})
+		if err != nil {
+			return nil, errors.Wrap(err, "get sub albums")
+		}
+
+		albumIDs := make([]int, 0, len(albums))
+		for _, album := range albums {
+			albumIDs = append(albumIDs, album.ID)
+		}
+		query = query.Where("media.album_id IN ?", albumIDs)
+	}
+
+	if filter.Camera != nil {
+		query = query.Where("media_exif.camera = ?", *filter.Camera)
+	}
+
+	if filter.FromDate != nil {
+		fromDate, _ := localDateShot(*filter.FromDate)
+		query = query.Where("media.date_shot >= ?", fromDate)
+	}
+
+	if filter.ToDate != nil {
+		toDate, _ := localDateShot(*filter.ToDate)
+		query = query.Where("media.date_shot <= ?", toDate)
+	}
+
+	var media []*models.Media
+	if err := query.Order("media.date_shot, media.id").Find(&media).Error; err != nil {
+		return nil, errors.Wrap(err, "get media to shift time")
+	}
+
+	return media, nil
+}
+
+// ShiftMediaTime shifts the date the media matching the filter was shot by `offsetSeconds`,
+// and sets their timezone offset if `timezoneOffset` is given. The shifted dates are stored as media overrides.
+// With `dryRun` the shifted dates are only returned.
+func ShiftMediaTime(db *gorm.DB, user *models.User, filter models.TimeShiftFilter, offsetSeconds int, timezoneOffset *int, dryRun bool) ([]*models.TimeShiftResult, error) {
+	if offsetSeconds == 0 && timezoneOffset == nil {
+		return nil, errors.New("no time shift given")
+	}
+
+	if timezoneOffset != nil && (*timezoneOffset < -maxTimezoneOffset || *timezoneOffset > maxTimezoneOffset) {
+		return nil, fmt.Errorf("timezone offset %d out of range", *timezoneOffset)
+	}
+
+	media, err := timeShiftMedia(db, user, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	mediaIDs := make([]int, 0, len(media))
+	for _, m := range media {
+		mediaIDs = append(mediaIDs, m.ID)
+	}
+
+	var overrides []*models.MediaOverride
+	if err := db.Where("media_id IN ?", mediaIDs).Find(&overrides).Error; err != nil {
+		return nil, errors.Wrap(err, "get media overrides")
+	}
+
+	overrideByID := make(map[int]*models.MediaOverride, len(overrides))
+	for _, override := range overrides {
+		overrideByID[override.MediaID] = override
+	}
+
+	results := make([]*models.TimeShiftResult, 0, len(media))
+	for _, m := range media {
+		exif := overrideByID[m.ID].ApplyToEXIF(m.Exif)
+
+		offsetSecAfter := exif.OffsetSecShot
+		if timezoneOffset != nil {
+			offsetSecAfter = timezoneOffset
+		}
+
+		results = append(results, &models.TimeShiftResult{
+			Media:          m,
+			DateBefore:     m.DateShot,
+			DateAfter:      m.DateShot.Add(time.Duration(offsetSeconds) * time.Second),
+			OffsetSecAfter: offsetSecAfter,
+		})
+	}
+
+	if dryRun || len(results) == 0 {
+		return results, nil
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		rows := make([]models.MediaOverride, 0, len(results))
+		for _, result := range results {
+			rows = append(rows, models.MediaOverride{
+				MediaID:       result.Media.ID,
+				DateShot:      &result.DateAfter,
+				OffsetSecShot: result.OffsetSecAfter,
+			})
+		}
+
+		// Other corrected metadata of existing overrides is kept
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "media_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"date_shot", "offset_sec_shot", "updated_at"}),
+		}).CreateInBatches(&rows, timeShiftBatchSize).Error; err != nil {
+			return errors.Wrap(err, "shift time of media overrides")
+		}
+
+		// The date of the media itself is used for sorting, so it is kept in sync with the shifted date
+		for start := 0; start < len(mediaIDs); start += timeShiftBatchSize {
+			batch := mediaIDs[start:min(start+timeShiftBatchSize, len(mediaIDs))]
+			if err := tx.Model(&models.Media{}).Where("id IN ?", batch).UpdateColumn("date_shot",
+				gorm.Expr("(SELECT media_overrides.date_shot FROM media_overrides WHERE media_overrides.media_id = media.id)"),
+			).Error; err != nil {
+				return errors.Wrap(err, "update dates of media")
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resultByID := make(map[int]*models.TimeShiftResult, len(results))
+	for _, result := range results {
+		resultByID[result.Media.ID] = result
+	}
+
+	xmp_sidecar.WriteBackMedia(db, mediaIDs, func(m *models.Media) (exiftool.XMPUpdate, error) {
+		result := resultByID[m.ID]
+		return exiftool.XMPUpdate{DateShot: &result.DateAfter, OffsetSecShot: result.OffsetSecAfter}, nil
+	})
+
+	return results, nil
+}
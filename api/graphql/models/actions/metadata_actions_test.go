@@ -0,0 +1,238 @@
+package actions_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/photoview/photoview/api/dataloader"
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/graphql/models/actions"
+	"github.com/photoview/photoview/api/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMediaMetadata(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	password := "1234"
+	user, err := models.RegisterUser(db, "user", &password, false)
+	require.NoError(t, err)
+
+	anotherUser, err := models.RegisterUser(db, "another", &password, false)
+	require.NoError(t, err)
+
+	album := models.Album{
+		Title: "album",
+		Path:  "/photos",
+	}
+	require.NoError(t, db.Save(&album).Error)
+	require.NoError(t, db.Model(&user).Association("Albums").Append(&album))
+
+	exifDate := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	exifLatitude, exifLongitude := 55.6, 12.5
+	exif := models.MediaEXIF{DateShot: &exifDate, GPSLatitude: &exifLatitude, GPSLongitude: &exifLongitude}
+	require.NoError(t, db.Create(&exif).Error)
+
+	media := []models.Media{
+		{Title: "first", Path: "/photos/first", AlbumID: album.ID, ExifID: &exif.ID, DateShot: exifDate},
+		{Title: "second", Path: "/photos/second", AlbumID: album.ID, DateShot: exifDate.Add(time.Hour)},
+	}
+	require.NoError(t, db.Save(&media).Error)
+	mediaIDs := []int{media[0].ID, media[1].ID}
+
+	loadOverride := func(media models.Media) *models.MediaOverride {
+		override, err := dataloader.NewMediaOverrideByIDLoader(db).Load(media.ID)
+		require.NoError(t, err)
+		return override
+	}
+
+	t.Run("edit media of another user", func(t *testing.T) {
+		title := "title"
+		_, err := actions.EditMediaMetadata(db, anotherUser, mediaIDs, models.MediaMetadataInput{Title: &title})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid metadata", func(t *testing.T) {
+		_, err := actions.EditMediaMetadata(db, user, mediaIDs, models.MediaMetadataInput{})
+		assert.Error(t, err)
+
+		empty := " "
+		_, err = actions.EditMediaMetadata(db, user, mediaIDs, models.MediaMetadataInput{Title: &empty})
+		assert.Error(t, err)
+
+		_, err = actions.EditMediaMetadata(db, user, mediaIDs, models.MediaMetadataInput{
+			Coordinates: &models.CoordinatesInput{Latitude: 91, Longitude: 0},
+		})
+		assert.Error(t, err)
+	})
+
+	dateShot := time.Date(2019, 12, 24, 18, 30, 0, 0, time.FixedZone("CET", 3600))
+
+	t.Run("edit metadata", func(t *testing.T) {
+		title, description := "Christmas", "Christmas eve"
+		edited, err := actions.EditMediaMetadata(db, user, mediaIDs, models.MediaMetadataInput{
+			Title:       &title,
+			Description: &description,
+			DateShot:    &dateShot,
+			Coordinates: &models.CoordinatesInput{Latitude: 48.1, Longitude: 11.6},
+		})
+		require.NoError(t, err)
+		require.Len(t, edited, 2)
+
+		localDate := time.Date(2019, 12, 24, 18, 30, 0, 0, time.UTC)
+		for _, m := range edited {
+			assert.True(t, localDate.Equal(m.DateShot), "date of media is synced with the override")
+			assert.Contains(t, []string{"first", "second"}, m.Title, "title of the file is kept")
+		}
+
+		override := loadOverride(media[0])
+		require.NotNil(t, override)
+		assert.Equal(t, &title, override.Title)
+
+		editedExif := override.ApplyToEXIF(&exif)
+		assert.Equal(t, &description, editedExif.Description)
+		assert.True(t, localDate.Equal(*editedExif.DateShot))
+		assert.Equal(t, 3600, *editedExif.OffsetSecShot)
+		assert.Equal(t, 48.1, *editedExif.GPSLatitude)
+		assert.Equal(t, 11.6, *editedExif.GPSLongitude)
+
+		assert.Equal(t, &exifDate, exif.DateShot, "exif data is not modified")
+	})
+
+	t.Run("edit only given fields", func(t *testing.T) {
+		title := "Tree"
+		_, err := actions.EditMediaMetadata(db, user, []int{media[0].ID}, models.MediaMetadataInput{Title: &title})
+		require.NoError(t, err)
+
+		override := loadOverride(media[0])
+		assert.Equal(t, &title, override.Title)
+		assert.NotNil(t, override.Description)
+		assert.NotNil(t, override.DateShot)
+	})
+
+	t.Run("reset metadata", func(t *testing.T) {
+		reset, err := actions.ResetMediaMetadata(db, user, []int{media[0].ID}, []models.MediaMetadataField{
+			models.MediaMetadataFieldTitle,
+			models.MediaMetadataFieldDateShot,
+		})
+		require.NoError(t, err)
+		require.Len(t, reset, 1)
+		assert.True(t, exifDate.Equal(reset[0].DateShot), "date of media is restored from exif")
+
+		override := loadOverride(media[0])
+		require.NotNil(t, override)
+		assert.Nil(t, override.Title)
+		assert.Nil(t, override.DateShot)
+		assert.NotNil(t, override.Description)
+
+		_, err = actions.ResetMediaMetadata(db, user, []int{media[0].ID}, []models.MediaMetadataField{
+			models.MediaMetadataFieldDescription,
+			models.MediaMetadataFieldCoordinates,
+		})
+		require.NoError(t, err)
+		assert.Nil(t, loadOverride(media[0]), "empty overrides are removed")
+	})
+}
+
+func TestShiftMediaTime(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	password := "1234"
+	user, err := models.RegisterUser(db, "user", &password, false)
+	require.NoError(t, err)
+
+	anotherUser, err := models.RegisterUser(db, "another", &password, false)
+	require.NoError(t, err)
+
+	trip := models.Album{Title: "trip", Path: "/photos/trip"}
+	require.NoError(t, db.Save(&trip).Error)
+	day := models.Album{Title: "day", Path: "/photos/trip/day", ParentAlbumID: &trip.ID}
+	require.NoError(t, db.Save(&day).Error)
+	require.NoError(t, db.Model(&user).Association("Albums").Append([]*models.Album{&trip, &day}))
+
+	wrongCamera, phone := "Wrong clock", "Phone"
+	exifs := []models.MediaEXIF{{Camera: &wrongCamera}, {Camera: &wrongCamera}, {Camera: &phone}}
+	require.NoError(t, db.Create(&exifs).Error)
+
+	date := time.Date(2023, 7, 1, 10, 0, 0, 0, time.UTC)
+	media := []models.Media{
+		{Title: "first", Path: "/photos/trip/first", AlbumID: trip.ID, ExifID: &exifs[0].ID, DateShot: date},
+		{Title: "second", Path: "/photos/trip/day/second", AlbumID: day.ID, ExifID: &exifs[1].ID, DateShot: date.AddDate(0, 0, 1)},
+		{Title: "phone", Path: "/photos/trip/day/phone", AlbumID: day.ID, ExifID: &exifs[2].ID, DateShot: date},
+	}
+	require.NoError(t, db.Save(&media).Error)
+
+	camera := wrongCamera
+	filter := models.TimeShiftFilter{AlbumID: &trip.ID, Camera: &camera}
+
+	t.Run("invalid filter", func(t *testing.T) {
+		_, err := actions.ShiftMediaTime(db, user, models.TimeShiftFilter{}, 3600, nil, true)
+		assert.Error(t, err)
+
+		_, err = actions.ShiftMediaTime(db, anotherUser, models.TimeShiftFilter{MediaIds: []int{media[0].ID}}, 3600, nil, true)
+		assert.Error(t, err)
+
+		_, err = actions.ShiftMediaTime(db, user, filter, 0, nil, true)
+		assert.Error(t, err)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		results, err := actions.ShiftMediaTime(db, user, filter, -3600, nil, true)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+
+		assert.Equal(t, media[0].ID, results[0].Media.ID)
+		assert.True(t, date.Equal(results[0].DateBefore))
+		assert.True(t, date.Add(-time.Hour).Equal(results[0].DateAfter))
+		assert.Equal(t, media[1].ID, results[1].Media.ID)
+
+		var overrides int64
+		require.NoError(t, db.Model(&models.MediaOverride{}).Count(&overrides).Error)
+		assert.EqualValues(t, 0, overrides, "dry run doesn't change anything")
+	})
+
+	t.Run("shift time within date range", func(t *testing.T) {
+		dateRangeFilter := filter
+		toDate := date.Add(time.Hour)
+		dateRangeFilter.ToDate = &toDate
+
+		timezone := 7200
+		results, err := actions.ShiftMediaTime(db, user, dateRangeFilter, -3600, &timezone, false)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, media[0].ID, results[0].Media.ID)
+
+		var shifted models.Media
+		require.NoError(t, db.Preload("Exif").First(&shifted, media[0].ID).Error)
+		assert.True(t, date.Add(-time.Hour).Equal(shifted.DateShot))
+
+		var override models.MediaOverride
+		require.NoError(t, db.First(&override, "media_id = ?", media[0].ID).Error)
+		exif := override.ApplyToEXIF(shifted.Exif)
+		assert.True(t, date.Add(-time.Hour).Equal(*exif.DateShot))
+		assert.Equal(t, &timezone, exif.OffsetSecShot)
+
+		var untouched models.Media
+		require.NoError(t, db.First(&untouched, media[2].ID).Error)
+		assert.True(t, date.Equal(untouched.DateShot), "media of other cameras are not shifted")
+	})
+
+	t.Run("shift time keeps other corrections", func(t *testing.T) {
+		title := "corrected"
+		require.NoError(t, db.Create(&models.MediaOverride{MediaID: media[1].ID, Title: &title}).Error)
+
+		_, err := actions.ShiftMediaTime(db, user, filter, 1800, nil, false)
+		require.NoError(t, err)
+
+		var shifted []models.Media
+		require.NoError(t, db.Order("id").Find(&shifted, []int{media[0].ID, media[1].ID}).Error)
+		assert.True(t, date.Add(-30*time.Minute).Equal(shifted[0].DateShot))
+		assert.True(t, date.AddDate(0, 0, 1).Add(30*time.Minute).Equal(shifted[1].DateShot))
+
+		var override models.MediaOverride
+		require.NoError(t, db.First(&override, "media_id = ?", media[1].ID).Error)
+		assert.Equal(t, &title, override.Title)
+		assert.True(t, date.AddDate(0, 0, 1).Add(30*time.Minute).Equal(*override.DateShot))
+	})
+}
@@ -0,0 +1,84 @@
+package actions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// userPlacesQuery returns a query of the places of the media of the user
+func userPlacesQuery(db *gorm.DB, user *models.User) *gorm.DB {
+	return db.Table("(?) AS places",
+		models.MediaPlacesQuery(db).Where("media.album_id IN (?)", userAlbumIDsQuery(db, user))).
+		Where("places.country_code IS NOT NULL")
+}
+
+// Places returns the places where the media of the user was shot grouped by `level`, with the number of media
+func Places(db *gorm.DB, user *models.User, level models.PlaceLevel, countryCode *string) ([]*models.PlaceMediaCount, error) {
+	var columns []string
+	switch level {
+	case models.PlaceLevelCountry:
+		columns = []string{"country_code", "country"}
+	case models.PlaceLevelRegion:
+		columns = []string{"country_code", "country", "region"}
+	case models.PlaceLevelCity:
+		columns = []string{"country_code", "country", "region", "city"}
+	default:
+		return nil, fmt.Errorf("invalid place level %q", level.String())
+	}
+
+	query := userPlacesQuery(db, user)
+	if countryCode != nil {
+		query = query.Where("places.country_code = ?", *countryCode)
+	}
+
+	var rows []struct {
+		models.Place
+		MediaCount int
+	}
+	if err := query.
+		Select(append(columns, "COUNT(*) AS media_count")).
+		Group(strings.Join(columns, ", ")).
+		Order("media_count DESC").
+		Order(columns[1]).
+		Scan(&rows).Error; err != nil {
+		return nil, errors.Wrap(err, "get places of media")
+	}
+
+	places := make([]*models.PlaceMediaCount, 0, len(rows))
+	for _, row := range rows {
+		place := row.Place
+		places = append(places, &models.PlaceMediaCount{Place: &place, MediaCount: row.MediaCount})
+	}
+
+	return places, nil
+}
+
+// PlaceMedia returns the media of the user shot at the place, the region and city are only matched if given
+func PlaceMedia(db *gorm.DB, user *models.User, countryCode string, region *string, city *string,
+	order *models.Ordering, paginate *models.Pagination) ([]*models.Media, error) {
+
+	placeQuery := userPlacesQuery(db, user).Select("places.media_id").Where("places.country_code = ?", countryCode)
+	if region != nil {
+		placeQuery = placeQuery.Where("places.region = ?", *region)
+	}
+	if city != nil {
+		placeQuery = placeQuery.Where("places.city = ?", *city)
+	}
+
+	query := db.Where("media.id IN (?)", placeQuery)
+	if order == nil || order.OrderBy == nil {
+		query = query.Order("media.date_shot DESC, media.id DESC")
+	}
+	query = models.FormatSQL(query, order, paginate)
+
+	var media []*models.Media
+	if err := query.Find(&media).Error; err != nil {
+		return nil, errors.Wrap(err, "get media of place")
+	}
+
+	return media, nil
+}
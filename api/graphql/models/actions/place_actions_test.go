@@ -0,0 +1,95 @@
+package actions_test
+
+import (
+	"testing"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/graphql/models/actions"
+	"github.com/photoview/photoview/api/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlaces(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	password := "1234"
+	user, err := models.RegisterUser(db, "user", &password, false)
+	require.NoError(t, err)
+
+	anotherUser, err := models.RegisterUser(db, "another", &password, false)
+	require.NoError(t, err)
+
+	album := models.Album{
+		Title: "album",
+		Path:  "/photos",
+	}
+	require.NoError(t, db.Save(&album).Error)
+	require.NoError(t, db.Model(&user).Association("Albums").Append(&album))
+
+	str := func(value string) *string { return &value }
+	inPlace := func(countryCode, country, region, city string) *models.MediaEXIF {
+		latitude, longitude := 1.0, 1.0
+		exif := models.MediaEXIF{GPSLatitude: &latitude, GPSLongitude: &longitude}
+		exif.SetPlace(&models.Place{CountryCode: countryCode, Country: country, Region: str(region), City: str(city)})
+		return &exif
+	}
+
+	media := []models.Media{
+		{Title: "tram", Path: "/photos/tram", AlbumID: album.ID, Exif: inPlace("PT", "Portugal", "Lisbon", "Lisbon")},
+		{Title: "tower", Path: "/photos/tower", AlbumID: album.ID, Exif: inPlace("PT", "Portugal", "Lisbon", "Lisbon")},
+		{Title: "bridge", Path: "/photos/bridge", AlbumID: album.ID, Exif: inPlace("PT", "Portugal", "Porto", "Porto")},
+		{Title: "wall", Path: "/photos/wall", AlbumID: album.ID, Exif: inPlace("DE", "Germany", "Berlin", "Berlin")},
+		{Title: "home", Path: "/photos/home", AlbumID: album.ID},
+	}
+	require.NoError(t, db.Save(&media).Error)
+
+	// The coordinates of the wall were corrected to Porto
+	override := models.MediaOverride{MediaID: media[3].ID}
+	latitude, longitude := 41.14961, -8.61099
+	override.GPSLatitude, override.GPSLongitude = &latitude, &longitude
+	override.SetPlace(&models.Place{CountryCode: "PT", Country: "Portugal", Region: str("Porto"), City: str("Porto")})
+	require.NoError(t, db.Create(&override).Error)
+
+	t.Run("places by country", func(t *testing.T) {
+		places, err := actions.Places(db, user, models.PlaceLevelCountry, nil)
+		require.NoError(t, err)
+		require.Len(t, places, 1)
+		assert.Equal(t, "Portugal", places[0].Place.Country)
+		assert.Nil(t, places[0].Place.City)
+		assert.Equal(t, 4, places[0].MediaCount)
+	})
+
+	t.Run("places by city", func(t *testing.T) {
+		places, err := actions.Places(db, user, models.PlaceLevelCity, str("PT"))
+		require.NoError(t, err)
+		require.Len(t, places, 2)
+		assert.Equal(t, "Lisbon", *places[0].Place.City)
+		assert.Equal(t, 2, places[0].MediaCount)
+		assert.Equal(t, "Porto", *places[1].Place.City)
+		assert.Equal(t, 2, places[1].MediaCount)
+	})
+
+	t.Run("places of another user", func(t *testing.T) {
+		places, err := actions.Places(db, anotherUser, models.PlaceLevelCity, nil)
+		require.NoError(t, err)
+		assert.Empty(t, places)
+	})
+
+	t.Run("media of a place", func(t *testing.T) {
+		placeMedia, err := actions.PlaceMedia(db, user, "PT", nil, str("Porto"), nil, nil)
+		require.NoError(t, err)
+
+		titles := make([]string, 0, len(placeMedia))
+		for _, m := range placeMedia {
+			titles = append(titles, m.Title)
+		}
+		assert.ElementsMatch(t, []string{"bridge", "wall"}, titles)
+	})
+
+	t.Run("search by place", func(t *testing.T) {
+		result, err := actions.Search(db, "lisbon", user.ID, nil, nil, nil, nil, nil, nil)
+		require.NoError(t, err)
+		assert.Len(t, result.Media, 2)
+	})
+}
@@ -0,0 +1,97 @@
+package actions
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/scanner/externaltools/exiftool"
+	"github.com/photoview/photoview/api/scanner/xmp_sidecar"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// updateUserMediaData sets the column of the user media data of all the given media to `value`,
+// the user media data is created for media that don't have it yet
+func updateUserMediaData(db *gorm.DB, user *models.User, mediaIDs []int, column string, value any) ([]*models.Media, error) {
+	if err := checkUserMedia(db, user, mediaIDs); err != nil {
+		return nil, err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		rows := make([]models.UserMediaData, 0, len(mediaIDs))
+		for _, mediaID := range mediaIDs {
+			rows = append(rows, models.UserMediaData{UserID: user.ID, MediaID: mediaID})
+		}
+
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+			return errors.Wrap(err, "create user media data")
+		}
+
+		if err := tx.Model(&models.UserMediaData{}).
+			Where("user_id = ? AND media_id IN ?", user.ID, mediaIDs).
+			Updates(map[string]any{column: value, "updated_at": time.Now()}).Error; err != nil {
+			return errors.Wrapf(err, "update %s of media", column)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mediaByIDs(db, mediaIDs)
+}
+
+// writesSharedRating returns true if the ratings and labels of the user are written to the XMP sidecars.
+// A sidecar is shared by all users and its values are used for users who haven't rated the media themselves,
+// so only the values of admins are written to it.
+func writesSharedRating(user *models.User) bool {
+	return user.Admin
+}
+
+// RateMedia sets the star rating of the user for the given media, a rating of 0 removes the rating
+func RateMedia(db *gorm.DB, user *models.User, mediaIDs []int, rating int) ([]*models.Media, error) {
+	if rating < 0 || rating > models.MaxMediaRating {
+		return nil, fmt.Errorf("rating must be between 0 and %d", models.MaxMediaRating)
+	}
+
+	media, err := updateUserMediaData(db, user, mediaIDs, "rating", rating)
+	if err != nil {
+		return nil, err
+	}
+
+	if writesSharedRating(user) {
+		xmp_sidecar.WriteBackMedia(db, mediaIDs, func(*models.Media) (exiftool.XMPUpdate, error) {
+			return exiftool.XMPUpdate{Rating: &rating}, nil
+		})
+	}
+
+	return media, nil
+}
+
+// LabelMedia sets the colour label of the user for the given media, a nil label removes the label
+func LabelMedia(db *gorm.DB, user *models.User, mediaIDs []int, label *models.ColorLabel) ([]*models.Media, error) {
+	// An empty label is stored rather than NULL, so the label of the XMP sidecar is not used instead
+	value := ""
+	if label != nil {
+		if !label.IsValid() {
+			return nil, fmt.Errorf("invalid colour label %q", label.String())
+		}
+		value = label.String()
+	}
+
+	media, err := updateUserMediaData(db, user, mediaIDs, "color_label", value)
+	if err != nil {
+		return nil, err
+	}
+
+	if writesSharedRating(user) {
+		xmp_sidecar.WriteBackMedia(db, mediaIDs, func(*models.Media) (exiftool.XMPUpdate, error) {
+			return exiftool.XMPUpdate{Label: &value}, nil
+		})
+	}
+
+	return media, nil
+}
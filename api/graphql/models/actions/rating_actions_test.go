@@ -0,0 +1,134 @@
+package actions_test
+
+import (
+	"testing"
+
+	"github.com/photoview/photoview/api/dataloader"
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/graphql/models/actions"
+	"github.com/photoview/photoview/api/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMediaRatings(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	password := "1234"
+	user, err := models.RegisterUser(db, "user", &password, false)
+	require.NoError(t, err)
+
+	anotherUser, err := models.RegisterUser(db, "another", &password, false)
+	require.NoError(t, err)
+
+	album := models.Album{
+		Title: "album",
+		Path:  "/photos",
+	}
+	require.NoError(t, db.Save(&album).Error)
+	require.NoError(t, db.Model(&user).Association("Albums").Append(&album))
+	require.NoError(t, db.Model(&anotherUser).Association("Albums").Append(&album))
+
+	xmpRating, xmpLabel := 4, "Red"
+	xmp := models.MediaXMP{Rating: &xmpRating, Label: &xmpLabel}
+	require.NoError(t, db.Create(&xmp).Error)
+
+	media := []models.Media{
+		{Title: "rated_in_darktable", Path: "/photos/rated_in_darktable", AlbumID: album.ID, XMPID: &xmp.ID},
+		{Title: "unrated", Path: "/photos/unrated", AlbumID: album.ID},
+	}
+	require.NoError(t, db.Save(&media).Error)
+
+	loadRating := func(user *models.User, media models.Media) *models.UserMediaData {
+		data, err := dataloader.NewUserMediaRatingLoader(db).Load(&models.UserMediaData{
+			UserID:  user.ID,
+			MediaID: media.ID,
+		})
+		require.NoError(t, err)
+		return data
+	}
+
+	timelineTitles := func(user *models.User, minRating *int, colorLabel *models.ColorLabel) []string {
+		timelineMedia, err := actions.MyTimeline(db, user, nil, actions.TimelineFilter{MinRating: minRating, ColorLabel: colorLabel}, nil)
+		require.NoError(t, err)
+
+		titles := make([]string, 0, len(timelineMedia))
+		for _, m := range timelineMedia {
+			titles = append(titles, m.Title)
+		}
+		return titles
+	}
+
+	red := models.ColorLabelRed
+	blue := models.ColorLabelBlue
+	three := 3
+
+	t.Run("XMP rating initialises the values", func(t *testing.T) {
+		data := loadRating(user, media[0])
+		assert.Equal(t, 4, data.GetRating())
+		assert.Equal(t, &red, data.GetColorLabel())
+
+		data = loadRating(user, media[1])
+		assert.Equal(t, 0, data.GetRating())
+		assert.Nil(t, data.GetColorLabel())
+
+		assert.Equal(t, []string{"rated_in_darktable"}, timelineTitles(user, &three, nil))
+		assert.Equal(t, []string{"rated_in_darktable"}, timelineTitles(user, nil, &red))
+	})
+
+	t.Run("rate media", func(t *testing.T) {
+		rated, err := actions.RateMedia(db, user, []int{media[0].ID, media[1].ID}, 5)
+		require.NoError(t, err)
+		assert.Len(t, rated, 2)
+
+		assert.Equal(t, 5, loadRating(user, media[1]).GetRating())
+		assert.Equal(t, 0, loadRating(anotherUser, media[1]).GetRating(), "ratings are per user")
+
+		_, err = actions.RateMedia(db, user, []int{media[0].ID}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 0, loadRating(user, media[0]).GetRating(), "a rating of the user overrides the XMP rating")
+
+		assert.Equal(t, []string{"unrated"}, timelineTitles(user, &three, nil))
+		assert.Equal(t, []string{"rated_in_darktable"}, timelineTitles(anotherUser, &three, nil))
+
+		_, err = actions.RateMedia(db, user, []int{media[0].ID}, 6)
+		assert.Error(t, err)
+	})
+
+	t.Run("label media", func(t *testing.T) {
+		_, err := actions.LabelMedia(db, user, []int{media[1].ID}, &blue)
+		require.NoError(t, err)
+		assert.Equal(t, &blue, loadRating(user, media[1]).GetColorLabel())
+
+		_, err = actions.LabelMedia(db, user, []int{media[0].ID}, nil)
+		require.NoError(t, err)
+		assert.Nil(t, loadRating(user, media[0]).GetColorLabel(), "removing the label overrides the XMP label")
+
+		assert.Equal(t, []string{"unrated"}, timelineTitles(user, nil, &blue))
+		assert.Empty(t, timelineTitles(user, nil, &red))
+		assert.Equal(t, []string{"rated_in_darktable"}, timelineTitles(anotherUser, nil, &red))
+
+		result, err := actions.Search(db, "unrated", user.ID, nil, nil, nil, nil, nil, &blue)
+		require.NoError(t, err)
+		assert.Len(t, result.Media, 1)
+
+		result, err = actions.Search(db, "unrated", anotherUser.ID, nil, nil, nil, nil, nil, &blue)
+		require.NoError(t, err)
+		assert.Empty(t, result.Media)
+
+		invalid := models.ColorLabel("Orange")
+		_, err = actions.LabelMedia(db, user, []int{media[0].ID}, &invalid)
+		assert.Error(t, err)
+	})
+
+	t.Run("other users can't rate media outside their albums", func(t *testing.T) {
+		stranger, err := models.RegisterUser(db, "stranger", &password, false)
+		require.NoError(t, err)
+
+		_, err = actions.RateMedia(db, stranger, []int{media[0].ID}, 3)
+		assert.Error(t, err)
+
+		_, err = actions.LabelMedia(db, stranger, []int{media[0].ID}, &red)
+		assert.Error(t, err)
+	})
+}
@@ -1,8 +1,6 @@
 package actions
 
 import (
-	"strings"
-
 	"github.com/photoview/photoview/api/database/drivers"
 	"github.com/photoview/photoview/api/graphql/models"
 	"github.com/pkg/errors"
@@ -10,9 +8,14 @@ import (
 	"gorm.io/gorm/clause"
 )
 
-func Search(db *gorm.DB, query string, userID int, limitMedia *int, limitAlbums *int) (*models.SearchResult, error) {
+// Search returns the media and albums of the user matching the search query, see ParseSearchQuery for its syntax.
+// Media are ranked by the relevance of the full-text search of the free text of the query.
+func Search(db *gorm.DB, query string, userID int, limitMedia *int, offsetMedia *int, limitAlbums *int, offsetAlbums *int,
+	minRating *int, colorLabel *models.ColorLabel) (*models.SearchResult, error) {
 	limitMediaInternal := 10
 	limitAlbumsInternal := 10
+	offsetMediaInternal := 0
+	offsetAlbumsInternal := 0
 
 	if limitMedia != nil {
 		limitMediaInternal = *limitMedia
@@ -22,9 +25,27 @@ func Search(db *gorm.DB, query string, userID int, limitMedia *int, limitAlbums
 		limitAlbumsInternal = *limitAlbums
 	}
 
-	wildQuery := "%" + strings.ToLower(query) + "%"
+	if offsetMedia != nil {
+		offsetMediaInternal = *offsetMedia
+	}
 
-	var media []*models.Media
+	if offsetAlbums != nil {
+		offsetAlbumsInternal = *offsetAlbums
+	}
+
+	conditions := newSearchConditions(query, userID)
+
+	result := models.SearchResult{
+		Query:  query,
+		Media:  make([]*models.Media, 0),
+		Albums: make([]*models.Album, 0),
+		Errors: conditions.errors,
+	}
+
+	// Searching without the terms that couldn't be parsed would match everything
+	if len(conditions.errors) > 0 && conditions.empty() {
+		return &result, nil
+	}
 
 	userSubquery := db.Table("user_albums").Where("user_id = ?", userID)
 	if drivers.POSTGRES.MatchDatabase(db) {
@@ -33,44 +54,85 @@ func Search(db *gorm.DB, query string, userID int, limitMedia *int, limitAlbums
 		userSubquery = userSubquery.Where("album_id = Album.id")
 	}
 
-	err := db.Joins("Album").
-		Where("EXISTS (?)", userSubquery).
-		Where("LOWER(media.title) LIKE ? OR LOWER(media.path) LIKE ?", wildQuery, wildQuery).
-		Clauses(clause.OrderBy{
-			Expression: clause.Expr{
-				SQL:    "(CASE WHEN LOWER(media.title) LIKE ? THEN 2 WHEN LOWER(media.path) LIKE ? THEN 1 END) DESC",
-				Vars:               []interface{}{wildQuery, wildQuery},
-				WithoutParentheses: true},
-		}).
-		Limit(limitMediaInternal).Find(&media).Error
+	mediaQuery := func() *gorm.DB {
+		query := db.Model(&models.Media{}).Joins("Album").
+			Joins("LEFT JOIN media_exif ON media_exif.id = media.exif_id").
+			Where("EXISTS (?)", userSubquery).
+			Where("media.id NOT IN (?)", models.HiddenStackMediaQuery(db))
+		query = conditions.apply(db, query)
+		return models.FilterMediaByRating(query, userID, minRating, colorLabel)
+	}
+
+	var totalMedia int64
+	if err := mediaQuery().Count(&totalMedia).Error; err != nil {
+		return nil, errors.Wrapf(err, "counting media")
+	}
+	result.TotalMedia = int(totalMedia)
+
+	orderedMedia := mediaQuery()
+	if conditions.searchText() != "" {
+		orderedMedia = orderedMedia.Order("search_matches.relevance DESC")
+	} else {
+		orderedMedia = orderedMedia.Order("media.date_shot DESC")
+	}
+
+	err := orderedMedia.
+		Order("media.id").
+		Offset(offsetMediaInternal).
+		Limit(limitMediaInternal).
+		Find(&result.Media).Error
 
 	if err != nil {
 		return nil, errors.Wrapf(err, "searching media")
 	}
 
-	var albums []*models.Album
+	// Filters only apply to media, so albums are only searched by free text
+	if len(conditions.filters) > 0 {
+		return &result, nil
+	}
+
+	albumQuery := func() *gorm.DB {
+		query := db.Model(&models.Album{}).
+			Where("EXISTS (?)", db.Table("user_albums").Where("user_id = ?", userID).Where("album_id = albums.id"))
+
+		for _, term := range conditions.text {
+			pattern := likeContains(term.Value)
+			expr := clause.Expr{
+				SQL:  "(LOWER(albums.title) LIKE ? ESCAPE '!' OR LOWER(albums.path) LIKE ? ESCAPE '!')",
+				Vars: []any{pattern, pattern},
+			}
+			if term.Negated {
+				expr = negateCondition(expr)
+			}
+			query = query.Where(expr)
+		}
+
+		return query
+	}
+
+	var totalAlbums int64
+	if err := albumQuery().Count(&totalAlbums).Error; err != nil {
+		return nil, errors.Wrapf(err, "counting albums")
+	}
+	result.TotalAlbums = int(totalAlbums)
+
+	rankPattern := likeContains(conditions.searchText())
 
-	err = db.
-		Where("EXISTS (?)", db.Table("user_albums").Where("user_id = ?", userID).Where("album_id = albums.id")).
-		Where("albums.title LIKE ? OR albums.path LIKE ?", wildQuery, wildQuery).
+	err = albumQuery().
 		Clauses(clause.OrderBy{
 			Expression: clause.Expr{
-				SQL:                "(CASE WHEN albums.title LIKE ? THEN 2 WHEN albums.path LIKE ? THEN 1 END) DESC",
-				Vars:               []interface{}{wildQuery, wildQuery},
+				SQL: "(CASE WHEN LOWER(albums.title) LIKE ? ESCAPE '!' THEN 2 " +
+					"WHEN LOWER(albums.path) LIKE ? ESCAPE '!' THEN 1 ELSE 0 END) DESC, albums.id",
+				Vars:               []interface{}{rankPattern, rankPattern},
 				WithoutParentheses: true},
 		}).
+		Offset(offsetAlbumsInternal).
 		Limit(limitAlbumsInternal).
-		Find(&albums).Error
+		Find(&result.Albums).Error
 
 	if err != nil {
 		return nil, errors.Wrapf(err, "searching albums")
 	}
 
-	result := models.SearchResult{
-		Query:  query,
-		Media:  media,
-		Albums: albums,
-	}
-
 	return &result, nil
 }
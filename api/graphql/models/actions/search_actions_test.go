@@ -3,11 +3,14 @@ package actions_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/photoview/photoview/api/database/drivers"
 	"github.com/photoview/photoview/api/graphql/models"
 	"github.com/photoview/photoview/api/graphql/models/actions"
 	"github.com/photoview/photoview/api/test_utils"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSearch(t *testing.T) {
@@ -91,7 +94,7 @@ func TestSearch(t *testing.T) {
 
 	for _, test := range searchTests {
 		t.Run(fmt.Sprintf("Search query: '%s'", test.query), func(t *testing.T) {
-			result, err := actions.Search(db, test.query, test.userID, test.limitMedia, test.limitAlbum)
+			result, err := actions.Search(db, test.query, test.userID, test.limitMedia, nil, test.limitAlbum, nil, nil, nil)
 			assert.NoError(t, err)
 
 			assert.Equal(t, result.Query, test.query)
@@ -100,3 +103,243 @@ func TestSearch(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSearchQuery(t *testing.T) {
+	terms := actions.ParseSearchQuery(`camera:"X-T4" lens:35mm  iso:>1600 -type:video "New York" beach in:"Summer 2021"`)
+
+	assert.Equal(t, []actions.SearchTerm{
+		{Raw: `camera:"X-T4"`, Key: "camera", Value: "X-T4"},
+		{Raw: "lens:35mm", Key: "lens", Value: "35mm"},
+		{Raw: "iso:>1600", Key: "iso", Value: ">1600"},
+		{Raw: "-type:video", Key: "type", Value: "video", Negated: true},
+		{Raw: `"New York"`, Value: "New York"},
+		{Raw: "beach", Value: "beach"},
+		{Raw: `in:"Summer 2021"`, Key: "in", Value: "Summer 2021"},
+	}, terms)
+
+	assert.Empty(t, actions.ParseSearchQuery("   "))
+}
+
+func TestSearchQuery(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	password := "1234"
+	user, err := models.RegisterUser(db, "user", &password, false)
+	require.NoError(t, err)
+
+	summer := models.Album{Title: "Summer 2021", Path: "/photos/summer"}
+	winter := models.Album{Title: "Winter", Path: "/photos/winter"}
+	require.NoError(t, db.Save(&summer).Error)
+	require.NoError(t, db.Save(&winter).Error)
+	require.NoError(t, db.Model(&user).Association("Albums").Append([]*models.Album{&summer, &winter}))
+
+	str := func(value string) *string { return &value }
+	num := func(value int64) *int64 { return &value }
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+	}
+
+	media := []models.Media{
+		{Title: "beach.jpg", Path: "/photos/summer/beach.jpg", AlbumID: summer.ID, Type: models.MediaTypePhoto,
+			DateShot: date(2021, 7, 10), Exif: &models.MediaEXIF{Camera: str("X-T4"), Lens: str("XF35mmF1.4 R"), Iso: num(3200)}},
+		{Title: "dinner.jpg", Path: "/photos/summer/dinner.jpg", AlbumID: summer.ID, Type: models.MediaTypePhoto,
+			DateShot: date(2021, 8, 31), Exif: &models.MediaEXIF{Camera: str("X-T4"), Lens: str("XF23mmF2"), Iso: num(800)}},
+		{Title: "waves.mp4", Path: "/photos/summer/waves.mp4", AlbumID: summer.ID, Type: models.MediaTypeVideo,
+			DateShot: date(2021, 7, 11)},
+		{Title: "snow.jpg", Path: "/photos/winter/snow.jpg", AlbumID: winter.ID, Type: models.MediaTypePhoto,
+			DateShot: date(2021, 12, 24), Exif: &models.MediaEXIF{Camera: str("iPhone 12"), Iso: num(50)}},
+	}
+	require.NoError(t, db.Save(&media).Error)
+
+	_, err = user.FavoriteMedia(db, media[3].ID, true)
+	require.NoError(t, err)
+
+	alice := models.FaceGroup{Label: str("Alice")}
+	require.NoError(t, db.Save(&alice).Error)
+	require.NoError(t, db.Save(&models.ImageFace{FaceGroupID: alice.ID, MediaID: media[1].ID}).Error)
+
+	search := func(t *testing.T, query string) []string {
+		result, err := actions.Search(db, query, user.ID, nil, nil, nil, nil, nil, nil)
+		require.NoError(t, err)
+		require.Empty(t, result.Errors)
+
+		titles := make([]string, 0, len(result.Media))
+		for _, m := range result.Media {
+			titles = append(titles, m.Title)
+		}
+		return titles
+	}
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{`camera:"x-t4"`, []string{"beach.jpg", "dinner.jpg"}},
+		{"lens:35mm", []string{"beach.jpg"}},
+		{"iso:>1600", []string{"beach.jpg"}},
+		{"iso:50..800", []string{"dinner.jpg", "snow.jpg"}},
+		{"date:2021-07", []string{"beach.jpg", "waves.mp4"}},
+		{"date:2021-07..2021-08", []string{"beach.jpg", "dinner.jpg", "waves.mp4"}},
+		{"date:>2021-08", []string{"snow.jpg"}},
+		{"type:video", []string{"waves.mp4"}},
+		{"-type:video in:\"summer 2021\"", []string{"beach.jpg", "dinner.jpg"}},
+		{"fav:true", []string{"snow.jpg"}},
+		{"fav:false camera:iphone", []string{}},
+		{"person:alice", []string{"dinner.jpg"}},
+		{"-camera:x-t4", []string{"waves.mp4", "snow.jpg"}},
+		{"camera:X-T4 beach", []string{"beach.jpg"}},
+		{"summer -beach -dinner", []string{"waves.mp4"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			assert.ElementsMatch(t, test.expected, search(t, test.query))
+		})
+	}
+
+	t.Run("albums are only searched by free text", func(t *testing.T) {
+		result, err := actions.Search(db, "summer", user.ID, nil, nil, nil, nil, nil, nil)
+		require.NoError(t, err)
+		assert.Len(t, result.Albums, 1)
+
+		result, err = actions.Search(db, "summer type:photo", user.ID, nil, nil, nil, nil, nil, nil)
+		require.NoError(t, err)
+		assert.Empty(t, result.Albums)
+	})
+
+	t.Run("parse errors", func(t *testing.T) {
+		result, err := actions.Search(db, "iso:high colour:red type:photo", user.ID, nil, nil, nil, nil, nil, nil)
+		require.NoError(t, err)
+		require.Len(t, result.Errors, 2)
+		assert.Equal(t, "iso:high", result.Errors[0].Term)
+		assert.Equal(t, "colour:red", result.Errors[1].Term)
+		assert.Len(t, result.Media, 3, "the valid terms are still searched")
+
+		result, err = actions.Search(db, "date:yesterday", user.ID, nil, nil, nil, nil, nil, nil)
+		require.NoError(t, err)
+		assert.Len(t, result.Errors, 1)
+		assert.Empty(t, result.Media, "nothing is matched without valid terms")
+	})
+}
+
+func TestFullTextSearch(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	password := "1234"
+	user, err := models.RegisterUser(db, "user", &password, false)
+	require.NoError(t, err)
+
+	album := models.Album{Title: "photos", Path: "/photos"}
+	require.NoError(t, db.Save(&album).Error)
+	require.NoError(t, db.Model(&user).Association("Albums").Append(&album))
+
+	str := func(value string) *string { return &value }
+
+	media := []models.Media{
+		{Title: "Café de Flore.jpg", Path: "/photos/paris/Café de Flore.jpg", AlbumID: album.ID,
+			Exif: &models.MediaEXIF{Description: str("Morning coffee with friends"), Camera: str("X-T4")}},
+		{Title: "beaches.jpg", Path: "/photos/summer/beaches.jpg", AlbumID: album.ID},
+		{Title: "summer.jpg", Path: "/photos/misc/summer.jpg", AlbumID: album.ID},
+		{Title: "IMG_0001.jpg", Path: "/photos/misc/IMG_0001.jpg", AlbumID: album.ID},
+	}
+	require.NoError(t, db.Save(&media).Error)
+
+	search := func(t *testing.T, query string) []string {
+		result, err := actions.Search(db, query, user.ID, nil, nil, nil, nil, nil, nil)
+		require.NoError(t, err)
+
+		titles := make([]string, 0, len(result.Media))
+		for _, m := range result.Media {
+			titles = append(titles, m.Title)
+		}
+		return titles
+	}
+
+	t.Run("case and accents are ignored", func(t *testing.T) {
+		assert.Equal(t, []string{"Café de Flore.jpg"}, search(t, "cafe"))
+		assert.Equal(t, []string{"Café de Flore.jpg"}, search(t, "CAFÉ FLORE"))
+	})
+
+	t.Run("words of the metadata", func(t *testing.T) {
+		assert.Equal(t, []string{"Café de Flore.jpg"}, search(t, "coffee"))
+		assert.Equal(t, []string{"Café de Flore.jpg"}, search(t, "x-t4 friends"))
+		assert.Equal(t, []string{"IMG_0001.jpg"}, search(t, "0001"))
+		assert.Empty(t, search(t, "offee"), "words match at their start")
+	})
+
+	t.Run("matches in the title rank higher", func(t *testing.T) {
+		assert.Equal(t, []string{"summer.jpg", "beaches.jpg"}, search(t, "summer"))
+	})
+
+	t.Run("negated words", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"beaches.jpg", "summer.jpg", "IMG_0001.jpg"}, search(t, "jpg -paris"))
+	})
+
+	t.Run("word stems", func(t *testing.T) {
+		if drivers.MYSQL.MatchDatabase(db) || (drivers.SQLITE.MatchDatabase(db) && !db.Migrator().HasTable(models.MediaSearchFTSTable)) {
+			t.Skip("the search index of the database doesn't stem words")
+		}
+		assert.Equal(t, []string{"Café de Flore.jpg"}, search(t, "friend"))
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		limit, offset := 2, 3
+		result, err := actions.Search(db, "jpg", user.ID, &limit, &offset, nil, nil, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 4, result.TotalMedia)
+		assert.Len(t, result.Media, 1)
+
+		result, err = actions.Search(db, "photos", user.ID, &limit, nil, &limit, &offset, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 4, result.TotalMedia)
+		assert.Equal(t, 1, result.TotalAlbums)
+		assert.Empty(t, result.Albums)
+	})
+
+	t.Run("corrected titles", func(t *testing.T) {
+		_, err := actions.EditMediaMetadata(db, user, []int{media[3].ID}, models.MediaMetadataInput{Title: str("Sunset at the pier")})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"IMG_0001.jpg"}, search(t, "sunset"))
+
+		_, err = actions.ResetMediaMetadata(db, user, []int{media[3].ID}, []models.MediaMetadataField{models.MediaMetadataFieldTitle})
+		require.NoError(t, err)
+		assert.Empty(t, search(t, "sunset"))
+	})
+
+	t.Run("people", func(t *testing.T) {
+		faceGroup := models.FaceGroup{}
+		require.NoError(t, db.Save(&faceGroup).Error)
+		require.NoError(t, db.Save(&models.ImageFace{FaceGroupID: faceGroup.ID, MediaID: media[1].ID}).Error)
+
+		require.NoError(t, db.Model(&faceGroup).Update("label", "Zoé").Error)
+		require.NoError(t, models.UpdateFaceGroupSearchDocuments(db, faceGroup.ID))
+		assert.Equal(t, []string{"beaches.jpg"}, search(t, "zoe"))
+	})
+
+	t.Run("short and stop words", func(t *testing.T) {
+		// Full-text indexes leave out these words, they are matched anywhere in the documents by all drivers
+		assert.Equal(t, []string{"Café de Flore.jpg"}, search(t, "de"))
+		assert.Equal(t, []string{"Café de Flore.jpg"}, search(t, "coffee with"))
+		assert.Equal(t, []string{"Café de Flore.jpg"}, search(t, "flore to"))
+		assert.ElementsMatch(t, []string{"Café de Flore.jpg", "beaches.jpg", "summer.jpg", "IMG_0001.jpg"}, search(t, "to"))
+	})
+
+	t.Run("keywords and sidecar metadata", func(t *testing.T) {
+		require.NoError(t, models.SetMediaKeywords(db, &media[2], []string{"Lighthouse"}))
+		assert.Equal(t, []string{"summer.jpg"}, search(t, "lighthouse"))
+
+		_, err := actions.AddMediaTags(db, user, []int{media[2].ID}, []string{"Secret"})
+		require.NoError(t, err)
+		assert.Empty(t, search(t, "secret"), "tags of users are only searched with the tag filter")
+
+		media[3].XMP = &models.MediaXMP{Title: str("Harbour at dusk"), Description: str("Fishing boats"), Keywords: []string{"Sailing"}}
+		require.NoError(t, db.Save(&media[3]).Error)
+		assert.Equal(t, []string{"IMG_0001.jpg"}, search(t, "harbour"))
+		assert.Equal(t, []string{"IMG_0001.jpg"}, search(t, "boats sailing"))
+	})
+
+	t.Run("deleted media", func(t *testing.T) {
+		require.NoError(t, db.Delete(&media[0]).Error)
+		assert.Empty(t, search(t, "cafe"))
+	})
+}
@@ -0,0 +1,407 @@
+package actions
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SearchTerm is a term of a search query, either free text or a `key:value` filter
+type SearchTerm struct {
+	// Raw is the term as written in the query
+	Raw string
+	// Key is empty for free text
+	Key   string
+	Value string
+	// Negated terms are prefixed with `-`, like `-type:video`
+	Negated bool
+}
+
+// ParseSearchQuery splits a search query into its terms. Terms are separated by whitespace,
+// and values with whitespace can be quoted, like `in:"Summer 2021"` or `"New York"`.
+func ParseSearchQuery(query string) []SearchTerm {
+	terms := make([]SearchTerm, 0)
+	runes := []rune(query)
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		start := i
+		term := SearchTerm{}
+		if runes[i] == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			term.Negated = true
+			i++
+		}
+
+		var value strings.Builder
+		quoted, seenQuote, hasKey := false, false, false
+		for ; i < len(runes) && (quoted || !unicode.IsSpace(runes[i])); i++ {
+			switch {
+			case runes[i] == '"':
+				quoted = !quoted
+				seenQuote = true
+			case runes[i] == ':' && !seenQuote && !hasKey && value.Len() > 0:
+				term.Key = strings.ToLower(value.String())
+				hasKey = true
+				value.Reset()
+			default:
+				value.WriteRune(runes[i])
+			}
+		}
+
+		term.Raw = string(runes[start:i])
+		term.Value = value.String()
+		terms = append(terms, term)
+	}
+
+	return terms
+}
+
+// searchFilter returns the condition on `media` of the value of a filter term
+type searchFilter func(userID int, value string) (clause.Expr, error)
+
+// searchFilters are the filters of search queries by their key
+var searchFilters = map[string]searchFilter{
+	"camera": func(userID int, value string) (clause.Expr, error) {
+		pattern := likeContains(value)
+		return clause.Expr{
+			SQL:  "(LOWER(media_exif.camera) LIKE ? ESCAPE '!' OR LOWER(media_exif.maker) LIKE ? ESCAPE '!')",
+			Vars: []any{pattern, pattern},
+		}, nil
+	},
+	"lens": func(userID int, value string) (clause.Expr, error) {
+		return clause.Expr{SQL: "LOWER(media_exif.lens) LIKE ? ESCAPE '!'", Vars: []any{likeContains(value)}}, nil
+	},
+	"iso": func(userID int, value string) (clause.Expr, error) {
+		return numericCondition(value, "media_exif.iso")
+	},
+	"aperture": func(userID int, value string) (clause.Expr, error) {
+		return numericCondition(strings.TrimPrefix(strings.ToLower(value), "f"), "media_exif.aperture")
+	},
+	"focal": func(userID int, value string) (clause.Expr, error) {
+		return numericCondition(strings.TrimSuffix(strings.ToLower(value), "mm"), "media_exif.focal_length")
+	},
+	"date": func(userID int, value string) (clause.Expr, error) {
+		return dateCondition(value, "media.date_shot")
+	},
+	"type": func(userID int, value string) (clause.Expr, error) {
+		for _, mediaType := range models.AllMediaType {
+			if strings.EqualFold(value, string(mediaType)) {
+				return clause.Expr{SQL: "media.type = ?", Vars: []any{mediaType}}, nil
+			}
+		}
+		return clause.Expr{}, fmt.Errorf("unknown media type %q, expected photo or video", value)
+	},
+	"fav": func(userID int, value string) (clause.Expr, error) {
+		favorite, err := strconv.ParseBool(value)
+		if err != nil {
+			return clause.Expr{}, fmt.Errorf("expected true or false, got %q", value)
+		}
+
+		expr := clause.Expr{
+			SQL:  "media.id IN (SELECT user_media_data.media_id FROM user_media_data WHERE user_media_data.user_id = ? AND user_media_data.favorite = ?)",
+			Vars: []any{userID, true},
+		}
+		if !favorite {
+			expr = negateCondition(expr)
+		}
+		return expr, nil
+	},
+	"rating": func(userID int, value string) (clause.Expr, error) {
+		// A plain number is a minimum rating, like the minRating argument
+		if _, err := strconv.Atoi(value); err == nil {
+			value = ">=" + value
+		}
+		return numericCondition(value, "("+models.MediaRatingSQL+")", userID)
+	},
+	"label": func(userID int, value string) (clause.Expr, error) {
+		for _, label := range models.AllColorLabel {
+			if strings.EqualFold(value, label.String()) {
+				return clause.Expr{SQL: models.MediaColorLabelSQL + " = ?", Vars: []any{userID, label.String()}}, nil
+			}
+		}
+		return clause.Expr{}, fmt.Errorf("unknown colour label %q", value)
+	},
+	"person": func(userID int, value string) (clause.Expr, error) {
+		return clause.Expr{
+			SQL: "media.id IN (SELECT image_faces.media_id FROM image_faces " +
+				"JOIN face_groups ON face_groups.id = image_faces.face_group_id WHERE LOWER(face_groups.label) = ?)",
+			Vars: []any{strings.ToLower(value)},
+		}, nil
+	},
+	"in": func(userID int, value string) (clause.Expr, error) {
+		return clause.Expr{
+			SQL:  "media.album_id IN (SELECT albums.id FROM albums WHERE LOWER(albums.title) = ?)",
+			Vars: []any{strings.ToLower(value)},
+		}, nil
+	},
+	"tag": func(userID int, value string) (clause.Expr, error) {
+		return clause.Expr{
+			SQL: "media.id IN (SELECT media_tags.media_id FROM media_tags JOIN tags ON tags.id = media_tags.tag_id " +
+				"WHERE LOWER(tags.name) = ? AND (tags.user_id IS NULL OR tags.user_id = ?))",
+			Vars: []any{strings.ToLower(value), userID},
+		}, nil
+	},
+}
+
+// searchConditions holds the conditions of a parsed search query
+type searchConditions struct {
+	// Free text terms, that are matched against the search documents of media
+	text []SearchTerm
+	// Conditions on `media` of the filter terms
+	filters []clause.Expr
+	errors  []*models.SearchQueryError
+}
+
+// newSearchConditions returns the conditions of the search query, terms that can't be parsed are left out
+func newSearchConditions(query string, userID int) searchConditions {
+	conditions := searchConditions{errors: make([]*models.SearchQueryError, 0)}
+
+	for _, term := range ParseSearchQuery(query) {
+		if term.Key == "" {
+			// Terms without words, like punctuation, can't be matched
+			if len(models.SearchWords(term.Value)) > 0 {
+				conditions.text = append(conditions.text, term)
+			}
+			continue
+		}
+
+		filter, found := searchFilters[term.Key]
+		if !found {
+			conditions.errors = append(conditions.errors, &models.SearchQueryError{
+				Term:    term.Raw,
+				Message: fmt.Sprintf("unknown filter %q, expected one of %s", term.Key, strings.Join(searchFilterKeys(), ", ")),
+			})
+			continue
+		}
+
+		if term.Value == "" {
+			conditions.errors = append(conditions.errors, &models.SearchQueryError{
+				Term:    term.Raw,
+				Message: fmt.Sprintf("no value given for %q", term.Key),
+			})
+			continue
+		}
+
+		expr, err := filter(userID, term.Value)
+		if err != nil {
+			conditions.errors = append(conditions.errors, &models.SearchQueryError{Term: term.Raw, Message: err.Error()})
+			continue
+		}
+
+		if term.Negated {
+			expr = negateCondition(expr)
+		}
+		conditions.filters = append(conditions.filters, expr)
+	}
+
+	return conditions
+}
+
+// empty is true if none of the terms of the query could be parsed
+func (c searchConditions) empty() bool {
+	return len(c.text) == 0 && len(c.filters) == 0
+}
+
+// apply adds the conditions to a query of `media`, which must join `media_exif`.
+// If the query has free text, the matches are joined as `search_matches` with their `relevance`.
+func (c searchConditions) apply(db *gorm.DB, query *gorm.DB) *gorm.DB {
+	if match := models.MediaSearchMatchQuery(db, c.searchText()); match != nil {
+		query = query.Joins("JOIN (?) AS search_matches ON search_matches.media_id = media.id", match)
+	}
+
+	for _, term := range c.text {
+		if term.Negated {
+			matches := db.Table("(?) AS excluded_matches", models.MediaSearchMatchQuery(db, term.Value)).Select("media_id")
+			query = query.Where("media.id NOT IN (?)", matches)
+		}
+	}
+
+	for _, expr := range c.filters {
+		query = query.Where(expr)
+	}
+
+	return query
+}
+
+// searchText returns the free text of the query that isn't negated
+func (c searchConditions) searchText() string {
+	words := make([]string, 0, len(c.text))
+	for _, term := range c.text {
+		if !term.Negated {
+			words = append(words, term.Value)
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+func searchFilterKeys() []string {
+	keys := make([]string, 0, len(searchFilters))
+	for key := range searchFilters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// negateCondition inverts the condition, values that are NULL in the condition don't match it and thereby match the negation
+func negateCondition(expr clause.Expr) clause.Expr {
+	return clause.Expr{SQL: "NOT COALESCE((" + expr.SQL + "), FALSE)", Vars: expr.Vars}
+}
+
+// likeContains returns a case-insensitive LIKE pattern matching values containing the text,
+// wildcards in the text are escaped with `!`
+func likeContains(text string) string {
+	escaped := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(strings.ToLower(text))
+	return "%" + escaped + "%"
+}
+
+// numericCondition compares the column to a value like `1600`, `>1600`, `<=5.6` or a range like `100..400`
+func numericCondition(value string, column string, columnVars ...any) (clause.Expr, error) {
+	parse := func(number string) (float64, error) {
+		result, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q", number)
+		}
+		return result, nil
+	}
+
+	if from, to, isRange := strings.Cut(value, ".."); isRange {
+		fromNumber, err := parse(from)
+		if err != nil {
+			return clause.Expr{}, err
+		}
+
+		toNumber, err := parse(to)
+		if err != nil {
+			return clause.Expr{}, err
+		}
+
+		return clause.Expr{
+			SQL:  column + " BETWEEN ? AND ?",
+			Vars: append(append([]any{}, columnVars...), fromNumber, toNumber),
+		}, nil
+	}
+
+	operator := "="
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(value, op) {
+			operator = op
+			value = strings.TrimPrefix(value, op)
+			break
+		}
+	}
+
+	number, err := parse(value)
+	if err != nil {
+		return clause.Expr{}, err
+	}
+
+	return clause.Expr{
+		SQL:  column + " " + operator + " ?",
+		Vars: append(append([]any{}, columnVars...), number),
+	}, nil
+}
+
+// parseDatePeriod parses a date like `2021`, `2021-06` or `2021-06-14` to the period it covers
+func parseDatePeriod(value string) (start time.Time, end time.Time, err error) {
+	layouts := []struct {
+		layout string
+		years  int
+		months int
+		days   int
+	}{
+		{"2006", 1, 0, 0},
+		{"2006-01", 0, 1, 0},
+		{"2006-01-02", 0, 0, 1},
+	}
+
+	for _, l := range layouts {
+		if len(value) != len(l.layout) {
+			continue
+		}
+
+		start, err := time.ParseInLocation(l.layout, value, time.UTC)
+		if err != nil {
+			break
+		}
+
+		return start, start.AddDate(l.years, l.months, l.days), nil
+	}
+
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q, expected YYYY, YYYY-MM or YYYY-MM-DD", value)
+}
+
+// dateCondition compares the column to a date like `2021-06`, `>2021`, `<=2021-06-14` or a range like `2021-06..2021-08`.
+// The dates are compared to the local time the media was shot.
+func dateCondition(value string, column string) (clause.Expr, error) {
+	if from, to, isRange := strings.Cut(value, ".."); isRange {
+		if from == "" && to == "" {
+			return clause.Expr{}, fmt.Errorf("empty date range")
+		}
+
+		var conditions []string
+		var vars []any
+
+		if from != "" {
+			start, _, err := parseDatePeriod(from)
+			if err != nil {
+				return clause.Expr{}, err
+			}
+			conditions = append(conditions, column+" >= ?")
+			vars = append(vars, start)
+		}
+
+		if to != "" {
+			_, end, err := parseDatePeriod(to)
+			if err != nil {
+				return clause.Expr{}, err
+			}
+			conditions = append(conditions, column+" < ?")
+			vars = append(vars, end)
+		}
+
+		return clause.Expr{SQL: "(" + strings.Join(conditions, " AND ") + ")", Vars: vars}, nil
+	}
+
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		if !strings.HasPrefix(value, op) {
+			continue
+		}
+
+		start, end, err := parseDatePeriod(strings.TrimPrefix(value, op))
+		if err != nil {
+			return clause.Expr{}, err
+		}
+
+		switch op {
+		case ">=":
+			return clause.Expr{SQL: column + " >= ?", Vars: []any{start}}, nil
+		case ">":
+			return clause.Expr{SQL: column + " >= ?", Vars: []any{end}}, nil
+		case "<=":
+			return clause.Expr{SQL: column + " < ?", Vars: []any{end}}, nil
+		default:
+			return clause.Expr{SQL: column + " < ?", Vars: []any{start}}, nil
+		}
+	}
+
+	start, end, err := parseDatePeriod(value)
+	if err != nil {
+		return clause.Expr{}, err
+	}
+
+	return clause.Expr{SQL: "(" + column + " >= ? AND " + column + " < ?)", Vars: []any{start, end}}, nil
+}
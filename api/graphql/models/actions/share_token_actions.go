@@ -102,6 +102,32 @@ func AddAlbumShare(db *gorm.DB, user *models.User, albumID int, expire *time.Tim
 	return &shareToken, nil
 }
 
+// AddSmartAlbumShare shares the smart album of the user, the share gives access to the media matching its filter
+func AddSmartAlbumShare(db *gorm.DB, user *models.User, smartAlbumID int, expire *time.Time, password *string) (*models.ShareToken, error) {
+	if _, err := SmartAlbum(db, user, smartAlbumID); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := hashSharePassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	shareToken := models.ShareToken{
+		Value:        utils.GenerateToken(),
+		OwnerID:      user.ID,
+		Expire:       expire,
+		Password:     hashedPassword,
+		SmartAlbumID: &smartAlbumID,
+	}
+
+	if err := db.Create(&shareToken).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to insert new share token into database")
+	}
+
+	return &shareToken, nil
+}
+
 func DeleteShareToken(db *gorm.DB, userID int, tokenValue string) (*models.ShareToken, error) {
 	token, err := getUserToken(db, userID, tokenValue)
 	if err != nil {
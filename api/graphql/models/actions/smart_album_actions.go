@@ -0,0 +1,293 @@
+package actions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/photoview/photoview/api/graphql/auth"
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// validateSmartAlbumFilter returns an error if the filter is invalid or refers to albums the user doesn't own
+func validateSmartAlbumFilter(db *gorm.DB, user *models.User, filter *models.SmartAlbumFilter) error {
+	if filter.FromDate != nil && filter.ToDate != nil && filter.FromDate.After(*filter.ToDate) {
+		return errors.New("the from date must be before the to date")
+	}
+
+	if filter.MinRating != nil && (*filter.MinRating < 0 || *filter.MinRating > models.MaxMediaRating) {
+		return fmt.Errorf("minimum rating must be between 0 and %d", models.MaxMediaRating)
+	}
+
+	if filter.MinDuration != nil && filter.MaxDuration != nil && *filter.MinDuration > *filter.MaxDuration {
+		return errors.New("the minimum duration must not be longer than the maximum duration")
+	}
+
+	if (filter.Region != nil || filter.City != nil) && filter.CountryCode == nil {
+		return errors.New("a country code must be given with a region or city")
+	}
+
+	if filter.AlbumID != nil {
+		if _, err := Album(db, user, *filter.AlbumID); err != nil {
+			return err
+		}
+	}
+
+	if len(filter.FaceGroupIDs) > 0 {
+		var count int64
+		if err := db.Model(&models.FaceGroup{}).Where("id IN ?", filter.FaceGroupIDs).Count(&count).Error; err != nil {
+			return errors.Wrap(err, "get face groups of filter")
+		}
+
+		if int(count) != len(filter.FaceGroupIDs) {
+			return errors.New("face group not found")
+		}
+	}
+
+	return nil
+}
+
+// SmartAlbumMediaQuery returns a query of the media of the owner of the smart album matching its filter,
+// the media are filtered the same way for users viewing the smart album through a share token
+func SmartAlbumMediaQuery(db *gorm.DB, smartAlbum *models.SmartAlbum) (*gorm.DB, error) {
+	filter := smartAlbum.Filter
+	owner := &models.User{Model: models.Model{ID: smartAlbum.OwnerID}}
+
+	query := db.Model(&models.Media{}).
+		Where("media.album_id IN (?)", userAlbumIDsQuery(db, owner)).
+		Where("media.id IN (?)", db.Model(&models.MediaURL{}).
+			Select("media_urls.media_id").
+			Where("media_urls.media_id = media.id")).
+		Where("media.id NOT IN (?)", models.HiddenStackMediaQuery(db))
+
+	if filter.FromDate != nil {
+		fromDate, _ := localDateShot(*filter.FromDate)
+		query = query.Where("media.date_shot >= ?", fromDate)
+	}
+
+	if filter.ToDate != nil {
+		toDate, _ := localDateShot(*filter.ToDate)
+		query = query.Where("media.date_shot <= ?", toDate)
+	}
+
+	if filter.Camera != nil {
+		pattern := likeContains(*filter.Camera)
+		query = query.Where("media.exif_id IN (?)", db.Model(&models.MediaEXIF{}).
+			Select("media_exif.id").
+			Where("LOWER(media_exif.camera) LIKE ? ESCAPE '!' OR LOWER(media_exif.maker) LIKE ? ESCAPE '!'", pattern, pattern))
+	}
+
+	if filter.OnlyFavorites != nil && *filter.OnlyFavorites {
+		query = query.Where("media.id IN (?)", db.Model(&models.UserMediaData{}).
+			Select("user_media_data.media_id").
+			Where("user_media_data.user_id = ? AND user_media_data.favorite = ?", smartAlbum.OwnerID, true))
+	}
+
+	query = models.FilterMediaByRating(query, smartAlbum.OwnerID, filter.MinRating, filter.ColorLabel)
+
+	for _, faceGroupID := range filter.FaceGroupIDs {
+		query = query.Where("media.id IN (?)", db.Model(&models.ImageFace{}).
+			Select("image_faces.media_id").
+			Where("image_faces.face_group_id = ?", faceGroupID))
+	}
+
+	if filter.MediaType != nil {
+		query = query.Where("media.type = ?", filter.MediaType.Normalized())
+	}
+
+	if filter.AlbumID != nil {
+		query = query.Where("media.album_id IN (?)", models.AlbumTreeIDsQuery(db, *filter.AlbumID))
+	}
+
+	if filter.CountryCode != nil {
+		placeQuery := db.Table("(?) AS places", models.MediaPlacesQuery(db)).
+			Select("places.media_id").
+			Where("places.country_code = ?", *filter.CountryCode)
+		if filter.Region != nil {
+			placeQuery = placeQuery.Where("places.region = ?", *filter.Region)
+		}
+		if filter.City != nil {
+			placeQuery = placeQuery.Where("places.city = ?", *filter.City)
+		}
+		query = query.Where("media.id IN (?)", placeQuery)
+	}
+
+	if filter.MinDuration != nil || filter.MaxDuration != nil {
+		durationQuery := db.Model(&models.VideoMetadata{}).Select("id")
+		if filter.MinDuration != nil {
+			durationQuery = durationQuery.Where("duration >= ?", *filter.MinDuration)
+		}
+		if filter.MaxDuration != nil {
+			durationQuery = durationQuery.Where("duration <= ?", *filter.MaxDuration)
+		}
+		query = query.Where("media.video_metadata_id IN (?)", durationQuery)
+	}
+
+	return query, nil
+}
+
+// SmartAlbumMedia returns the media of the smart album, the most recent first unless ordered otherwise
+func SmartAlbumMedia(db *gorm.DB, smartAlbum *models.SmartAlbum, order *models.Ordering, paginate *models.Pagination) ([]*models.Media, error) {
+	query, err := SmartAlbumMediaQuery(db, smartAlbum)
+	if err != nil {
+		return nil, err
+	}
+
+	if order == nil || order.OrderBy == nil {
+		query = query.Order("media.date_shot DESC, media.id DESC")
+	}
+	query = models.FormatSQL(query, order, paginate)
+
+	var media []*models.Media
+	if err := query.Find(&media).Error; err != nil {
+		return nil, errors.Wrap(err, "get media of smart album")
+	}
+
+	return media, nil
+}
+
+// SmartAlbumMediaCount returns the number of media in the smart album
+func SmartAlbumMediaCount(db *gorm.DB, smartAlbum *models.SmartAlbum) (int, error) {
+	query, err := SmartAlbumMediaQuery(db, smartAlbum)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return 0, errors.Wrap(err, "count media of smart album")
+	}
+
+	return int(count), nil
+}
+
+// SmartAlbumContainsMedia checks if the media currently matches the filter of the smart album
+func SmartAlbumContainsMedia(db *gorm.DB, smartAlbum *models.SmartAlbum, mediaID int) (bool, error) {
+	query, err := SmartAlbumMediaQuery(db, smartAlbum)
+	if err != nil {
+		return false, err
+	}
+
+	var count int64
+	if err := query.Where("media.id = ?", mediaID).Count(&count).Error; err != nil {
+		return false, errors.Wrap(err, "check media of smart album")
+	}
+
+	return count > 0, nil
+}
+
+// MySmartAlbums returns the smart albums of the user, ordered by title unless ordered otherwise
+func MySmartAlbums(db *gorm.DB, user *models.User, order *models.Ordering, paginate *models.Pagination) ([]*models.SmartAlbum, error) {
+	query := db.Where("owner_id = ?", user.ID)
+	if order == nil || order.OrderBy == nil {
+		query = query.Order("title, id")
+	}
+	query = models.FormatSQL(query, order, paginate)
+
+	var smartAlbums []*models.SmartAlbum
+	if err := query.Find(&smartAlbums).Error; err != nil {
+		return nil, errors.Wrap(err, "get smart albums of user")
+	}
+
+	return smartAlbums, nil
+}
+
+// SmartAlbum returns the smart album if it is owned by the user
+func SmartAlbum(db *gorm.DB, user *models.User, id int) (*models.SmartAlbum, error) {
+	var smartAlbum models.SmartAlbum
+	if err := db.Where("owner_id = ?", user.ID).First(&smartAlbum, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("smart album not found")
+		}
+		return nil, errors.Wrap(err, "get smart album")
+	}
+
+	return &smartAlbum, nil
+}
+
+// CreateSmartAlbum creates a smart album of the user with the media matching the filter
+func CreateSmartAlbum(db *gorm.DB, user *models.User, title string, filter models.SmartAlbumFilter) (*models.SmartAlbum, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil, errors.New("title must not be empty")
+	}
+
+	if err := validateSmartAlbumFilter(db, user, &filter); err != nil {
+		return nil, err
+	}
+
+	smartAlbum := models.SmartAlbum{
+		Title:   title,
+		OwnerID: user.ID,
+		Filter:  filter.Normalized(),
+	}
+
+	if err := db.Omit("Owner").Create(&smartAlbum).Error; err != nil {
+		return nil, errors.Wrap(err, "create smart album")
+	}
+
+	return &smartAlbum, nil
+}
+
+// UpdateSmartAlbum changes the title of the smart album and replaces its filter, if they are given
+func UpdateSmartAlbum(db *gorm.DB, user *models.User, id int, title *string, filter *models.SmartAlbumFilter) (*models.SmartAlbum, error) {
+	smartAlbum, err := SmartAlbum(db, user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if title != nil {
+		smartAlbum.Title = strings.TrimSpace(*title)
+		if smartAlbum.Title == "" {
+			return nil, errors.New("title must not be empty")
+		}
+	}
+
+	if filter != nil {
+		if err := validateSmartAlbumFilter(db, user, filter); err != nil {
+			return nil, err
+		}
+		smartAlbum.Filter = filter.Normalized()
+	}
+
+	if err := db.Model(smartAlbum).Select("title", "filter").Updates(smartAlbum).Error; err != nil {
+		return nil, errors.Wrap(err, "update smart album")
+	}
+
+	return smartAlbum, nil
+}
+
+// DeleteSmartAlbum deletes the smart album and its share tokens
+func DeleteSmartAlbum(db *gorm.DB, user *models.User, id int) (*models.SmartAlbum, error) {
+	smartAlbum, err := SmartAlbum(db, user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("smart_album_id = ?", smartAlbum.ID).Delete(&models.ShareToken{}).Error; err != nil {
+			return errors.Wrap(err, "delete share tokens of smart album")
+		}
+
+		if err := tx.Delete(smartAlbum).Error; err != nil {
+			return errors.Wrap(err, "delete smart album")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return smartAlbum, nil
+}
+
+// SharedSmartAlbum returns the smart album shared by the share token
+func SharedSmartAlbum(shareToken *models.ShareToken, id int) (*models.SmartAlbum, error) {
+	if shareToken.SmartAlbumID == nil || *shareToken.SmartAlbumID != id || shareToken.SmartAlbum == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return shareToken.SmartAlbum, nil
+}
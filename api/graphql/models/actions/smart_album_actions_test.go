@@ -0,0 +1,229 @@
+package actions_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/graphql/models/actions"
+	"github.com/photoview/photoview/api/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSmartAlbums(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	password := "1234"
+	user, err := models.RegisterUser(db, "user", &password, false)
+	require.NoError(t, err)
+
+	anotherUser, err := models.RegisterUser(db, "another", &password, false)
+	require.NoError(t, err)
+
+	photos := models.Album{Title: "photos", Path: "/photos"}
+	require.NoError(t, db.Save(&photos).Error)
+	summer := models.Album{Title: "summer", Path: "/photos/summer", ParentAlbumID: &photos.ID}
+	require.NoError(t, db.Save(&summer).Error)
+	require.NoError(t, db.Model(&user).Association("Albums").Append([]*models.Album{&photos, &summer}))
+
+	other := models.Album{Title: "other", Path: "/other"}
+	require.NoError(t, db.Save(&other).Error)
+	require.NoError(t, db.Model(&anotherUser).Association("Albums").Append(&other))
+
+	str := func(value string) *string { return &value }
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+	}
+
+	media := []models.Media{
+		{Title: "beach.jpg", Path: "/photos/summer/beach.jpg", AlbumID: summer.ID, Type: models.MediaTypePhoto,
+			DateShot: date(2021, 7, 10), Exif: &models.MediaEXIF{Camera: str("X-T4"), Maker: str("FUJIFILM")}},
+		{Title: "dinner.jpg", Path: "/photos/summer/dinner.jpg", AlbumID: summer.ID, Type: models.MediaTypePhoto,
+			DateShot: date(2021, 8, 31), Exif: &models.MediaEXIF{Camera: str("iPhone 12"), Maker: str("Apple")}},
+		{Title: "waves.mp4", Path: "/photos/summer/waves.mp4", AlbumID: summer.ID, Type: models.MediaTypeVideo,
+			DateShot: date(2021, 7, 11), VideoMetadata: &models.VideoMetadata{Duration: 12.5}},
+		{Title: "snow.jpg", Path: "/photos/snow.jpg", AlbumID: photos.ID, Type: models.MediaTypePhoto,
+			DateShot: date(2021, 12, 24), Exif: &models.MediaEXIF{Camera: str("X-T4"), Maker: str("FUJIFILM")}},
+		{Title: "city.jpg", Path: "/other/city.jpg", AlbumID: other.ID, Type: models.MediaTypePhoto,
+			DateShot: date(2021, 7, 12), Exif: &models.MediaEXIF{Camera: str("X-T4"), Maker: str("FUJIFILM")}},
+		// Media that haven't been processed yet have no urls
+		{Title: "unprocessed.jpg", Path: "/photos/unprocessed.jpg", AlbumID: photos.ID, Type: models.MediaTypePhoto,
+			DateShot: date(2021, 7, 13), Exif: &models.MediaEXIF{Camera: str("X-T4"), Maker: str("FUJIFILM")}},
+	}
+	require.NoError(t, db.Save(&media).Error)
+
+	for i := range media[:5] {
+		require.NoError(t, db.Save(&models.MediaURL{MediaID: media[i].ID, MediaName: media[i].Title}).Error)
+	}
+
+	_, err = user.FavoriteMedia(db, media[3].ID, true)
+	require.NoError(t, err)
+
+	rating := 4
+	require.NoError(t, db.Save(&models.UserMediaData{UserID: user.ID, MediaID: media[1].ID, Rating: &rating}).Error)
+
+	alice := models.FaceGroup{Label: str("Alice")}
+	bob := models.FaceGroup{Label: str("Bob")}
+	require.NoError(t, db.Save(&alice).Error)
+	require.NoError(t, db.Save(&bob).Error)
+	require.NoError(t, db.Save(&[]models.ImageFace{
+		{FaceGroupID: alice.ID, MediaID: media[0].ID},
+		{FaceGroupID: alice.ID, MediaID: media[1].ID},
+		{FaceGroupID: bob.ID, MediaID: media[1].ID},
+	}).Error)
+
+	titles := func(t *testing.T, smartAlbum *models.SmartAlbum) []string {
+		result, err := actions.SmartAlbumMedia(db, smartAlbum, nil, nil)
+		require.NoError(t, err)
+
+		titles := make([]string, 0, len(result))
+		for _, m := range result {
+			titles = append(titles, m.Title)
+		}
+		return titles
+	}
+
+	t.Run("filters", func(t *testing.T) {
+		from, to := date(2021, 7, 1), date(2021, 7, 31)
+		favorites := true
+		minRating := 3
+		video := models.MediaTypeVideo
+		// Media types of the GraphQL API are capitalized
+		apiVideo := models.MediaType("Video")
+		minDuration, maxDuration := 10.0, 20.0
+
+		tests := []struct {
+			name     string
+			filter   models.SmartAlbumFilter
+			expected []string
+		}{
+			{"everything, most recent first", models.SmartAlbumFilter{},
+				[]string{"snow.jpg", "dinner.jpg", "waves.mp4", "beach.jpg"}},
+			{"date range", models.SmartAlbumFilter{FromDate: &from, ToDate: &to}, []string{"waves.mp4", "beach.jpg"}},
+			{"camera", models.SmartAlbumFilter{Camera: str("fujifilm")}, []string{"snow.jpg", "beach.jpg"}},
+			{"favorites", models.SmartAlbumFilter{OnlyFavorites: &favorites}, []string{"snow.jpg"}},
+			{"rating", models.SmartAlbumFilter{MinRating: &minRating}, []string{"dinner.jpg"}},
+			{"all people", models.SmartAlbumFilter{FaceGroupIDs: []int{alice.ID, bob.ID}}, []string{"dinner.jpg"}},
+			{"media type", models.SmartAlbumFilter{MediaType: &video}, []string{"waves.mp4"}},
+			{"media type of the api", models.SmartAlbumFilter{MediaType: &apiVideo}, []string{"waves.mp4"}},
+			{"album and sub albums", models.SmartAlbumFilter{AlbumID: &summer.ID},
+				[]string{"dinner.jpg", "waves.mp4", "beach.jpg"}},
+			{"duration", models.SmartAlbumFilter{MinDuration: &minDuration, MaxDuration: &maxDuration},
+				[]string{"waves.mp4"}},
+			{"combined", models.SmartAlbumFilter{Camera: str("x-t4"), FromDate: &from}, []string{"snow.jpg", "beach.jpg"}},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				smartAlbum, err := actions.CreateSmartAlbum(db, user, test.name, test.filter)
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, titles(t, smartAlbum))
+
+				count, err := actions.SmartAlbumMediaCount(db, smartAlbum)
+				require.NoError(t, err)
+				assert.Equal(t, len(test.expected), count)
+			})
+		}
+	})
+
+	t.Run("media type of the api is stored like media", func(t *testing.T) {
+		apiVideo := models.MediaType("Video")
+		filter := models.SmartAlbumFilter{MediaType: &apiVideo}
+
+		smartAlbum, err := actions.CreateSmartAlbum(db, user, "videos", filter)
+		require.NoError(t, err)
+		assert.Equal(t, models.MediaTypeVideo, *smartAlbum.Filter.MediaType)
+		assert.Equal(t, models.MediaType("Video"), *filter.MediaType, "the filter given is left as it is")
+	})
+
+	t.Run("media are matched when queried", func(t *testing.T) {
+		smartAlbum, err := actions.CreateSmartAlbum(db, user, "favorites", models.SmartAlbumFilter{OnlyFavorites: new(bool)})
+		require.NoError(t, err)
+
+		favorites := true
+		smartAlbum, err = actions.UpdateSmartAlbum(db, user, smartAlbum.ID, str(" Favourites "), &models.SmartAlbumFilter{OnlyFavorites: &favorites})
+		require.NoError(t, err)
+		assert.Equal(t, "Favourites", smartAlbum.Title)
+
+		_, err = user.FavoriteMedia(db, media[0].ID, true)
+		require.NoError(t, err)
+		defer user.FavoriteMedia(db, media[0].ID, false)
+
+		smartAlbum, err = actions.SmartAlbum(db, user, smartAlbum.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"snow.jpg", "beach.jpg"}, titles(t, smartAlbum))
+	})
+
+	t.Run("invalid filters", func(t *testing.T) {
+		from, to := date(2021, 7, 31), date(2021, 7, 1)
+		minRating := 6
+		minDuration, maxDuration := 20.0, 10.0
+		missingFaceGroup := bob.ID + 1000
+
+		filters := []models.SmartAlbumFilter{
+			{FromDate: &from, ToDate: &to},
+			{MinRating: &minRating},
+			{MinDuration: &minDuration, MaxDuration: &maxDuration},
+			{City: str("Copenhagen")},
+			{AlbumID: &other.ID},
+			{FaceGroupIDs: []int{alice.ID, missingFaceGroup}},
+		}
+
+		for _, filter := range filters {
+			_, err := actions.CreateSmartAlbum(db, user, "invalid", filter)
+			assert.Error(t, err)
+		}
+
+		_, err := actions.CreateSmartAlbum(db, user, "  ", models.SmartAlbumFilter{})
+		assert.Error(t, err)
+	})
+
+	t.Run("smart albums of other users", func(t *testing.T) {
+		smartAlbum, err := actions.CreateSmartAlbum(db, anotherUser, "X-T4", models.SmartAlbumFilter{Camera: str("X-T4")})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"city.jpg"}, titles(t, smartAlbum))
+
+		_, err = actions.SmartAlbum(db, user, smartAlbum.ID)
+		assert.Error(t, err)
+
+		_, err = actions.UpdateSmartAlbum(db, user, smartAlbum.ID, str("mine"), nil)
+		assert.Error(t, err)
+
+		_, err = actions.DeleteSmartAlbum(db, user, smartAlbum.ID)
+		assert.Error(t, err)
+
+		_, err = actions.AddSmartAlbumShare(db, user, smartAlbum.ID, nil, nil)
+		assert.Error(t, err)
+
+		smartAlbums, err := actions.MySmartAlbums(db, anotherUser, nil, nil)
+		require.NoError(t, err)
+		require.Len(t, smartAlbums, 1)
+		assert.Equal(t, smartAlbum.ID, smartAlbums[0].ID)
+	})
+
+	t.Run("share", func(t *testing.T) {
+		video := models.MediaTypeVideo
+		smartAlbum, err := actions.CreateSmartAlbum(db, user, "videos", models.SmartAlbumFilter{MediaType: &video})
+		require.NoError(t, err)
+
+		shareToken, err := actions.AddSmartAlbumShare(db, user, smartAlbum.ID, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, smartAlbum.ID, *shareToken.SmartAlbumID)
+
+		contains, err := actions.SmartAlbumContainsMedia(db, smartAlbum, media[2].ID)
+		require.NoError(t, err)
+		assert.True(t, contains)
+
+		contains, err = actions.SmartAlbumContainsMedia(db, smartAlbum, media[0].ID)
+		require.NoError(t, err)
+		assert.False(t, contains)
+
+		_, err = actions.DeleteSmartAlbum(db, user, smartAlbum.ID)
+		require.NoError(t, err)
+
+		var count int64
+		require.NoError(t, db.Model(&models.ShareToken{}).Where("smart_album_id = ?", smartAlbum.ID).Count(&count).Error)
+		assert.Zero(t, count)
+	})
+}
@@ -0,0 +1,131 @@
+package actions
+
+import (
+	"fmt"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// userStackedMedia returns the given media if they are owned by the user and part of a stack
+func userStackedMedia(db *gorm.DB, user *models.User, mediaIDs []int) ([]*models.Media, error) {
+	var media []*models.Media
+	if err := db.
+		Where("media.id IN ?", mediaIDs).
+		Where("media.album_id IN (?)", db.Table("user_albums").Select("user_albums.album_id").Where("user_id = ?", user.ID)).
+		Order("media.date_shot, media.id").
+		Find(&media).Error; err != nil {
+		return nil, errors.Wrap(err, "get stacked media")
+	}
+
+	foundIDs := make(map[int]bool, len(media))
+	for _, m := range media {
+		foundIDs[m.ID] = true
+	}
+
+	for _, id := range mediaIDs {
+		if !foundIDs[id] {
+			return nil, fmt.Errorf("media (%d) not found", id)
+		}
+	}
+
+	for _, m := range media {
+		if m.StackID == nil {
+			return nil, fmt.Errorf("media (%d) is not part of a stack", m.ID)
+		}
+	}
+
+	return media, nil
+}
+
+// SetMediaStackCover makes the media the cover of its stack, that is listed in the timeline and albums
+func SetMediaStackCover(db *gorm.DB, user *models.User, mediaID int) (*models.MediaStack, error) {
+	media, err := userStackedMedia(db, user, []int{mediaID})
+	if err != nil {
+		return nil, err
+	}
+
+	var stack models.MediaStack
+	if err := db.First(&stack, *media[0].StackID).Error; err != nil {
+		return nil, errors.Wrap(err, "get media stack")
+	}
+
+	if err := db.Model(&stack).Update("cover_id", mediaID).Error; err != nil {
+		return nil, errors.Wrap(err, "update cover of media stack")
+	}
+
+	return &stack, nil
+}
+
+// SplitMediaStack moves the given media out of their stack into a new stack, the media are listed there
+// with the earliest frame as cover. Both stacks are marked as manual, so the scanner doesn't merge them again.
+func SplitMediaStack(db *gorm.DB, user *models.User, mediaIDs []int) (*models.MediaStack, error) {
+	if len(mediaIDs) == 0 {
+		return nil, errors.New("no media to split from the stack")
+	}
+
+	media, err := userStackedMedia(db, user, mediaIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	stackID := *media[0].StackID
+	for _, m := range media {
+		if *m.StackID != stackID {
+			return nil, errors.New("media must be part of the same stack to split it")
+		}
+	}
+
+	var stack models.MediaStack
+	if err := db.First(&stack, stackID).Error; err != nil {
+		return nil, errors.Wrap(err, "get media stack")
+	}
+
+	var remaining []*models.Media
+	if err := db.Where("stack_id = ? AND id NOT IN ?", stackID, mediaIDs).Order("date_shot, id").Find(&remaining).Error; err != nil {
+		return nil, errors.Wrap(err, "get remaining media of stack")
+	}
+
+	newStack := models.MediaStack{
+		AlbumID: stack.AlbumID,
+		CoverID: media[0].ID,
+		Manual:  true,
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&newStack).Error; err != nil {
+			return errors.Wrap(err, "create media stack")
+		}
+
+		if err := tx.Model(&models.Media{}).Where("id IN ?", mediaIDs).UpdateColumn("stack_id", newStack.ID).Error; err != nil {
+			return errors.Wrap(err, "move media to new stack")
+		}
+
+		if len(remaining) == 0 {
+			// All media were split off, so the old stack is empty
+			if err := tx.Delete(&stack).Error; err != nil {
+				return errors.Wrap(err, "delete empty media stack")
+			}
+			return nil
+		}
+
+		stack.Manual = true
+		for _, m := range media {
+			if stack.CoverID == m.ID {
+				stack.CoverID = remaining[0].ID
+			}
+		}
+
+		if err := tx.Select("manual", "cover_id").Save(&stack).Error; err != nil {
+			return errors.Wrap(err, "update split media stack")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &newStack, nil
+}
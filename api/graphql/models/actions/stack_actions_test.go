@@ -0,0 +1,104 @@
+package actions_test
+
+import (
+	"testing"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/graphql/models/actions"
+	"github.com/photoview/photoview/api/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMediaStacks(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	password := "1234"
+	user, err := models.RegisterUser(db, "user", &password, false)
+	require.NoError(t, err)
+
+	album := models.Album{
+		Title: "album",
+		Path:  "/photos",
+	}
+	require.NoError(t, db.Save(&album).Error)
+	require.NoError(t, db.Model(&user).Association("Albums").Append(&album))
+
+	media := []models.Media{
+		{Title: "frame_1", Path: "/photos/frame_1", AlbumID: album.ID},
+		{Title: "frame_2", Path: "/photos/frame_2", AlbumID: album.ID},
+		{Title: "frame_3", Path: "/photos/frame_3", AlbumID: album.ID},
+		{Title: "frame_4", Path: "/photos/frame_4", AlbumID: album.ID},
+		{Title: "single", Path: "/photos/single", AlbumID: album.ID},
+	}
+	require.NoError(t, db.Save(&media).Error)
+
+	stack := models.MediaStack{AlbumID: album.ID, CoverID: media[0].ID}
+	require.NoError(t, db.Create(&stack).Error)
+	require.NoError(t, db.Model(&models.Media{}).
+		Where("id IN ?", []int{media[0].ID, media[1].ID, media[2].ID, media[3].ID}).
+		UpdateColumn("stack_id", stack.ID).Error)
+
+	timelineTitles := func() []string {
+		timelineMedia, err := actions.MyTimeline(db, user, nil, actions.TimelineFilter{}, nil)
+		require.NoError(t, err)
+
+		titles := make([]string, 0, len(timelineMedia))
+		for _, m := range timelineMedia {
+			titles = append(titles, m.Title)
+		}
+		return titles
+	}
+
+	assert.ElementsMatch(t, []string{"frame_1", "single"}, timelineTitles())
+
+	t.Run("hidden frames aren't listed", func(t *testing.T) {
+		myMedia, err := actions.MyMedia(db, user, nil, nil)
+		require.NoError(t, err)
+		assert.Len(t, myMedia, 2)
+
+		result, err := actions.Search(db, "frame", user.ID, nil, nil, nil, nil, nil, nil)
+		require.NoError(t, err)
+		require.Len(t, result.Media, 1)
+		assert.Equal(t, "frame_1", result.Media[0].Title)
+	})
+
+	t.Run("set stack cover", func(t *testing.T) {
+		updated, err := actions.SetMediaStackCover(db, user, media[1].ID)
+		require.NoError(t, err)
+		assert.Equal(t, media[1].ID, updated.CoverID)
+		assert.ElementsMatch(t, []string{"frame_2", "single"}, timelineTitles())
+
+		_, err = actions.SetMediaStackCover(db, user, media[4].ID)
+		assert.Error(t, err, "media without stack can't be a stack cover")
+	})
+
+	t.Run("split stack", func(t *testing.T) {
+		newStack, err := actions.SplitMediaStack(db, user, []int{media[1].ID, media[3].ID})
+		require.NoError(t, err)
+		assert.True(t, newStack.Manual)
+		assert.Equal(t, media[1].ID, newStack.CoverID)
+
+		newStackMedia, err := newStack.GetMedia(db)
+		require.NoError(t, err)
+		assert.Len(t, newStackMedia, 2)
+
+		var oldStack models.MediaStack
+		require.NoError(t, db.First(&oldStack, stack.ID).Error)
+		assert.True(t, oldStack.Manual)
+		assert.Equal(t, media[0].ID, oldStack.CoverID, "old stack should get a new cover when its cover is split off")
+
+		assert.ElementsMatch(t, []string{"frame_1", "frame_2", "single"}, timelineTitles())
+	})
+
+	t.Run("other users can't change stacks", func(t *testing.T) {
+		anotherUser, err := models.RegisterUser(db, "user2", &password, false)
+		require.NoError(t, err)
+
+		_, err = actions.SetMediaStackCover(db, anotherUser, media[2].ID)
+		assert.Error(t, err)
+
+		_, err = actions.SplitMediaStack(db, anotherUser, []int{media[2].ID})
+		assert.Error(t, err)
+	})
+}
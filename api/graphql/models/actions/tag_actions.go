@@ -0,0 +1,301 @@
+package actions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/scanner/externaltools/exiftool"
+	"github.com/photoview/photoview/api/scanner/xmp_sidecar"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// userAlbumIDsQuery returns a subquery of the ids of the albums of the user
+func userAlbumIDsQuery(db *gorm.DB, user *models.User) *gorm.DB {
+	return db.Table("user_albums").Select("user_albums.album_id").Where("user_albums.user_id = ?", user.ID)
+}
+
+// checkUserMedia returns an error if one of the media is not in the albums of the user
+func checkUserMedia(db *gorm.DB, user *models.User, mediaIDs []int) error {
+	if len(mediaIDs) == 0 {
+		return errors.New("no media given")
+	}
+
+	var foundIDs []int
+	if err := db.Model(&models.Media{}).
+		Where("media.id IN ?", mediaIDs).
+		Where("media.album_id IN (?)", userAlbumIDsQuery(db, user)).
+		Pluck("media.id", &foundIDs).Error; err != nil {
+		return errors.Wrap(err, "get media of user")
+	}
+
+	found := make(map[int]bool, len(foundIDs))
+	for _, id := range foundIDs {
+		found[id] = true
+	}
+
+	for _, id := range mediaIDs {
+		if !found[id] {
+			return fmt.Errorf("media (%d) not found", id)
+		}
+	}
+
+	return nil
+}
+
+// userOwnedTag returns the tag if it belongs to the user, keywords of the media files can't be changed by users
+func userOwnedTag(db *gorm.DB, user *models.User, tagID int) (*models.Tag, error) {
+	var tag models.Tag
+	if err := db.Where("id = ? AND user_id = ?", tagID, user.ID).First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("tag (%d) not found or not owned by the user", tagID)
+		}
+		return nil, errors.Wrap(err, "get tag")
+	}
+
+	return &tag, nil
+}
+
+// MyTags returns the tags of the user and the keywords of the media of the user, ordered by name
+func MyTags(db *gorm.DB, user *models.User, paginate *models.Pagination) ([]*models.Tag, error) {
+	query := models.UserTagsQuery(db, user).Order("tags.name, tags.id")
+	query = models.FormatSQL(query, nil, paginate)
+
+	var tags []*models.Tag
+	if err := query.Find(&tags).Error; err != nil {
+		return nil, errors.Wrap(err, "get tags of user")
+	}
+
+	return tags, nil
+}
+
+// UserTag returns the tag if it is visible to the user
+func UserTag(db *gorm.DB, user *models.User, tagID int) (*models.Tag, error) {
+	var tag models.Tag
+	if err := models.UserTagsQuery(db, user).Where("tags.id = ?", tagID).First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("tag (%d) not found", tagID)
+		}
+		return nil, errors.Wrap(err, "get tag")
+	}
+
+	return &tag, nil
+}
+
+// tagMediaQuery returns a query of the media with the tag, that are in the albums of the user
+func tagMediaQuery(db *gorm.DB, user *models.User, tag *models.Tag) *gorm.DB {
+	return db.Model(&models.Media{}).
+		Where("media.id IN (?)", db.Model(&models.MediaTag{}).Select("media_tags.media_id").Where("media_tags.tag_id = ?", tag.ID)).
+		Where("media.album_id IN (?)", userAlbumIDsQuery(db, user))
+}
+
+// TagMedia returns the media of the user with the tag, newest first unless another order is given
+func TagMedia(db *gorm.DB, user *models.User, tag *models.Tag, order *models.Ordering, paginate *models.Pagination) ([]*models.Media, error) {
+	query := tagMediaQuery(db, user, tag)
+	if order == nil || order.OrderBy == nil {
+		query = query.Order("media.date_shot DESC, media.id DESC")
+	}
+	query = models.FormatSQL(query, order, paginate)
+
+	var media []*models.Media
+	if err := query.Find(&media).Error; err != nil {
+		return nil, errors.Wrap(err, "get media of tag")
+	}
+
+	return media, nil
+}
+
+// TagMediaCount returns the number of media of the user with the tag
+func TagMediaCount(db *gorm.DB, user *models.User, tag *models.Tag) (int, error) {
+	var count int64
+	if err := tagMediaQuery(db, user, tag).Count(&count).Error; err != nil {
+		return -1, errors.Wrap(err, "count media of tag")
+	}
+
+	return int(count), nil
+}
+
+// MediaTags returns the tags of the media visible to the user, and its keywords.
+// Only the keywords are returned if `user` is nil, for media shared by a share token.
+func MediaTags(db *gorm.DB, user *models.User, mediaID int) ([]*models.Tag, error) {
+	query := db.
+		Joins("JOIN media_tags ON media_tags.tag_id = tags.id").
+		Where("media_tags.media_id = ?", mediaID).
+		Order("tags.name, tags.id")
+
+	if user == nil {
+		query = query.Where("tags.user_id IS NULL")
+	} else {
+		query = query.Where("tags.user_id IS NULL OR tags.user_id = ?", user.ID)
+	}
+
+	var tags []*models.Tag
+	if err := query.Find(&tags).Error; err != nil {
+		return nil, errors.Wrap(err, "get tags of media")
+	}
+
+	return tags, nil
+}
+
+// AddMediaTags adds the tags of the user with the given names to all the media.
+// Tags the user doesn't have yet are created.
+func AddMediaTags(db *gorm.DB, user *models.User, mediaIDs []int, names []string) ([]*models.Tag, error) {
+	if len(models.NormalizeTagNames(names)) == 0 {
+		return nil, errors.New("no tag names given")
+	}
+
+	if err := checkUserMedia(db, user, mediaIDs); err != nil {
+		return nil, err
+	}
+
+	var tags []*models.Tag
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		tags, err = models.FindOrCreateTags(tx, &user.ID, names)
+		if err != nil {
+			return err
+		}
+
+		tagIDs := make([]int, 0, len(tags))
+		for _, tag := range tags {
+			tagIDs = append(tagIDs, tag.ID)
+		}
+
+		return models.LinkMediaTags(tx, mediaIDs, tagIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	writeBackKeywords(db, mediaIDs, tagNames(tags), nil)
+
+	return tags, nil
+}
+
+// RemoveMediaTags removes the tags of the user from all the media
+func RemoveMediaTags(db *gorm.DB, user *models.User, mediaIDs []int, tagIDs []int) ([]*models.Tag, error) {
+	if err := checkUserMedia(db, user, mediaIDs); err != nil {
+		return nil, err
+	}
+
+	tags := make([]*models.Tag, 0, len(tagIDs))
+	for _, tagID := range tagIDs {
+		tag, err := userOwnedTag(db, user, tagID)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	if len(tagIDs) == 0 {
+		return tags, nil
+	}
+
+	if err := db.Where("media_id IN ? AND tag_id IN ?", mediaIDs, tagIDs).Delete(&models.MediaTag{}).Error; err != nil {
+		return nil, errors.Wrap(err, "remove tags from media")
+	}
+
+	writeBackKeywords(db, mediaIDs, nil, tagNames(tags))
+
+	return tags, nil
+}
+
+// RenameTag changes the name of a tag of the user
+func RenameTag(db *gorm.DB, user *models.User, tagID int, name string) (*models.Tag, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("tag name must not be empty")
+	}
+
+	tag, err := userOwnedTag(db, user, tagID)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing int64
+	if err := db.Model(&models.Tag{}).
+		Where("name_hash = ? AND id <> ?", models.TagNameHash(&user.ID, name), tag.ID).
+		Count(&existing).Error; err != nil {
+		return nil, errors.Wrap(err, "check tag name")
+	}
+
+	if existing > 0 {
+		return nil, fmt.Errorf("a tag named %q already exists", name)
+	}
+
+	oldName := tag.Name
+	tag.Name = name
+	if err := db.Save(tag).Error; err != nil {
+		return nil, errors.Wrap(err, "rename tag")
+	}
+
+	mediaIDs, err := taggedMediaIDs(db, tag)
+	if err != nil {
+		return nil, err
+	}
+	writeBackKeywords(db, mediaIDs, []string{name}, []string{oldName})
+
+	return tag, nil
+}
+
+// DeleteTag deletes a tag of the user, and removes it from all media
+func DeleteTag(db *gorm.DB, user *models.User, tagID int) (*models.Tag, error) {
+	tag, err := userOwnedTag(db, user, tagID)
+	if err != nil {
+		return nil, err
+	}
+
+	mediaIDs, err := taggedMediaIDs(db, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", tag.ID).Delete(&models.MediaTag{}).Error; err != nil {
+			return errors.Wrap(err, "remove tag from media")
+		}
+
+		if err := tx.Delete(tag).Error; err != nil {
+			return errors.Wrap(err, "delete tag")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	writeBackKeywords(db, mediaIDs, nil, []string{tag.Name})
+
+	return tag, nil
+}
+
+func tagNames(tags []*models.Tag) []string {
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
+
+// taggedMediaIDs returns the ids of all media with the tag
+func taggedMediaIDs(db *gorm.DB, tag *models.Tag) ([]int, error) {
+	var mediaIDs []int
+	if err := db.Model(&models.MediaTag{}).Where("tag_id = ?", tag.ID).Pluck("media_id", &mediaIDs).Error; err != nil {
+		return nil, errors.Wrap(err, "get media of tag")
+	}
+
+	return mediaIDs, nil
+}
+
+// writeBackKeywords adds and removes the keywords in the XMP sidecars of the media, where writing sidecars is enabled
+func writeBackKeywords(db *gorm.DB, mediaIDs []int, add []string, remove []string) {
+	if len(mediaIDs) == 0 {
+		return
+	}
+
+	xmp_sidecar.WriteBackMedia(db, mediaIDs, func(*models.Media) (exiftool.XMPUpdate, error) {
+		return exiftool.XMPUpdate{AddKeywords: add, RemoveKeywords: remove}, nil
+	})
+}
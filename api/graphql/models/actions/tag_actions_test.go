@@ -0,0 +1,163 @@
+package actions_test
+
+import (
+	"testing"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/graphql/models/actions"
+	"github.com/photoview/photoview/api/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTags(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	password := "1234"
+	user, err := models.RegisterUser(db, "user", &password, false)
+	require.NoError(t, err)
+
+	anotherUser, err := models.RegisterUser(db, "another", &password, false)
+	require.NoError(t, err)
+
+	album := models.Album{
+		Title: "album",
+		Path:  "/photos",
+	}
+	require.NoError(t, db.Save(&album).Error)
+	require.NoError(t, db.Model(&user).Association("Albums").Append(&album))
+
+	otherAlbum := models.Album{
+		Title: "other",
+		Path:  "/other",
+	}
+	require.NoError(t, db.Save(&otherAlbum).Error)
+	require.NoError(t, db.Model(&anotherUser).Association("Albums").Append(&otherAlbum))
+
+	media := []models.Media{
+		{Title: "beach", Path: "/photos/beach", AlbumID: album.ID},
+		{Title: "forest", Path: "/photos/forest", AlbumID: album.ID},
+		{Title: "city", Path: "/other/city", AlbumID: otherAlbum.ID},
+	}
+	require.NoError(t, db.Save(&media).Error)
+
+	require.NoError(t, models.SetMediaKeywords(db, &media[0], []string{"summer", " holiday ", "summer"}))
+	require.NoError(t, models.SetMediaKeywords(db, &media[2], []string{"night"}))
+
+	tagNames := func(tags []*models.Tag) []string {
+		names := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			names = append(names, tag.Name)
+		}
+		return names
+	}
+
+	t.Run("keywords", func(t *testing.T) {
+		tags, err := actions.MyTags(db, user, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"holiday", "summer"}, tagNames(tags))
+		assert.True(t, tags[0].Keyword())
+
+		tags, err = actions.MyTags(db, anotherUser, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"night"}, tagNames(tags))
+
+		// Keywords are replaced when the file is parsed again
+		require.NoError(t, models.SetMediaKeywords(db, &media[0], []string{"summer"}))
+		tags, err = actions.MediaTags(db, nil, media[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"summer"}, tagNames(tags))
+	})
+
+	t.Run("add tags", func(t *testing.T) {
+		tags, err := actions.AddMediaTags(db, user, []int{media[0].ID, media[1].ID}, []string{"favorites", "summer"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"favorites", "summer"}, tagNames(tags))
+		for _, tag := range tags {
+			assert.False(t, tag.Keyword())
+		}
+
+		// Adding the same tags again doesn't create new tags
+		again, err := actions.AddMediaTags(db, user, []int{media[0].ID}, []string{"favorites"})
+		require.NoError(t, err)
+		assert.Equal(t, tags[0].ID, again[0].ID)
+
+		mediaTags, err := actions.MediaTags(db, user, media[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"favorites", "summer", "summer"}, tagNames(mediaTags))
+
+		mediaTags, err = actions.MediaTags(db, anotherUser, media[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"summer"}, tagNames(mediaTags), "other users only see the keywords")
+
+		tagMedia, err := actions.TagMedia(db, user, tags[0], nil, nil)
+		require.NoError(t, err)
+		assert.Len(t, tagMedia, 2)
+
+		count, err := actions.TagMediaCount(db, user, tags[0])
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		_, err = actions.AddMediaTags(db, user, []int{media[2].ID}, []string{"favorites"})
+		assert.Error(t, err, "media of other users can't be tagged")
+
+		_, err = actions.UserTag(db, anotherUser, tags[0].ID)
+		assert.Error(t, err, "tags of other users are not visible")
+	})
+
+	t.Run("remove tags", func(t *testing.T) {
+		tag, err := actions.AddMediaTags(db, user, []int{media[1].ID}, []string{"remove me"})
+		require.NoError(t, err)
+
+		_, err = actions.RemoveMediaTags(db, user, []int{media[1].ID}, []int{tag[0].ID})
+		require.NoError(t, err)
+
+		count, err := actions.TagMediaCount(db, user, tag[0])
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+
+		keywords, err := actions.MediaTags(db, nil, media[0].ID)
+		require.NoError(t, err)
+		_, err = actions.RemoveMediaTags(db, user, []int{media[0].ID}, []int{keywords[0].ID})
+		assert.Error(t, err, "keywords can't be removed by users")
+	})
+
+	t.Run("rename and delete tags", func(t *testing.T) {
+		tags, err := actions.AddMediaTags(db, user, []int{media[1].ID}, []string{"trees", "woods"})
+		require.NoError(t, err)
+
+		_, err = actions.RenameTag(db, user, tags[0].ID, "woods")
+		assert.Error(t, err, "tag names are unique for the user")
+
+		renamed, err := actions.RenameTag(db, user, tags[0].ID, "nature")
+		require.NoError(t, err)
+		assert.Equal(t, "nature", renamed.Name)
+
+		_, err = actions.DeleteTag(db, anotherUser, tags[1].ID)
+		assert.Error(t, err)
+
+		_, err = actions.DeleteTag(db, user, tags[1].ID)
+		require.NoError(t, err)
+
+		mediaTags, err := actions.MediaTags(db, user, media[1].ID)
+		require.NoError(t, err)
+		assert.NotContains(t, tagNames(mediaTags), "woods")
+		assert.Contains(t, tagNames(mediaTags), "nature")
+	})
+	t.Run("orphan keywords are deleted", func(t *testing.T) {
+		// "holiday" was removed from the keywords of the beach photo when it was parsed again
+		tags, err := actions.AddMediaTags(db, user, []int{media[1].ID}, []string{"unused"})
+		require.NoError(t, err)
+		_, err = actions.RemoveMediaTags(db, user, []int{media[1].ID}, []int{tags[0].ID})
+		require.NoError(t, err)
+
+		require.NoError(t, models.DeleteOrphanKeywords(db))
+
+		var names []string
+		require.NoError(t, db.Model(&models.Tag{}).Where("user_id IS NULL").Order("name").Pluck("name", &names).Error)
+		assert.Equal(t, []string{"night", "summer"}, names)
+
+		_, err = actions.UserTag(db, user, tags[0].ID)
+		assert.NoError(t, err, "tags of users are kept")
+	})
+}
@@ -1,21 +1,36 @@
 package actions
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/photoview/photoview/api/database/drivers"
 	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/pkg/errors"
 	"gorm.io/gorm"
 )
 
-func MyTimeline(db *gorm.DB, user *models.User, paginate *models.Pagination, onlyFavorites *bool,
+// TimelineFilter limits the media of the timeline of the user, nil conditions are left out
+type TimelineFilter struct {
+	OnlyFavorites *bool
+	MinRating     *int
+	ColorLabel    *models.ColorLabel
+	MediaType     *models.MediaType
+	// Media in the album or its sub albums
+	AlbumID *int
+}
+
+func MyTimeline(db *gorm.DB, user *models.User, paginate *models.Pagination, filter TimelineFilter,
 	fromDate *time.Time) ([]*models.Media, error) {
 
 	const albumsTitleASC = "albums.title ASC"
 
-	query := db.
-		Joins("JOIN albums ON media.album_id = albums.id").
-		Where("albums.id IN (?)", db.Table("user_albums").Select("user_albums.album_id").Where("user_id = ?", user.ID))
+	query, err := timelineQuery(db, user, filter)
+	if err != nil {
+		return nil, err
+	}
+	query = query.Joins("JOIN albums ON media.album_id = albums.id")
 
 	switch drivers.GetDatabaseDriverType(db) {
 	case drivers.POSTGRES:
@@ -43,7 +58,141 @@ func MyTimeline(db *gorm.DB, user *models.User, paginate *models.Pagination, onl
 		query = query.Where("media.date_shot < ?", fromDate)
 	}
 
-	if onlyFavorites != nil && *onlyFavorites {
+	query = models.FormatSQL(query, nil, paginate)
+
+	var media []*models.Media
+	if err := query.Find(&media).Error; err != nil {
+		return nil, err
+	}
+
+	return media, nil
+}
+
+// MyTimelineConnection returns a page of the timeline of the user, the newest media first
+func MyTimelineConnection(db *gorm.DB, user *models.User, args models.ConnectionArgs,
+	filter TimelineFilter) (*models.MediaConnection, error) {
+
+	query, err := timelineQuery(db, user, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	media, pageInfo, err := models.PaginateConnection(query, models.MediaDateShotColumn, models.MediaIDColumn, true, args, models.MediaCursor)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewMediaConnection(media, pageInfo), nil
+}
+
+// MyTimelineBuckets counts the media of the timeline of the user per year, month or day, the newest first.
+// Each bucket has the offset of its first media in the timeline, and a cursor to page from it with MyTimelineConnection.
+func MyTimelineBuckets(db *gorm.DB, user *models.User, granularity models.TimelineGranularity,
+	filter TimelineFilter) ([]*models.TimelineBucket, error) {
+
+	if !granularity.IsValid() {
+		return nil, fmt.Errorf("invalid timeline granularity %q", granularity)
+	}
+
+	query, err := timelineQuery(db, user, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var year, month, day string
+	switch drivers.GetDatabaseDriverType(db) {
+	case drivers.POSTGRES:
+		year = "CAST(EXTRACT(YEAR FROM media.date_shot) AS INTEGER)"
+		month = "CAST(EXTRACT(MONTH FROM media.date_shot) AS INTEGER)"
+		day = "CAST(EXTRACT(DAY FROM media.date_shot) AS INTEGER)"
+	case drivers.SQLITE:
+		year = "CAST(strftime('%Y', media.date_shot) AS INTEGER)"
+		month = "CAST(strftime('%m', media.date_shot) AS INTEGER)"
+		day = "CAST(strftime('%d', media.date_shot) AS INTEGER)"
+	default:
+		year = "YEAR(media.date_shot)"
+		month = "MONTH(media.date_shot)"
+		day = "DAY(media.date_shot)"
+	}
+
+	// Periods that aren't counted are selected as 1, the first month or day
+	groups := []string{year}
+	columns := []string{year + " AS year", "1 AS month", "1 AS day"}
+	switch granularity {
+	case models.TimelineGranularityMonth:
+		groups = append(groups, month)
+		columns[1] = month + " AS month"
+	case models.TimelineGranularityDay:
+		groups = append(groups, month, day)
+		columns[1] = month + " AS month"
+		columns[2] = day + " AS day"
+	}
+
+	query = query.Select(strings.Join(columns, ", ") + ", COUNT(*) AS count").
+		Group(strings.Join(groups, ", "))
+	for _, group := range groups {
+		query = query.Order(group + " DESC")
+	}
+
+	var rows []struct {
+		Year  int
+		Month int
+		Day   int
+		Count int
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, errors.Wrap(err, "count media of timeline")
+	}
+
+	buckets := make([]*models.TimelineBucket, 0, len(rows))
+	offset := 0
+	for _, row := range rows {
+		bucket := models.TimelineBucket{
+			Date:   time.Date(row.Year, time.Month(row.Month), row.Day, 0, 0, 0, 0, time.UTC),
+			Year:   row.Year,
+			Count:  row.Count,
+			Offset: offset,
+		}
+
+		end := bucket.Date.AddDate(1, 0, 0)
+		switch granularity {
+		case models.TimelineGranularityMonth:
+			bucket.Month = &row.Month
+			end = bucket.Date.AddDate(0, 1, 0)
+		case models.TimelineGranularityDay:
+			bucket.Month = &row.Month
+			bucket.Day = &row.Day
+			end = bucket.Date.AddDate(0, 0, 1)
+		}
+
+		// The timeline is ordered by date and id descending, so the media after a cursor at the end of the period,
+		// with an id lower than any media, start with the newest media of the period
+		bucket.Cursor = models.Cursor{Date: end, ID: 0}.Encode()
+
+		buckets = append(buckets, &bucket)
+		offset += row.Count
+	}
+
+	return buckets, nil
+}
+
+// timelineQuery returns a query of the media in the timeline of the user matching the filter
+func timelineQuery(db *gorm.DB, user *models.User, filter TimelineFilter) (*gorm.DB, error) {
+
+	query := db.Model(&models.Media{}).
+		Where("media.album_id IN (?)", db.Table("user_albums").Select("user_albums.album_id").Where("user_id = ?", user.ID))
+
+	// Leave out duplicates hidden by the user
+	query = query.
+		Where("media.id NOT IN (?)", db.Table("user_media_data").
+			Select("user_media_data.media_id").
+			Where("user_media_data.user_id = ?", user.ID).
+			Where("user_media_data.hidden"))
+
+	// Only list the cover of burst and bracketing stacks
+	query = query.Where("media.id NOT IN (?)", models.HiddenStackMediaQuery(db))
+
+	if filter.OnlyFavorites != nil && *filter.OnlyFavorites {
 		query = query.
 			Where("media.id IN (?)", db.Table("user_media_data").
 				Select("user_media_data.media_id").
@@ -51,12 +200,16 @@ func MyTimeline(db *gorm.DB, user *models.User, paginate *models.Pagination, onl
 				Where("user_media_data.favorite"))
 	}
 
-	query = models.FormatSQL(query, nil, paginate)
+	if filter.MediaType != nil {
+		query = query.Where("media.type = ?", filter.MediaType.Normalized())
+	}
 
-	var media []*models.Media
-	if err := query.Find(&media).Error; err != nil {
-		return nil, err
+	if filter.AlbumID != nil {
+		if _, err := Album(db, user, *filter.AlbumID); err != nil {
+			return nil, err
+		}
+		query = query.Where("media.album_id IN (?)", models.AlbumTreeIDsQuery(db, *filter.AlbumID))
 	}
 
-	return media, nil
+	return models.FilterMediaByRating(query, user.ID, filter.MinRating, filter.ColorLabel), nil
 }
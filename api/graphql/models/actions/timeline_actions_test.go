@@ -8,6 +8,7 @@ import (
 	"github.com/photoview/photoview/api/graphql/models/actions"
 	"github.com/photoview/photoview/api/test_utils"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMyTimeline(t *testing.T) {
@@ -88,7 +89,7 @@ func TestMyTimeline(t *testing.T) {
 	assert.NoError(t, db.Model(&anotherUser).Association("Albums").Append(&anotherAlbum))
 
 	t.Run("MyTimeline with no filters", func(t *testing.T) {
-		timelineMedia, err := actions.MyTimeline(db, user, nil, nil, nil)
+		timelineMedia, err := actions.MyTimeline(db, user, nil, actions.TimelineFilter{}, nil)
 
 		assert.NoError(t, err)
 		assert.Len(t, timelineMedia, 4)
@@ -101,7 +102,7 @@ func TestMyTimeline(t *testing.T) {
 
 	t.Run("MyTimeline with only favorites", func(t *testing.T) {
 		favorites := true
-		timelineMedia, err := actions.MyTimeline(db, user, nil, &favorites, nil)
+		timelineMedia, err := actions.MyTimeline(db, user, nil, actions.TimelineFilter{OnlyFavorites: &favorites}, nil)
 
 		assert.NoError(t, err)
 		assert.Len(t, timelineMedia, 1)
@@ -109,9 +110,212 @@ func TestMyTimeline(t *testing.T) {
 
 	t.Run("MyTimeline before date", func(t *testing.T) {
 		beforeDate := time.Unix(1629792000, 0) // Aug 24 2021 08:00:00
-		timelineMedia, err := actions.MyTimeline(db, user, nil, nil, &beforeDate)
+		timelineMedia, err := actions.MyTimeline(db, user, nil, actions.TimelineFilter{}, &beforeDate)
 
 		assert.NoError(t, err)
 		assert.Len(t, timelineMedia, 2)
 	})
 }
+
+func TestMyTimelineConnection(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	password := "1234"
+	user, err := models.RegisterUser(db, "user", &password, false)
+	require.NoError(t, err)
+
+	album := models.Album{Title: "photos", Path: "/photos"}
+	require.NoError(t, db.Save(&album).Error)
+	require.NoError(t, db.Model(&user).Association("Albums").Append(&album))
+
+	date := func(day int) time.Time {
+		return time.Date(2021, time.July, day, 12, 0, 0, 0, time.UTC)
+	}
+
+	// pic3 and pic4 were shot at the same time, the newest id comes first
+	media := []models.Media{
+		{Title: "pic1", Path: "/photos/pic1", AlbumID: album.ID, DateShot: date(1)},
+		{Title: "pic2", Path: "/photos/pic2", AlbumID: album.ID, DateShot: date(2)},
+		{Title: "pic3", Path: "/photos/pic3", AlbumID: album.ID, DateShot: date(3)},
+		{Title: "pic4", Path: "/photos/pic4", AlbumID: album.ID, DateShot: date(3)},
+		{Title: "pic5", Path: "/photos/pic5", AlbumID: album.ID, DateShot: date(5)},
+	}
+	require.NoError(t, db.Save(&media).Error)
+
+	page := func(t *testing.T, args models.ConnectionArgs) ([]string, *models.PageInfo) {
+		connection, err := actions.MyTimelineConnection(db, user, args, actions.TimelineFilter{})
+		require.NoError(t, err)
+
+		titles := make([]string, 0, len(connection.Edges))
+		for _, edge := range connection.Edges {
+			titles = append(titles, edge.Node.Title)
+		}
+		return titles, connection.PageInfo
+	}
+
+	two := 2
+
+	t.Run("forwards", func(t *testing.T) {
+		titles, pageInfo := page(t, models.ConnectionArgs{First: &two})
+		assert.Equal(t, []string{"pic5", "pic4"}, titles)
+		assert.True(t, pageInfo.HasNextPage)
+		assert.False(t, pageInfo.HasPreviousPage)
+
+		titles, pageInfo = page(t, models.ConnectionArgs{First: &two, After: pageInfo.EndCursor})
+		assert.Equal(t, []string{"pic3", "pic2"}, titles)
+		assert.True(t, pageInfo.HasNextPage)
+		assert.True(t, pageInfo.HasPreviousPage)
+
+		titles, pageInfo = page(t, models.ConnectionArgs{First: &two, After: pageInfo.EndCursor})
+		assert.Equal(t, []string{"pic1"}, titles)
+		assert.False(t, pageInfo.HasNextPage)
+	})
+
+	t.Run("backwards", func(t *testing.T) {
+		titles, pageInfo := page(t, models.ConnectionArgs{Last: &two})
+		assert.Equal(t, []string{"pic2", "pic1"}, titles)
+		assert.True(t, pageInfo.HasPreviousPage)
+		assert.False(t, pageInfo.HasNextPage)
+
+		titles, pageInfo = page(t, models.ConnectionArgs{Last: &two, Before: pageInfo.StartCursor})
+		assert.Equal(t, []string{"pic4", "pic3"}, titles)
+		assert.True(t, pageInfo.HasPreviousPage)
+		assert.True(t, pageInfo.HasNextPage)
+	})
+
+	t.Run("new media don't shift pages", func(t *testing.T) {
+		_, pageInfo := page(t, models.ConnectionArgs{First: &two})
+
+		newMedia := models.Media{Title: "new", Path: "/photos/new", AlbumID: album.ID, DateShot: date(6)}
+		require.NoError(t, db.Save(&newMedia).Error)
+		defer db.Delete(&newMedia)
+
+		titles, _ := page(t, models.ConnectionArgs{First: &two, After: pageInfo.EndCursor})
+		assert.Equal(t, []string{"pic3", "pic2"}, titles)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := actions.MyTimelineConnection(db, user, models.ConnectionArgs{First: &two, Last: &two}, actions.TimelineFilter{})
+		assert.Error(t, err)
+
+		cursor := "invalid"
+		_, err = actions.MyTimelineConnection(db, user, models.ConnectionArgs{After: &cursor}, actions.TimelineFilter{})
+		assert.Error(t, err)
+	})
+}
+
+func TestMyTimelineBuckets(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	password := "1234"
+	user, err := models.RegisterUser(db, "user", &password, false)
+	require.NoError(t, err)
+
+	rootAlbum := models.Album{Title: "photos", Path: "/photos"}
+	require.NoError(t, db.Save(&rootAlbum).Error)
+	childAlbum := models.Album{Title: "2017", Path: "/photos/2017", ParentAlbumID: &rootAlbum.ID}
+	require.NoError(t, db.Save(&childAlbum).Error)
+	require.NoError(t, db.Model(&user).Association("Albums").Append([]*models.Album{&rootAlbum, &childAlbum}))
+
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+	}
+
+	media := []models.Media{
+		{Title: "pic1", Path: "/photos/2017/pic1", AlbumID: childAlbum.ID, Type: models.MediaTypePhoto, DateShot: date(2017, 3, 4)},
+		{Title: "pic2", Path: "/photos/2017/pic2", AlbumID: childAlbum.ID, Type: models.MediaTypePhoto, DateShot: date(2017, 3, 4)},
+		{Title: "vid1", Path: "/photos/2017/vid1", AlbumID: childAlbum.ID, Type: models.MediaTypeVideo, DateShot: date(2017, 8, 20)},
+		{Title: "pic3", Path: "/photos/pic3", AlbumID: rootAlbum.ID, Type: models.MediaTypePhoto, DateShot: date(2021, 7, 10)},
+		{Title: "pic4", Path: "/photos/pic4", AlbumID: rootAlbum.ID, Type: models.MediaTypePhoto, DateShot: date(2021, 7, 12)},
+	}
+	require.NoError(t, db.Save(&media).Error)
+
+	_, err = user.FavoriteMedia(db, media[2].ID, true)
+	require.NoError(t, err)
+
+	type bucket struct {
+		date   string
+		count  int
+		offset int
+	}
+
+	buckets := func(t *testing.T, granularity models.TimelineGranularity, onlyFavorites *bool,
+		mediaType *models.MediaType, albumID *int) []bucket {
+
+		filter := actions.TimelineFilter{OnlyFavorites: onlyFavorites, MediaType: mediaType, AlbumID: albumID}
+		result, err := actions.MyTimelineBuckets(db, user, granularity, filter)
+		require.NoError(t, err)
+
+		buckets := make([]bucket, 0, len(result))
+		for _, b := range result {
+			buckets = append(buckets, bucket{b.Date.Format("2006-01-02"), b.Count, b.Offset})
+		}
+		return buckets
+	}
+
+	t.Run("granularity", func(t *testing.T) {
+		assert.Equal(t, []bucket{{"2021-01-01", 2, 0}, {"2017-01-01", 3, 2}},
+			buckets(t, models.TimelineGranularityYear, nil, nil, nil))
+		assert.Equal(t, []bucket{{"2021-07-01", 2, 0}, {"2017-08-01", 1, 2}, {"2017-03-01", 2, 3}},
+			buckets(t, models.TimelineGranularityMonth, nil, nil, nil))
+		assert.Equal(t, []bucket{{"2021-07-12", 1, 0}, {"2021-07-10", 1, 1}, {"2017-08-20", 1, 2}, {"2017-03-04", 2, 3}},
+			buckets(t, models.TimelineGranularityDay, nil, nil, nil))
+
+		result, err := actions.MyTimelineBuckets(db, user, models.TimelineGranularityMonth, actions.TimelineFilter{})
+		require.NoError(t, err)
+		assert.Nil(t, result[0].Day)
+		require.NotNil(t, result[0].Month)
+		assert.Equal(t, 7, *result[0].Month)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		favorites := true
+		assert.Equal(t, []bucket{{"2017-01-01", 1, 0}},
+			buckets(t, models.TimelineGranularityYear, &favorites, nil, nil))
+
+		// Media types of the GraphQL API are capitalized
+		video := models.MediaType("Video")
+		assert.Equal(t, []bucket{{"2017-08-01", 1, 0}},
+			buckets(t, models.TimelineGranularityMonth, nil, &video, nil))
+
+		assert.Equal(t, []bucket{{"2017-01-01", 3, 0}},
+			buckets(t, models.TimelineGranularityYear, nil, nil, &childAlbum.ID))
+
+		anotherUser, err := models.RegisterUser(db, "another", &password, false)
+		require.NoError(t, err)
+		_, err = actions.MyTimelineBuckets(db, anotherUser, models.TimelineGranularityYear, actions.TimelineFilter{AlbumID: &childAlbum.ID})
+		assert.Error(t, err)
+	})
+
+	t.Run("jump to a bucket", func(t *testing.T) {
+		result, err := actions.MyTimelineBuckets(db, user, models.TimelineGranularityYear, actions.TimelineFilter{})
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+
+		one := 1
+		connection, err := actions.MyTimelineConnection(db, user, models.ConnectionArgs{First: &one, After: &result[1].Cursor},
+			actions.TimelineFilter{})
+		require.NoError(t, err)
+		require.Len(t, connection.Edges, 1)
+		assert.Equal(t, "vid1", connection.Edges[0].Node.Title)
+
+		timeline, err := actions.MyTimeline(db, user, &models.Pagination{Limit: &one, Offset: &result[1].Offset}, actions.TimelineFilter{}, nil)
+		require.NoError(t, err)
+		require.Len(t, timeline, 1)
+		assert.Equal(t, "vid1", timeline[0].Title)
+	})
+
+	t.Run("jump to a bucket with the same filters", func(t *testing.T) {
+		photo := models.MediaType("Photo")
+		filter := actions.TimelineFilter{MediaType: &photo, AlbumID: &childAlbum.ID}
+
+		result, err := actions.MyTimelineBuckets(db, user, models.TimelineGranularityYear, filter)
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+
+		timeline, err := actions.MyTimeline(db, user, &models.Pagination{Offset: &result[0].Offset}, filter, nil)
+		require.NoError(t, err)
+		require.Len(t, timeline, result[0].Count)
+		assert.ElementsMatch(t, []string{"pic1", "pic2"}, []string{timeline[0].Title, timeline[1].Title})
+	})
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"crypto/md5"
 	"encoding/hex"
+	"fmt"
 
 	"gorm.io/gorm"
 )
@@ -18,6 +19,9 @@ type Album struct {
 	Path     string `gorm:"not null"`
 	PathHash string `gorm:"unique"`
 	CoverID  *int
+	// Edits of the media in this album and its sub albums are written to XMP sidecars,
+	// only enabled for libraries that are not mounted read-only
+	XMPWriteback bool `gorm:"not null;default:false"`
 }
 
 func (a *Album) FilePath() string {
@@ -56,6 +60,20 @@ func GetChildrenFromAlbums(db *gorm.DB, filter func(*gorm.DB) *gorm.DB, albumIDs
 	return children, err
 }
 
+// AlbumTreeIDsQuery returns a query of the ids of the album and all of its sub albums,
+// to be used as a subquery so the albums are walked by the database in the same query
+func AlbumTreeIDsQuery(db *gorm.DB, albumID int) *gorm.DB {
+	return db.Raw(`
+	WITH recursive sub_albums AS (
+		SELECT id FROM albums AS root WHERE id = ?
+		UNION ALL
+		SELECT child.id FROM albums AS child JOIN sub_albums ON child.parent_album_id = sub_albums.id
+	)
+
+	SELECT id FROM sub_albums
+	`, albumID)
+}
+
 func (a *Album) GetParents(db *gorm.DB, filter func(*gorm.DB) *gorm.DB) (parents []*Album, err error) {
 	return GetParentsFromAlbums(db, filter, a.ID)
 }
@@ -80,6 +98,22 @@ func GetParentsFromAlbums(db *gorm.DB, filter func(*gorm.DB) *gorm.DB, albumID i
 	return parents, err
 }
 
+// RootAlbumPath returns the path of the root album of the album, the topmost album without a parent
+func RootAlbumPath(db *gorm.DB, albumID int) (string, error) {
+	roots, err := GetParentsFromAlbums(db, func(query *gorm.DB) *gorm.DB {
+		return query.Where("parent_album_id IS NULL")
+	}, albumID)
+	if err != nil {
+		return "", err
+	}
+
+	if len(roots) == 0 {
+		return "", fmt.Errorf("root album of album (%d) not found", albumID)
+	}
+
+	return roots[0].Path, nil
+}
+
 func (a *Album) Thumbnail(db *gorm.DB) (*Media, error) {
 	var media Media
 
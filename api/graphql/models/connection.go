@@ -0,0 +1,194 @@
+package models
+
+import (
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultConnectionPageSize is the number of edges of a connection page, if neither `first` nor `last` is given
+const DefaultConnectionPageSize = 100
+
+// MaxConnectionPageSize is the largest number of edges of a connection page, larger `first` or `last` are clamped to it
+const MaxConnectionPageSize = 1000
+
+// ConnectionArgs are the Relay arguments selecting a page of a connection,
+// `first` and `after` page forwards and `last` and `before` page backwards
+type ConnectionArgs struct {
+	First  *int
+	After  *string
+	Last   *int
+	Before *string
+}
+
+// Cursor is the position of an item in a connection ordered by date and id.
+// The id breaks ties between items of the same date, so a cursor keeps its position when items are added.
+type Cursor struct {
+	Date time.Time
+	ID   int
+}
+
+// Encode returns the opaque string of the cursor that is given to clients
+func (c Cursor) Encode() string {
+	value := c.Date.UTC().Format(time.RFC3339Nano) + "|" + strconv.Itoa(c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+// DecodeCursor parses a cursor returned by Cursor.Encode
+func DecodeCursor(cursor string) (Cursor, error) {
+	invalid := fmt.Errorf("invalid cursor %q", cursor)
+
+	value, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return Cursor{}, invalid
+	}
+
+	date, id, found := strings.Cut(string(value), "|")
+	if !found {
+		return Cursor{}, invalid
+	}
+
+	parsedDate, err := time.Parse(time.RFC3339Nano, date)
+	if err != nil {
+		return Cursor{}, invalid
+	}
+
+	parsedID, err := strconv.Atoi(id)
+	if err != nil {
+		return Cursor{}, invalid
+	}
+
+	return Cursor{Date: parsedDate, ID: parsedID}, nil
+}
+
+// The columns connections of media are ordered by
+var (
+	MediaDateShotColumn = clause.Column{Table: "media", Name: "date_shot"}
+	MediaIDColumn       = clause.Column{Table: "media", Name: "id"}
+)
+
+// MediaCursor is the cursor of media in connections of media
+func MediaCursor(media *Media) Cursor {
+	return Cursor{Date: media.DateShot, ID: media.ID}
+}
+
+// ImageFaceCursor is the cursor of faces in connections of faces, the faces are ordered by the date of their media.
+// The media must be loaded.
+func ImageFaceCursor(imageFace *ImageFace) Cursor {
+	return Cursor{Date: imageFace.Media.DateShot, ID: imageFace.ID}
+}
+
+// NewMediaConnection returns the connection of a page of media
+func NewMediaConnection(media []*Media, pageInfo *PageInfo) *MediaConnection {
+	edges := make([]*MediaEdge, len(media))
+	for i, m := range media {
+		edges[i] = &MediaEdge{Cursor: MediaCursor(m).Encode(), Node: m}
+	}
+
+	return &MediaConnection{Edges: edges, PageInfo: pageInfo}
+}
+
+// NewImageFaceConnection returns the connection of a page of faces
+func NewImageFaceConnection(imageFaces []*ImageFace, pageInfo *PageInfo) *ImageFaceConnection {
+	edges := make([]*ImageFaceEdge, len(imageFaces))
+	for i, imageFace := range imageFaces {
+		edges[i] = &ImageFaceEdge{Cursor: ImageFaceCursor(imageFace).Encode(), Node: imageFace}
+	}
+
+	return &ImageFaceConnection{Edges: edges, PageInfo: pageInfo}
+}
+
+// PaginateConnection fetches the page of the query selected by the connection arguments.
+// The items are ordered by the date column and then the id column, descending if `desc` is true.
+// Pages are found by comparing with the cursors instead of an offset,
+// so deep pages are as fast as the first and don't shift when items are added.
+func PaginateConnection[T any](query *gorm.DB, dateColumn, idColumn clause.Column, desc bool,
+	args ConnectionArgs, cursorOf func(T) Cursor) ([]T, *PageInfo, error) {
+
+	if args.First != nil && args.Last != nil {
+		return nil, nil, errors.New("first and last can't be used together")
+	}
+
+	limit := DefaultConnectionPageSize
+	backwards := args.Last != nil
+	if args.First != nil {
+		limit = *args.First
+	} else if args.Last != nil {
+		limit = *args.Last
+	}
+
+	if limit < 0 {
+		return nil, nil, errors.New("the page size must not be negative")
+	}
+	limit = min(limit, MaxConnectionPageSize)
+
+	// beyond returns the condition of items after the cursor in the order of the connection, or before it
+	beyond := func(cursor Cursor, after bool) clause.Expr {
+		operator := ">"
+		if desc == after {
+			operator = "<"
+		}
+
+		return clause.Expr{
+			SQL:  fmt.Sprintf("(? %[1]s ? OR (? = ? AND ? %[1]s ?))", operator),
+			Vars: []any{dateColumn, cursor.Date, dateColumn, cursor.Date, idColumn, cursor.ID},
+		}
+	}
+
+	if args.After != nil {
+		cursor, err := DecodeCursor(*args.After)
+		if err != nil {
+			return nil, nil, err
+		}
+		query = query.Where(beyond(cursor, true))
+	}
+
+	if args.Before != nil {
+		cursor, err := DecodeCursor(*args.Before)
+		if err != nil {
+			return nil, nil, err
+		}
+		query = query.Where(beyond(cursor, false))
+	}
+
+	// A backwards page is fetched in reverse order, starting at the end
+	query = query.
+		Order(clause.OrderByColumn{Column: dateColumn, Desc: desc != backwards}).
+		Order(clause.OrderByColumn{Column: idColumn, Desc: desc != backwards})
+
+	// One more item than the page is fetched, to know if there is another page
+	var items []T
+	if err := query.Limit(limit + 1).Find(&items).Error; err != nil {
+		return nil, nil, errors.Wrap(err, "get page of connection")
+	}
+
+	morePages := len(items) > limit
+	if morePages {
+		items = items[:limit]
+	}
+
+	if backwards {
+		slices.Reverse(items)
+	}
+
+	pageInfo := PageInfo{
+		HasNextPage:     morePages && !backwards || args.Before != nil,
+		HasPreviousPage: morePages && backwards || args.After != nil,
+	}
+
+	if len(items) > 0 {
+		startCursor := cursorOf(items[0]).Encode()
+		endCursor := cursorOf(items[len(items)-1]).Encode()
+		pageInfo.StartCursor = &startCursor
+		pageInfo.EndCursor = &endCursor
+	}
+
+	return items, &pageInfo, nil
+}
@@ -0,0 +1,149 @@
+package models_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor(t *testing.T) {
+	cursor := models.Cursor{Date: time.Date(2021, time.July, 10, 12, 30, 15, 500, time.UTC), ID: 42}
+
+	decoded, err := models.DecodeCursor(cursor.Encode())
+	require.NoError(t, err)
+	assert.True(t, cursor.Date.Equal(decoded.Date))
+	assert.Equal(t, cursor.ID, decoded.ID)
+
+	for _, invalid := range []string{"", "not base64!", "aW52YWxpZA", cursor.Encode()[1:]} {
+		_, err := models.DecodeCursor(invalid)
+		assert.Errorf(t, err, "cursor %q", invalid)
+	}
+}
+
+func TestPaginateConnection(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	album := models.Album{Title: "photos", Path: "/photos"}
+	require.NoError(t, db.Save(&album).Error)
+
+	date := func(day int) time.Time {
+		return time.Date(2021, time.July, day, 12, 0, 0, 0, time.UTC)
+	}
+
+	// pic2, pic3 and pic4 were shot at the same time, their ids order them
+	media := []models.Media{
+		{Title: "pic1", Path: "/photos/pic1", AlbumID: album.ID, DateShot: date(1)},
+		{Title: "pic2", Path: "/photos/pic2", AlbumID: album.ID, DateShot: date(2)},
+		{Title: "pic3", Path: "/photos/pic3", AlbumID: album.ID, DateShot: date(2)},
+		{Title: "pic4", Path: "/photos/pic4", AlbumID: album.ID, DateShot: date(2)},
+		{Title: "pic5", Path: "/photos/pic5", AlbumID: album.ID, DateShot: date(3)},
+	}
+	require.NoError(t, db.Save(&media).Error)
+
+	page := func(t *testing.T, desc bool, args models.ConnectionArgs) ([]string, *models.PageInfo) {
+		query := db.Model(&models.Media{}).Where("media.album_id = ?", album.ID)
+		items, pageInfo, err := models.PaginateConnection(query, models.MediaDateShotColumn, models.MediaIDColumn,
+			desc, args, models.MediaCursor)
+		require.NoError(t, err)
+
+		titles := make([]string, 0, len(items))
+		for _, item := range items {
+			titles = append(titles, item.Title)
+		}
+		return titles, pageInfo
+	}
+
+	two := 2
+
+	t.Run("forwards with after", func(t *testing.T) {
+		titles, pageInfo := page(t, false, models.ConnectionArgs{First: &two})
+		assert.Equal(t, []string{"pic1", "pic2"}, titles)
+		assert.True(t, pageInfo.HasNextPage)
+		assert.False(t, pageInfo.HasPreviousPage)
+
+		titles, pageInfo = page(t, false, models.ConnectionArgs{First: &two, After: pageInfo.EndCursor})
+		assert.Equal(t, []string{"pic3", "pic4"}, titles)
+		assert.True(t, pageInfo.HasNextPage)
+		assert.True(t, pageInfo.HasPreviousPage)
+
+		titles, pageInfo = page(t, false, models.ConnectionArgs{First: &two, After: pageInfo.EndCursor})
+		assert.Equal(t, []string{"pic5"}, titles)
+		assert.False(t, pageInfo.HasNextPage)
+		assert.True(t, pageInfo.HasPreviousPage)
+
+		titles, pageInfo = page(t, false, models.ConnectionArgs{First: &two, After: pageInfo.EndCursor})
+		assert.Empty(t, titles)
+		assert.False(t, pageInfo.HasNextPage)
+		assert.Nil(t, pageInfo.StartCursor)
+		assert.Nil(t, pageInfo.EndCursor)
+	})
+
+	t.Run("backwards with before", func(t *testing.T) {
+		titles, pageInfo := page(t, false, models.ConnectionArgs{Last: &two})
+		assert.Equal(t, []string{"pic4", "pic5"}, titles)
+		assert.False(t, pageInfo.HasNextPage)
+		assert.True(t, pageInfo.HasPreviousPage)
+
+		titles, pageInfo = page(t, false, models.ConnectionArgs{Last: &two, Before: pageInfo.StartCursor})
+		assert.Equal(t, []string{"pic2", "pic3"}, titles)
+		assert.True(t, pageInfo.HasNextPage)
+		assert.True(t, pageInfo.HasPreviousPage)
+
+		titles, pageInfo = page(t, false, models.ConnectionArgs{Last: &two, Before: pageInfo.StartCursor})
+		assert.Equal(t, []string{"pic1"}, titles)
+		assert.True(t, pageInfo.HasNextPage)
+		assert.False(t, pageInfo.HasPreviousPage)
+	})
+
+	t.Run("ties on the date broken by id", func(t *testing.T) {
+		one := 1
+		var forwards, backwards []string
+
+		pageInfo := &models.PageInfo{HasNextPage: true}
+		for pageInfo.HasNextPage {
+			var titles []string
+			titles, pageInfo = page(t, true, models.ConnectionArgs{First: &one, After: pageInfo.EndCursor})
+			forwards = append(forwards, titles...)
+		}
+		assert.Equal(t, []string{"pic5", "pic4", "pic3", "pic2", "pic1"}, forwards)
+
+		pageInfo = &models.PageInfo{HasPreviousPage: true}
+		for pageInfo.HasPreviousPage {
+			var titles []string
+			titles, pageInfo = page(t, true, models.ConnectionArgs{Last: &one, Before: pageInfo.StartCursor})
+			backwards = append(titles, backwards...)
+		}
+		assert.Equal(t, forwards, backwards)
+	})
+
+	t.Run("page size clamped", func(t *testing.T) {
+		manyMedia := make([]models.Media, models.MaxConnectionPageSize)
+		for i := range manyMedia {
+			path := fmt.Sprintf("/photos/many%d", i)
+			manyMedia[i] = models.Media{Title: path, Path: path, AlbumID: album.ID, DateShot: date(4)}
+		}
+		require.NoError(t, db.CreateInBatches(&manyMedia, 100).Error)
+
+		tooMany := models.MaxConnectionPageSize + 1
+		titles, pageInfo := page(t, false, models.ConnectionArgs{First: &tooMany})
+		assert.Len(t, titles, models.MaxConnectionPageSize)
+		assert.True(t, pageInfo.HasNextPage)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		query := db.Model(&models.Media{})
+		_, _, err := models.PaginateConnection(query, models.MediaDateShotColumn, models.MediaIDColumn, false,
+			models.ConnectionArgs{First: &two, Last: &two}, models.MediaCursor)
+		assert.Error(t, err)
+
+		negative := -1
+		_, _, err = models.PaginateConnection(query, models.MediaDateShotColumn, models.MediaIDColumn, false,
+			models.ConnectionArgs{First: &negative}, models.MediaCursor)
+		assert.Error(t, err)
+	})
+}
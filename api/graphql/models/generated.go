@@ -25,12 +25,71 @@ type Coordinates struct {
 	Longitude float64 `json:"longitude"`
 }
 
+type CoordinatesInput struct {
+	// GPS latitude in degrees
+	Latitude float64 `json:"latitude"`
+	// GPS longitude in degrees
+	Longitude float64 `json:"longitude"`
+}
+
+// A group of photos that are exact or near-duplicates of each other
+type DuplicateGroup struct {
+	// The photos in the group, ordered by when they were scanned
+	Media []*Media `json:"media"`
+	// The largest difference between the perceptual hashes of two near-duplicate photos in the group,
+	// 0 means exact duplicates. The photos at the ends of a chain of near-duplicates may differ by more.
+	Distance int `json:"distance"`
+}
+
+// A page of faces, ordered by the date their media was shot and then by id
+type ImageFaceConnection struct {
+	Edges    []*ImageFaceEdge `json:"edges"`
+	PageInfo *PageInfo        `json:"pageInfo"`
+}
+
+// An edge of an `ImageFaceConnection`
+type ImageFaceEdge struct {
+	// Opaque cursor pointing at this face, built from the date of its media and its id
+	Cursor string     `json:"cursor"`
+	Node   *ImageFace `json:"node"`
+}
+
+// A page of media, ordered by the date the media was shot and then by id
+type MediaConnection struct {
+	Edges    []*MediaEdge `json:"edges"`
+	PageInfo *PageInfo    `json:"pageInfo"`
+}
+
 type MediaDownload struct {
 	// A description of the role of the media file
 	Title    string    `json:"title"`
 	MediaURL *MediaURL `json:"mediaUrl"`
 }
 
+// An edge of a `MediaConnection`
+type MediaEdge struct {
+	// Opaque cursor pointing at this media, built from its date and id
+	Cursor string `json:"cursor"`
+	Node   *Media `json:"node"`
+}
+
+// Corrected metadata of media, values that are not given are left as they are
+type MediaMetadataInput struct {
+	Title       *string `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+	// The date and time the media was shot, its timezone offset is kept as the timezone of the media
+	DateShot *time.Time `json:"dateShot,omitempty"`
+	// GPS coordinates of where the media was shot
+	Coordinates *CoordinatesInput `json:"coordinates,omitempty"`
+}
+
+// A photo encoded to one of the sizes configured on the server
+type MediaRendition struct {
+	// The name of the configured size
+	Name     string    `json:"name"`
+	MediaURL *MediaURL `json:"mediaUrl"`
+}
+
 type Mutation struct {
 }
 
@@ -59,6 +118,21 @@ type Ordering struct {
 	OrderDirection *OrderDirection `json:"order_direction,omitempty"`
 }
 
+// Information about a page of a connection, see the Relay cursor connections specification.
+// Pages are selected with `first` and `after` to page forwards, or `last` and `before` to page backwards,
+// using the opaque cursors of the edges. If neither `first` nor `last` is given, the first 100 edges are returned,
+// a page has at most 1000 edges.
+type PageInfo struct {
+	// Whether there are more edges after this page
+	HasNextPage bool `json:"hasNextPage"`
+	// Whether there are more edges before this page
+	HasPreviousPage bool `json:"hasPreviousPage"`
+	// The cursor of the first edge of the page, null if the page is empty
+	StartCursor *string `json:"startCursor,omitempty"`
+	// The cursor of the last edge of the page, null if the page is empty
+	EndCursor *string `json:"endCursor,omitempty"`
+}
+
 // Used to specify pagination on a list of items
 type Pagination struct {
 	// How many items to maximally fetch
@@ -67,6 +141,12 @@ type Pagination struct {
 	Offset *int `json:"offset,omitempty"`
 }
 
+// The number of media shot at a place
+type PlaceMediaCount struct {
+	Place      *Place `json:"place"`
+	MediaCount int    `json:"mediaCount"`
+}
+
 type Query struct {
 }
 
@@ -77,13 +157,27 @@ type ScannerResult struct {
 	Message  *string  `json:"message,omitempty"`
 }
 
+// A term of a search query that could not be parsed
+type SearchQueryError struct {
+	// The term as written in the query
+	Term string `json:"term"`
+	// Why the term could not be parsed
+	Message string `json:"message"`
+}
+
 type SearchResult struct {
 	// The string that was searched for
 	Query string `json:"query"`
 	// A list of albums that matched the query
 	Albums []*Album `json:"albums"`
-	// A list of media that matched the query
+	// A list of media that matched the query, the best matches first
 	Media []*Media `json:"media"`
+	// The number of albums that matched the query, regardless of the limit
+	TotalAlbums int `json:"totalAlbums"`
+	// The number of media that matched the query, regardless of the limit
+	TotalMedia int `json:"totalMedia"`
+	// Terms of the query that could not be parsed, they are left out of the search
+	Errors []*SearchQueryError `json:"errors"`
 }
 
 // Credentials used to identify and authenticate a share token
@@ -95,6 +189,48 @@ type ShareTokenCredentials struct {
 type Subscription struct {
 }
 
+// Media to shift the time of, media must match all the given conditions
+type TimeShiftFilter struct {
+	// Only the given media
+	MediaIds []int `json:"mediaIds,omitempty"`
+	// Only media of the given album and its sub albums
+	AlbumID *int `json:"albumId,omitempty"`
+	// Only media shot with the given camera model, as found in the exif data
+	Camera *string `json:"camera,omitempty"`
+	// Only media shot at or after this date
+	FromDate *time.Time `json:"fromDate,omitempty"`
+	// Only media shot at or before this date
+	ToDate *time.Time `json:"toDate,omitempty"`
+}
+
+// The date of a media before and after shifting its time
+type TimeShiftResult struct {
+	Media      *Media    `json:"media"`
+	DateBefore time.Time `json:"dateBefore"`
+	DateAfter  time.Time `json:"dateAfter"`
+	// The timezone offset to UTC in seconds after shifting the time, if known
+	OffsetSecAfter *int `json:"offsetSecAfter,omitempty"`
+}
+
+// The media of the timeline in a year, month or day
+type TimelineBucket struct {
+	// The first day of the period
+	Date time.Time `json:"date"`
+	Year int       `json:"year"`
+	// The month of the period, null for years
+	Month *int `json:"month,omitempty"`
+	// The day of the month of the period, null for years and months
+	Day *int `json:"day,omitempty"`
+	// The number of media in the period
+	Count int `json:"count"`
+	// The number of media in the timeline before the period, the media of newer periods.
+	// Used as the offset of `myTimeline` to jump to the period, with the same filters.
+	Offset int `json:"offset"`
+	// Cursor to pass as `after` to `myTimelineConnection` with the same filters,
+	// to page through the timeline from the newest media of the period
+	Cursor string `json:"cursor"`
+}
+
 // A group of media from the same album and the same day, that is grouped together in a timeline view
 // NOTE: It isn't used. Just copy from the old schema.graphql.
 type TimelineGroup struct {
@@ -108,6 +244,68 @@ type TimelineGroup struct {
 	Date time.Time `json:"date"`
 }
 
+// Colour labels to sort media while culling, as used by Lightroom and darktable
+type ColorLabel string
+
+const (
+	ColorLabelRed    ColorLabel = "Red"
+	ColorLabelYellow ColorLabel = "Yellow"
+	ColorLabelGreen  ColorLabel = "Green"
+	ColorLabelBlue   ColorLabel = "Blue"
+	ColorLabelPurple ColorLabel = "Purple"
+)
+
+var AllColorLabel = []ColorLabel{
+	ColorLabelRed,
+	ColorLabelYellow,
+	ColorLabelGreen,
+	ColorLabelBlue,
+	ColorLabelPurple,
+}
+
+func (e ColorLabel) IsValid() bool {
+	switch e {
+	case ColorLabelRed, ColorLabelYellow, ColorLabelGreen, ColorLabelBlue, ColorLabelPurple:
+		return true
+	}
+	return false
+}
+
+func (e ColorLabel) String() string {
+	return string(e)
+}
+
+func (e *ColorLabel) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ColorLabel(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ColorLabel", str)
+	}
+	return nil
+}
+
+func (e ColorLabel) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ColorLabel) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ColorLabel) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 // Supported language translations of the user interface
 type LanguageTranslation string
 
@@ -196,6 +394,127 @@ func (e LanguageTranslation) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// Metadata fields of media that can be corrected by users
+type MediaMetadataField string
+
+const (
+	MediaMetadataFieldTitle       MediaMetadataField = "Title"
+	MediaMetadataFieldDescription MediaMetadataField = "Description"
+	MediaMetadataFieldDateShot    MediaMetadataField = "DateShot"
+	MediaMetadataFieldCoordinates MediaMetadataField = "Coordinates"
+)
+
+var AllMediaMetadataField = []MediaMetadataField{
+	MediaMetadataFieldTitle,
+	MediaMetadataFieldDescription,
+	MediaMetadataFieldDateShot,
+	MediaMetadataFieldCoordinates,
+}
+
+func (e MediaMetadataField) IsValid() bool {
+	switch e {
+	case MediaMetadataFieldTitle, MediaMetadataFieldDescription, MediaMetadataFieldDateShot, MediaMetadataFieldCoordinates:
+		return true
+	}
+	return false
+}
+
+func (e MediaMetadataField) String() string {
+	return string(e)
+}
+
+func (e *MediaMetadataField) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = MediaMetadataField(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid MediaMetadataField", str)
+	}
+	return nil
+}
+
+func (e MediaMetadataField) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *MediaMetadataField) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e MediaMetadataField) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+// A scanner task that can fail for a single media file
+type MediaScanTask string
+
+const (
+	// Generating the thumbnail and high resolution versions of a photo
+	MediaScanTaskProcessPhoto MediaScanTask = "ProcessPhoto"
+	// Generating the web version and thumbnail of a video
+	MediaScanTaskProcessVideo MediaScanTask = "ProcessVideo"
+	// Reading the EXIF metadata of a media file
+	MediaScanTaskExif MediaScanTask = "Exif"
+)
+
+var AllMediaScanTask = []MediaScanTask{
+	MediaScanTaskProcessPhoto,
+	MediaScanTaskProcessVideo,
+	MediaScanTaskExif,
+}
+
+func (e MediaScanTask) IsValid() bool {
+	switch e {
+	case MediaScanTaskProcessPhoto, MediaScanTaskProcessVideo, MediaScanTaskExif:
+		return true
+	}
+	return false
+}
+
+func (e MediaScanTask) String() string {
+	return string(e)
+}
+
+func (e *MediaScanTask) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = MediaScanTask(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid MediaScanTask", str)
+	}
+	return nil
+}
+
+func (e MediaScanTask) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *MediaScanTask) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e MediaScanTask) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 // Specified the type a particular notification is of
 type NotificationType string
 
@@ -314,3 +633,247 @@ func (e OrderDirection) MarshalJSON() ([]byte, error) {
 	e.MarshalGQL(&buf)
 	return buf.Bytes(), nil
 }
+
+// Level to group places by
+type PlaceLevel string
+
+const (
+	PlaceLevelCountry PlaceLevel = "Country"
+	PlaceLevelRegion  PlaceLevel = "Region"
+	PlaceLevelCity    PlaceLevel = "City"
+)
+
+var AllPlaceLevel = []PlaceLevel{
+	PlaceLevelCountry,
+	PlaceLevelRegion,
+	PlaceLevelCity,
+}
+
+func (e PlaceLevel) IsValid() bool {
+	switch e {
+	case PlaceLevelCountry, PlaceLevelRegion, PlaceLevelCity:
+		return true
+	}
+	return false
+}
+
+func (e PlaceLevel) String() string {
+	return string(e)
+}
+
+func (e *PlaceLevel) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PlaceLevel(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PlaceLevel", str)
+	}
+	return nil
+}
+
+func (e PlaceLevel) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *PlaceLevel) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e PlaceLevel) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+// Priority of a scanner job, jobs with a higher priority are run first
+type ScannerJobPriority string
+
+const (
+	// Jobs started by periodic scans
+	ScannerJobPriorityLow ScannerJobPriority = "Low"
+	// Full scans requested by an admin
+	ScannerJobPriorityNormal ScannerJobPriority = "Normal"
+	// Scans of single users or albums, requested from the UI or by filesystem changes
+	ScannerJobPriorityHigh ScannerJobPriority = "High"
+)
+
+var AllScannerJobPriority = []ScannerJobPriority{
+	ScannerJobPriorityLow,
+	ScannerJobPriorityNormal,
+	ScannerJobPriorityHigh,
+}
+
+func (e ScannerJobPriority) IsValid() bool {
+	switch e {
+	case ScannerJobPriorityLow, ScannerJobPriorityNormal, ScannerJobPriorityHigh:
+		return true
+	}
+	return false
+}
+
+func (e ScannerJobPriority) String() string {
+	return string(e)
+}
+
+func (e *ScannerJobPriority) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ScannerJobPriority(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ScannerJobPriority", str)
+	}
+	return nil
+}
+
+func (e ScannerJobPriority) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ScannerJobPriority) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ScannerJobPriority) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+// Status of a scanner job
+type ScannerJobStatus string
+
+const (
+	// The job is waiting to be run
+	ScannerJobStatusQueued ScannerJobStatus = "Queued"
+	// The job is currently being run by the scanner
+	ScannerJobStatusRunning ScannerJobStatus = "Running"
+	// The job finished successfully
+	ScannerJobStatusDone ScannerJobStatus = "Done"
+	// The job stopped because of an error
+	ScannerJobStatusFailed ScannerJobStatus = "Failed"
+	// The job was cancelled before it finished
+	ScannerJobStatusCancelled ScannerJobStatus = "Cancelled"
+)
+
+var AllScannerJobStatus = []ScannerJobStatus{
+	ScannerJobStatusQueued,
+	ScannerJobStatusRunning,
+	ScannerJobStatusDone,
+	ScannerJobStatusFailed,
+	ScannerJobStatusCancelled,
+}
+
+func (e ScannerJobStatus) IsValid() bool {
+	switch e {
+	case ScannerJobStatusQueued, ScannerJobStatusRunning, ScannerJobStatusDone, ScannerJobStatusFailed, ScannerJobStatusCancelled:
+		return true
+	}
+	return false
+}
+
+func (e ScannerJobStatus) String() string {
+	return string(e)
+}
+
+func (e *ScannerJobStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ScannerJobStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ScannerJobStatus", str)
+	}
+	return nil
+}
+
+func (e ScannerJobStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ScannerJobStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ScannerJobStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+// The length of the periods of `TimelineBucket`s
+type TimelineGranularity string
+
+const (
+	TimelineGranularityYear  TimelineGranularity = "YEAR"
+	TimelineGranularityMonth TimelineGranularity = "MONTH"
+	TimelineGranularityDay   TimelineGranularity = "DAY"
+)
+
+var AllTimelineGranularity = []TimelineGranularity{
+	TimelineGranularityYear,
+	TimelineGranularityMonth,
+	TimelineGranularityDay,
+}
+
+func (e TimelineGranularity) IsValid() bool {
+	switch e {
+	case TimelineGranularityYear, TimelineGranularityMonth, TimelineGranularityDay:
+		return true
+	}
+	return false
+}
+
+func (e TimelineGranularity) String() string {
+	return string(e)
+}
+
+func (e *TimelineGranularity) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TimelineGranularity(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TimelineGranularity", str)
+	}
+	return nil
+}
+
+func (e TimelineGranularity) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *TimelineGranularity) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e TimelineGranularity) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
@@ -30,6 +30,17 @@ type Media struct {
 	SideCarHash     *string      `gorm:"unique"`
 	Faces           []*ImageFace `gorm:"constraint:OnDelete:CASCADE;"`
 	Blurhash        *string      `gorm:""`
+	// Fingerprint of the file content, used to detect moved and renamed files.
+	// It is nil for media scanned before fingerprints were introduced, and empty if the file could not be read.
+	Fingerprint *string `gorm:"index"`
+	// Perceptual hash of the thumbnail, used to detect duplicate photos
+	PerceptualHash *int64
+	// Metadata of the XMP sidecar
+	XMPID *int      `gorm:"index"`
+	XMP   *MediaXMP `gorm:"constraint:OnDelete:SET NULL;"`
+	// Burst or bracketing stack the media is part of
+	StackID *int        `gorm:"index"`
+	Stack   *MediaStack `gorm:"constraint:OnDelete:SET NULL;"`
 }
 
 func (Media) TableName() string {
@@ -43,6 +54,11 @@ func (m *Media) BeforeSave(tx *gorm.DB) error {
 	return nil
 }
 
+// AfterSave keeps the search document of the media up to date with its title and path
+func (m *Media) AfterSave(tx *gorm.DB) error {
+	return UpdateMediaSearchDocuments(tx.Session(&gorm.Session{NewDB: true}), []int{m.ID})
+}
+
 func (m *Media) Date() time.Time {
 	return m.DateShot
 }
@@ -62,6 +78,27 @@ func (m *Media) GetThumbnail() (*MediaURL, error) {
 	return nil, nil
 }
 
+// BestFittingMediaURL returns the smallest of the urls that is at least `size` pixels wide or high,
+// or the largest of the urls if none of them are big enough
+func BestFittingMediaURL(urls []*MediaURL, size int) *MediaURL {
+	var best *MediaURL
+	for _, url := range urls {
+		urlSize := max(url.Width, url.Height)
+
+		if best == nil {
+			best = url
+			continue
+		}
+
+		bestSize := max(best.Width, best.Height)
+		if (bestSize < size && urlSize > bestSize) || (urlSize >= size && urlSize < bestSize) {
+			best = url
+		}
+	}
+
+	return best
+}
+
 func (m *Media) GetHighRes() (*MediaURL, error) {
 	if len(m.MediaURL) == 0 {
 		return nil, errors.New("media.MediaURL is empty")
@@ -93,6 +130,21 @@ var AllMediaType = []MediaType{
 	MediaTypeVideo,
 }
 
+// Normalized returns the media type as it is stored in Media.Type, from either the stored or the API form
+func (t MediaType) Normalized() MediaType {
+	return MediaType(strings.ToLower(string(t)))
+}
+
+// Formatted returns the media type as it is written in the GraphQL API, which capitalizes the stored media types
+func (t MediaType) Formatted() MediaType {
+	normalized := string(t.Normalized())
+	if normalized == "" {
+		return ""
+	}
+
+	return MediaType(strings.ToUpper(normalized[:1]) + normalized[1:])
+}
+
 type MediaPurpose string
 
 const (
@@ -101,8 +153,30 @@ const (
 	MediaOriginal  MediaPurpose = "original"
 	VideoWeb       MediaPurpose = "video-web"
 	VideoThumbnail MediaPurpose = "video-thumbnail"
+	// VideoPreview is a short silent clip sampled across the video
+	VideoPreview MediaPurpose = "video-preview"
+	// VideoHLS is a directory with HLS playlists and segments, the media name is the name of the directory
+	VideoHLS MediaPurpose = "video-hls"
+	// MotionVideo is the video clip of a Live Photo or Motion Photo, it belongs to the still photo
+	MotionVideo MediaPurpose = "motion-video"
 )
 
+// HLSMasterPlaylist is the name of the playlist inside the HLS directory of a video, that lists all variants
+const HLSMasterPlaylist = "master.m3u8"
+
+// renditionPurposePrefix is the prefix of the purpose of configured photo renditions, followed by the rendition name
+const renditionPurposePrefix = "rendition-"
+
+// RenditionPurpose returns the purpose of the media urls for the photo rendition with the given name
+func RenditionPurpose(name string) MediaPurpose {
+	return MediaPurpose(renditionPurposePrefix + name)
+}
+
+// RenditionName returns the name of the rendition if the purpose is a photo rendition
+func (p MediaPurpose) RenditionName() (string, bool) {
+	return strings.CutPrefix(string(p), renditionPurposePrefix)
+}
+
 type MediaURL struct {
 	Model
 	MediaID     int          `gorm:"not null;index"`
@@ -118,10 +192,13 @@ type MediaURL struct {
 func (p *MediaURL) URL() string {
 
 	imageURL := utils.ApiEndpointUrl()
-	if p.Purpose != VideoWeb {
-		imageURL.Path = path.Join(imageURL.Path, "photo", p.MediaName)
-	} else {
+	switch p.Purpose {
+	case VideoWeb, VideoPreview, MotionVideo:
 		imageURL.Path = path.Join(imageURL.Path, "video", p.MediaName)
+	case VideoHLS:
+		imageURL.Path = path.Join(imageURL.Path, "video", "hls", p.MediaName, HLSMasterPlaylist)
+	default:
+		imageURL.Path = path.Join(imageURL.Path, "photo", p.MediaName)
 	}
 
 	return imageURL.String()
@@ -134,9 +211,15 @@ func (p *MediaURL) CachedPath() (string, error) {
 		return "", errors.New("mediaURL.Media is nil")
 	}
 
-	if p.Purpose == PhotoThumbnail || p.Purpose == PhotoHighRes || p.Purpose == VideoThumbnail || p.Purpose == VideoWeb {
+	_, isRendition := p.Purpose.RenditionName()
+
+	if p.Purpose == PhotoThumbnail || p.Purpose == PhotoHighRes || p.Purpose == VideoThumbnail || p.Purpose == VideoWeb ||
+		p.Purpose == VideoPreview || p.Purpose == MotionVideo || isRendition {
 		cachedPath = path.Join(utils.MediaCachePath(), strconv.Itoa(int(p.Media.AlbumID)), strconv.Itoa(int(p.MediaID)),
 			p.MediaName)
+	} else if p.Purpose == VideoHLS {
+		cachedPath = path.Join(utils.MediaCachePath(), strconv.Itoa(int(p.Media.AlbumID)), strconv.Itoa(int(p.MediaID)),
+			p.MediaName, HLSMasterPlaylist)
 	} else if p.Purpose == MediaOriginal {
 		cachedPath = p.Media.Path
 	} else {
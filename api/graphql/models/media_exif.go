@@ -22,6 +22,16 @@ type MediaEXIF struct {
 	ExposureProgram *int64
 	GPSLatitude     *float64
 	GPSLongitude    *float64
+	// Identifiers of the burst or bracketing sequence the photo was shot in
+	BurstID        *string
+	SequenceNumber *int64
+	// IPTC and XMP keywords embedded in the file
+	Keywords []string `gorm:"type:text;serializer:json"`
+	// Place resolved from the GPS coordinates, nil if not resolved
+	CountryCode *string `gorm:"size:2;index"`
+	Country     *string
+	Region      *string
+	City        *string
 }
 
 func (MediaEXIF) TableName() string {
@@ -32,6 +42,25 @@ func (exif *MediaEXIF) Media() *Media {
 	panic("not implemented")
 }
 
+// Place returns the place resolved from the GPS coordinates, or nil if not resolved
+func (exif *MediaEXIF) Place() *Place {
+	if exif.CountryCode == nil || exif.Country == nil {
+		return nil
+	}
+
+	return &Place{
+		CountryCode: *exif.CountryCode,
+		Country:     *exif.Country,
+		Region:      exif.Region,
+		City:        exif.City,
+	}
+}
+
+// SetPlace sets the place resolved from the GPS coordinates, a nil place clears it
+func (exif *MediaEXIF) SetPlace(place *Place) {
+	exif.CountryCode, exif.Country, exif.Region, exif.City = place.columns()
+}
+
 func (exif *MediaEXIF) Coordinates() *Coordinates {
 	if exif.GPSLatitude == nil || exif.GPSLongitude == nil {
 		return nil
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MediaOverride stores metadata of a media corrected by users, that takes precedence over the metadata of the media file.
+// It is stored apart from the metadata of the file, so scans don't overwrite it.
+type MediaOverride struct {
+	ModelTimestamps
+	MediaID     int   `gorm:"primaryKey;autoIncrement:false"`
+	Media       Media `gorm:"constraint:OnDelete:CASCADE;"`
+	Title       *string
+	Description *string
+	// Date and time the media was shot in local time, the timezone of the value is meaningless like MediaEXIF.DateShot
+	DateShot      *time.Time
+	OffsetSecShot *int
+	GPSLatitude   *float64
+	GPSLongitude  *float64
+	// Place resolved from the overridden GPS coordinates
+	CountryCode *string `gorm:"size:2"`
+	Country     *string
+	Region      *string
+	City        *string
+}
+
+func (MediaOverride) TableName() string {
+	return "media_overrides"
+}
+
+// ApplyToEXIF returns a copy of the exif data with the overridden values, `exif` may be nil if the media has no exif data
+func (o *MediaOverride) ApplyToEXIF(exif *MediaEXIF) *MediaEXIF {
+	result := MediaEXIF{}
+	if exif != nil {
+		result = *exif
+	}
+
+	if o == nil {
+		return &result
+	}
+
+	if o.Description != nil {
+		result.Description = o.Description
+	}
+
+	if o.DateShot != nil {
+		result.DateShot = o.DateShot
+		result.OffsetSecShot = o.OffsetSecShot
+	}
+
+	if o.GPSLatitude != nil && o.GPSLongitude != nil {
+		result.GPSLatitude = o.GPSLatitude
+		result.GPSLongitude = o.GPSLongitude
+		result.CountryCode, result.Country, result.Region, result.City = o.CountryCode, o.Country, o.Region, o.City
+	}
+
+	return &result
+}
+
+// SetPlace sets the place resolved from the overridden GPS coordinates, a nil place clears it
+func (o *MediaOverride) SetPlace(place *Place) {
+	o.CountryCode, o.Country, o.Region, o.City = place.columns()
+}
+
+// Empty returns true if no value is overridden
+func (o *MediaOverride) Empty() bool {
+	return o.Title == nil && o.Description == nil && o.DateShot == nil && o.GPSLatitude == nil && o.GPSLongitude == nil
+}
+
+// DateShotOverridden returns true if users have corrected the date the media was shot
+func DateShotOverridden(db *gorm.DB, mediaID int) (bool, error) {
+	var count int64
+	if err := db.Model(&MediaOverride{}).
+		Where("media_id = ? AND date_shot IS NOT NULL", mediaID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,69 @@
+package models
+
+import "gorm.io/gorm"
+
+// MaxMediaRating is the highest star rating of media
+const MaxMediaRating = 5
+
+// MediaRatingSQL selects the rating of the user for `media`, falling back to the rating of its XMP sidecar.
+// The placeholder is the ID of the user.
+const MediaRatingSQL = "COALESCE(" +
+	"(SELECT user_media_data.rating FROM user_media_data WHERE user_media_data.media_id = media.id AND user_media_data.user_id = ?), " +
+	"(SELECT media_xmp.rating FROM media_xmp WHERE media_xmp.id = media.xmp_id), 0)"
+
+// MediaColorLabelSQL selects the colour label of the user for `media`, falling back to the label of its XMP sidecar.
+// The placeholder is the ID of the user.
+const MediaColorLabelSQL = "COALESCE(" +
+	"(SELECT user_media_data.color_label FROM user_media_data WHERE user_media_data.media_id = media.id AND user_media_data.user_id = ?), " +
+	"(SELECT media_xmp.label FROM media_xmp WHERE media_xmp.id = media.xmp_id), '')"
+
+// FilterMediaByRating limits the media of the query to the ones the user has rated with at least `minRating` stars,
+// and labelled with `colorLabel`. Filters that are nil are left out.
+func FilterMediaByRating(query *gorm.DB, userID int, minRating *int, colorLabel *ColorLabel) *gorm.DB {
+	if minRating != nil && *minRating > 0 {
+		query = query.Where(MediaRatingSQL+" >= ?", userID, *minRating)
+	}
+
+	if colorLabel != nil {
+		query = query.Where(MediaColorLabelSQL+" = ?", userID, colorLabel.String())
+	}
+
+	return query
+}
+
+// ApplyXMP uses the rating and label of the XMP sidecar of the media, where the user hasn't set them
+func (d *UserMediaData) ApplyXMP(xmpRating *int, xmpLabel *string) {
+	if d.Rating == nil && xmpRating != nil {
+		// Rejected media have a rating of -1 in XMP
+		rating := max(*xmpRating, 0)
+		d.Rating = &rating
+	}
+
+	if d.ColorLabel == nil && xmpLabel != nil {
+		label := *xmpLabel
+		d.ColorLabel = &label
+	}
+}
+
+// GetRating returns the star rating, 0 if the media is not rated
+func (d *UserMediaData) GetRating() int {
+	if d.Rating == nil {
+		return 0
+	}
+
+	return *d.Rating
+}
+
+// GetColorLabel returns the colour label, nil if the media has no label or the label is not a known colour
+func (d *UserMediaData) GetColorLabel() *ColorLabel {
+	if d.ColorLabel == nil {
+		return nil
+	}
+
+	label := ColorLabel(*d.ColorLabel)
+	if !label.IsValid() {
+		return nil
+	}
+
+	return &label
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// mediaScanErrorBaseBackoff is how long to wait before retrying a task, after it failed for the first time
+	mediaScanErrorBaseBackoff = time.Hour
+	// mediaScanErrorMaxBackoff is the longest time to wait before retrying a task
+	mediaScanErrorMaxBackoff = 7 * 24 * time.Hour
+)
+
+// MediaScanError records that a scanner task failed for a media file,
+// it is used to back off from retrying broken files on every scan
+type MediaScanError struct {
+	Model
+	MediaID     int           `gorm:"not null;uniqueIndex:idx_media_scan_errors_media_task"`
+	Media       *Media        `gorm:"constraint:OnDelete:CASCADE;"`
+	Path        string        `gorm:"not null"`
+	Task        MediaScanTask `gorm:"not null;uniqueIndex:idx_media_scan_errors_media_task"`
+	Error       string        `gorm:"not null"`
+	Attempts    int           `gorm:"not null"`
+	LastAttempt time.Time     `gorm:"not null"`
+	NextAttempt time.Time     `gorm:"not null;index"`
+}
+
+func (MediaScanError) TableName() string {
+	return "media_scan_errors"
+}
+
+// InBackoff returns true if the task should not be retried yet
+func (e *MediaScanError) InBackoff() bool {
+	return time.Now().Before(e.NextAttempt)
+}
+
+// mediaScanErrorBackoff returns how long to wait before the next attempt, doubling for every failed attempt
+func mediaScanErrorBackoff(attempts int) time.Duration {
+	backoff := mediaScanErrorBaseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= mediaScanErrorMaxBackoff {
+			return mediaScanErrorMaxBackoff
+		}
+	}
+
+	return backoff
+}
+
+// GetMediaScanError returns the recorded error of the task for the given media, or nil if the task has not failed
+func GetMediaScanError(db *gorm.DB, mediaID int, task MediaScanTask) (*MediaScanError, error) {
+	var scanErrors []*MediaScanError
+	if err := db.Where("media_id = ? AND task = ?", mediaID, task).Limit(1).Find(&scanErrors).Error; err != nil {
+		return nil, err
+	}
+
+	if len(scanErrors) == 0 {
+		return nil, nil
+	}
+
+	return scanErrors[0], nil
+}
+
+// mediaScanErrorKey identifies the recorded error of a task for a media
+type mediaScanErrorKey struct {
+	mediaID int
+	task    MediaScanTask
+}
+
+// MediaScanErrors are recorded errors looked up by media and task
+type MediaScanErrors map[mediaScanErrorKey]*MediaScanError
+
+// Get returns the recorded error of the task for the given media, or nil if the task has not failed
+func (e MediaScanErrors) Get(mediaID int, task MediaScanTask) *MediaScanError {
+	return e[mediaScanErrorKey{mediaID: mediaID, task: task}]
+}
+
+// GetAlbumMediaScanErrors returns the recorded errors of all media in the album
+func GetAlbumMediaScanErrors(db *gorm.DB, albumID int) (MediaScanErrors, error) {
+	var scanErrors []*MediaScanError
+	if err := db.Where("media_id IN (?)", db.Model(&Media{}).Select("id").Where("album_id = ?", albumID)).
+		Find(&scanErrors).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(MediaScanErrors, len(scanErrors))
+	for _, scanError := range scanErrors {
+		result[mediaScanErrorKey{mediaID: scanError.MediaID, task: scanError.Task}] = scanError
+	}
+
+	return result, nil
+}
+
+// RecordMediaScanError saves a failed attempt of the task for the given media,
+// and schedules the next attempt using an exponential backoff
+func RecordMediaScanError(db *gorm.DB, media *Media, task MediaScanTask, taskErr error) error {
+	var scanError MediaScanError
+	if err := db.Where("media_id = ? AND task = ?", media.ID, task).FirstOrInit(&scanError).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	scanError.MediaID = media.ID
+	scanError.Path = media.Path
+	scanError.Task = task
+	scanError.Error = taskErr.Error()
+	scanError.Attempts++
+	scanError.LastAttempt = now
+	scanError.NextAttempt = now.Add(mediaScanErrorBackoff(scanError.Attempts))
+
+	return db.Save(&scanError).Error
+}
+
+// ClearMediaScanError removes the recorded error of the task for the given media, after it succeeded
+func ClearMediaScanError(db *gorm.DB, mediaID int, task MediaScanTask) error {
+	return db.Where("media_id = ? AND task = ?", mediaID, task).Delete(&MediaScanError{}).Error
+}
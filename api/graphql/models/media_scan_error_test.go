@@ -0,0 +1,90 @@
+package models_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMediaScanError(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	album := models.Album{
+		Title: "album",
+		Path:  "/photos",
+	}
+	require.NoError(t, db.Save(&album).Error)
+
+	media := models.Media{
+		Title:   "broken.jpg",
+		Path:    "/photos/broken.jpg",
+		AlbumID: album.ID,
+	}
+	require.NoError(t, db.Save(&media).Error)
+
+	scanError, err := models.GetMediaScanError(db, media.ID, models.MediaScanTaskProcessPhoto)
+	require.NoError(t, err)
+	assert.Nil(t, scanError, "no error should be recorded before the task has failed")
+
+	require.NoError(t, models.RecordMediaScanError(db, &media, models.MediaScanTaskProcessPhoto, errors.New("first error")))
+
+	scanError, err = models.GetMediaScanError(db, media.ID, models.MediaScanTaskProcessPhoto)
+	require.NoError(t, err)
+	require.NotNil(t, scanError)
+	assert.Equal(t, media.Path, scanError.Path)
+	assert.Equal(t, "first error", scanError.Error)
+	assert.Equal(t, 1, scanError.Attempts)
+	assert.WithinDuration(t, scanError.LastAttempt.Add(time.Hour), scanError.NextAttempt, time.Second)
+	assert.True(t, scanError.InBackoff())
+
+	require.NoError(t, models.RecordMediaScanError(db, &media, models.MediaScanTaskProcessPhoto, errors.New("second error")))
+
+	scanError, err = models.GetMediaScanError(db, media.ID, models.MediaScanTaskProcessPhoto)
+	require.NoError(t, err)
+	require.NotNil(t, scanError)
+	assert.Equal(t, "second error", scanError.Error)
+	assert.Equal(t, 2, scanError.Attempts)
+	assert.WithinDuration(t, scanError.LastAttempt.Add(2*time.Hour), scanError.NextAttempt, time.Second)
+
+	exifError, err := models.GetMediaScanError(db, media.ID, models.MediaScanTaskExif)
+	require.NoError(t, err)
+	assert.Nil(t, exifError, "errors should be recorded per task")
+
+	require.NoError(t, models.ClearMediaScanError(db, media.ID, models.MediaScanTaskProcessPhoto))
+
+	scanError, err = models.GetMediaScanError(db, media.ID, models.MediaScanTaskProcessPhoto)
+	require.NoError(t, err)
+	assert.Nil(t, scanError)
+}
+
+func TestGetAlbumMediaScanErrors(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	album := models.Album{Title: "album", Path: "/photos"}
+	otherAlbum := models.Album{Title: "other", Path: "/other"}
+	require.NoError(t, db.Save(&album).Error)
+	require.NoError(t, db.Save(&otherAlbum).Error)
+
+	media := models.Media{Title: "broken.jpg", Path: "/photos/broken.jpg", AlbumID: album.ID}
+	otherMedia := models.Media{Title: "broken.jpg", Path: "/other/broken.jpg", AlbumID: otherAlbum.ID}
+	require.NoError(t, db.Save(&media).Error)
+	require.NoError(t, db.Save(&otherMedia).Error)
+
+	require.NoError(t, models.RecordMediaScanError(db, &media, models.MediaScanTaskExif, errors.New("exif error")))
+	require.NoError(t, models.RecordMediaScanError(db, &otherMedia, models.MediaScanTaskExif, errors.New("other error")))
+
+	scanErrors, err := models.GetAlbumMediaScanErrors(db, album.ID)
+	require.NoError(t, err)
+	assert.Len(t, scanErrors, 1)
+
+	scanError := scanErrors.Get(media.ID, models.MediaScanTaskExif)
+	require.NotNil(t, scanError)
+	assert.Equal(t, "exif error", scanError.Error)
+	assert.Nil(t, scanErrors.Get(media.ID, models.MediaScanTaskProcessPhoto))
+	assert.Nil(t, scanErrors.Get(otherMedia.ID, models.MediaScanTaskExif))
+}
@@ -0,0 +1,365 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/photoview/photoview/api/database/drivers"
+	"github.com/pkg/errors"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MediaSearchFTSTable is the SQLite FTS5 table indexing media_search_documents,
+// it doesn't exist if SQLite was built without FTS5
+const MediaSearchFTSTable = "media_search_fts"
+
+// mediaSearchFTS is true if the SQLite full-text search table exists,
+// it is detected once when the search index is set up instead of on every search
+var mediaSearchFTS atomic.Bool
+
+// SetMediaSearchFTS records whether searches of SQLite can use the full-text search table
+func SetMediaSearchFTS(enabled bool) {
+	mediaSearchFTS.Store(enabled)
+}
+
+// SqliteFTS5Enabled is true if SQLite was built with FTS5, which needs the `sqlite_fts5` build tag
+func SqliteFTS5Enabled(db *gorm.DB) bool {
+	var enabled bool
+	if err := db.Raw("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&enabled).Error; err != nil {
+		return false
+	}
+
+	return enabled
+}
+
+// MediaSearchConfiguration is the PostgreSQL text search configuration of the search index
+const MediaSearchConfiguration = "english"
+
+// MediaSearchDocument is the text of a media that is indexed for full-text search.
+// The text is lower case, without accents and with words separated by spaces,
+// so the search behaves the same way for all database drivers.
+type MediaSearchDocument struct {
+	MediaID int   `gorm:"primaryKey;autoIncrement:false"`
+	Media   Media `gorm:"constraint:OnDelete:CASCADE;"`
+	// Title of the media and of its XMP sidecar, matches in the title rank higher than matches in the content
+	Title string
+	// Path, descriptions, keywords, camera, lens, place and the names of the people of the media.
+	// Documents are shared by all users, so the private tags of users are only searched with the `tag:` filter.
+	Content string
+}
+
+func (MediaSearchDocument) TableName() string {
+	return "media_search_documents"
+}
+
+// SearchWords returns the words of the text in lower case and without accents,
+// words are sequences of letters and numbers
+func SearchWords(text string) []string {
+	removeAccents := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	if normalized, _, err := transform.String(removeAccents, text); err == nil {
+		text = normalized
+	}
+
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// searchText joins the words of the values into the text of a search document
+func searchText(values ...*string) string {
+	words := make([]string, 0)
+	for _, value := range values {
+		if value != nil {
+			words = append(words, SearchWords(*value)...)
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// UpdateMediaSearchDocuments rebuilds the search documents of the media from their current metadata,
+// documents that didn't change are left untouched
+func UpdateMediaSearchDocuments(db *gorm.DB, mediaIDs []int) error {
+	const batchSize = 500
+
+	for start := 0; start < len(mediaIDs); start += batchSize {
+		if err := updateMediaSearchDocuments(db, mediaIDs[start:min(start+batchSize, len(mediaIDs))]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func updateMediaSearchDocuments(db *gorm.DB, mediaIDs []int) error {
+	var media []*Media
+	if err := db.Preload("Exif").Preload("XMP").Where("id IN ?", mediaIDs).Find(&media).Error; err != nil {
+		return errors.Wrap(err, "get media to index")
+	}
+
+	var overrides []*MediaOverride
+	if err := db.Where("media_id IN ?", mediaIDs).Find(&overrides).Error; err != nil {
+		return errors.Wrap(err, "get metadata overrides of media to index")
+	}
+
+	overridesByMedia := make(map[int]*MediaOverride, len(overrides))
+	for _, override := range overrides {
+		overridesByMedia[override.MediaID] = override
+	}
+
+	var faceLabels []struct {
+		MediaID int
+		Label   *string
+	}
+	if err := db.Model(&ImageFace{}).
+		Select("image_faces.media_id, face_groups.label").
+		Joins("JOIN face_groups ON face_groups.id = image_faces.face_group_id").
+		Where("image_faces.media_id IN ? AND face_groups.label IS NOT NULL", mediaIDs).
+		Scan(&faceLabels).Error; err != nil {
+		return errors.Wrap(err, "get people of media to index")
+	}
+
+	labelsByMedia := make(map[int][]*string)
+	for _, face := range faceLabels {
+		labelsByMedia[face.MediaID] = append(labelsByMedia[face.MediaID], face.Label)
+	}
+
+	var keywordTags []struct {
+		MediaID int
+		Name    string
+	}
+	if err := db.Model(&MediaTag{}).
+		Select("media_tags.media_id, tags.name").
+		Joins("JOIN tags ON tags.id = media_tags.tag_id").
+		Where("media_tags.media_id IN ? AND tags.user_id IS NULL", mediaIDs).
+		Order("tags.name").
+		Scan(&keywordTags).Error; err != nil {
+		return errors.Wrap(err, "get keywords of media to index")
+	}
+
+	keywordsByMedia := make(map[int][]string)
+	for _, tag := range keywordTags {
+		keywordsByMedia[tag.MediaID] = append(keywordsByMedia[tag.MediaID], tag.Name)
+	}
+
+	var existing []*MediaSearchDocument
+	if err := db.Where("media_id IN ?", mediaIDs).Find(&existing).Error; err != nil {
+		return errors.Wrap(err, "get search documents of media")
+	}
+
+	existingByMedia := make(map[int]*MediaSearchDocument, len(existing))
+	for _, document := range existing {
+		existingByMedia[document.MediaID] = document
+	}
+
+	documents := make([]*MediaSearchDocument, 0, len(media))
+	for _, m := range media {
+		override := overridesByMedia[m.ID]
+		exif := override.ApplyToEXIF(m.Exif)
+
+		title := []*string{&m.Title}
+		if override != nil && override.Title != nil {
+			title = []*string{override.Title}
+		}
+
+		content := []*string{&m.Path, exif.Description, exif.Camera, exif.Maker, exif.Lens, exif.Country, exif.Region, exif.City}
+		content = append(content, labelsByMedia[m.ID]...)
+
+		keywords := append(slices.Clone(keywordsByMedia[m.ID]), exif.Keywords...)
+		if m.XMP != nil {
+			title = append(title, m.XMP.Title)
+			content = append(content, m.XMP.Description)
+			keywords = append(keywords, m.XMP.Keywords...)
+		}
+		for _, keyword := range NormalizeTagNames(keywords) {
+			content = append(content, &keyword)
+		}
+
+		document := MediaSearchDocument{
+			MediaID: m.ID,
+			Title:   searchText(title...),
+			Content: searchText(content...),
+		}
+
+		previous, found := existingByMedia[m.ID]
+		if found && previous.Title == document.Title && previous.Content == document.Content {
+			continue
+		}
+		documents = append(documents, &document)
+	}
+
+	if len(documents) == 0 {
+		return nil
+	}
+
+	if err := db.Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "media_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"title", "content"}),
+	}).Create(&documents).Error; err != nil {
+		return errors.Wrap(err, "save search documents of media")
+	}
+
+	return nil
+}
+
+// UpdateFaceGroupSearchDocuments rebuilds the search documents of the media with faces in the face groups,
+// after their labels or faces changed
+func UpdateFaceGroupSearchDocuments(db *gorm.DB, faceGroupIDs ...int) error {
+	var mediaIDs []int
+	if err := db.Model(&ImageFace{}).
+		Distinct("media_id").
+		Where("face_group_id IN ?", faceGroupIDs).
+		Pluck("media_id", &mediaIDs).Error; err != nil {
+		return errors.Wrap(err, "get media of face groups")
+	}
+
+	return UpdateMediaSearchDocuments(db, mediaIDs)
+}
+
+// minIndexedWordLength is the length of the shortest words in the full-text indexes,
+// shorter words are left out by MySQL with the default `innodb_ft_min_token_size`
+const minIndexedWordLength = 3
+
+// searchStopWords are left out of the full-text indexes, they are the stop words of the english configuration
+// of PostgreSQL and the default stop words of MySQL
+var searchStopWords = map[string]bool{}
+
+func init() {
+	stopWords := "a about above after again against all am an and any are as at be because been before being below " +
+		"between both but by can com de did do does doing don down during each en few for from further had has have " +
+		"having he her here hers herself him himself his how i if in into is it its itself just la me more most my " +
+		"myself no nor not now of off on once only or other our ours ourselves out over own s same she should so some " +
+		"such t than that the their theirs them themselves then there these they this those through to too und under " +
+		"until up very was we were what when where which while who whom why will with www you your yours yourself yourselves"
+
+	for _, word := range strings.Fields(stopWords) {
+		searchStopWords[word] = true
+	}
+}
+
+// indexedSearchWord is true if the word is in the full-text indexes of all database drivers
+func indexedSearchWord(word string) bool {
+	return utf8.RuneCountInString(word) >= minIndexedWordLength && !searchStopWords[word]
+}
+
+// searchDocumentText returns the SQL joining the columns of the search documents with spaces,
+// starting with a space so words can be matched at their start with `LIKE '% word%'`
+func searchDocumentText(db *gorm.DB, columns ...string) string {
+	if drivers.MYSQL.MatchDatabase(db) {
+		return "CONCAT(' ', " + strings.Join(columns, ", ' ', ") + ")"
+	}
+
+	return "(' ' || " + strings.Join(columns, " || ' ' || ") + ")"
+}
+
+// MediaSearchMatchQuery returns a query of the `media_id` and `relevance` of the media
+// whose search document contains all the words of the text, or words starting with them.
+// Short words and stop words aren't in the full-text indexes of PostgreSQL and MySQL,
+// so they are matched anywhere in the documents, the same way for all database drivers.
+// A higher relevance means a better match. Nil is returned if the text has no words.
+func MediaSearchMatchQuery(db *gorm.DB, text string) *gorm.DB {
+	words := SearchWords(text)
+	if len(words) == 0 {
+		return nil
+	}
+
+	indexedWords := make([]string, 0, len(words))
+	unindexedWords := make([]string, 0)
+	for _, word := range words {
+		if indexedSearchWord(word) {
+			indexedWords = append(indexedWords, word)
+		} else {
+			unindexedWords = append(unindexedWords, word)
+		}
+	}
+
+	match := fullTextMatchQuery(db, indexedWords)
+	if match == nil {
+		return scanMatchQuery(db, indexedWords, unindexedWords)
+	}
+
+	for _, word := range unindexedWords {
+		match = match.Where(searchDocumentText(db, "title", "content")+" LIKE ?", "%"+word+"%")
+	}
+
+	return match
+}
+
+// fullTextMatchQuery returns a query of the matches of the words in the full-text index of the database,
+// nil is returned if there are no words or the database has no full-text index
+func fullTextMatchQuery(db *gorm.DB, words []string) *gorm.DB {
+	if len(words) == 0 {
+		return nil
+	}
+
+	switch drivers.GetDatabaseDriverType(db) {
+	case drivers.POSTGRES:
+		query := make([]string, len(words))
+		for i, word := range words {
+			query[i] = word + ":*"
+		}
+		tsQuery := fmt.Sprintf("to_tsquery('%s', ?)", MediaSearchConfiguration)
+
+		return db.Table("media_search_documents").
+			Select("media_id, ts_rank(search_vector, "+tsQuery+") AS relevance", strings.Join(query, " & ")).
+			Where("search_vector @@ "+tsQuery, strings.Join(query, " & "))
+
+	case drivers.MYSQL:
+		query := make([]string, len(words))
+		for i, word := range words {
+			query[i] = "+" + word + "*"
+		}
+		match := "MATCH(title, content) AGAINST (? IN BOOLEAN MODE)"
+
+		return db.Table("media_search_documents").
+			Select("media_id, "+match+" AS relevance", strings.Join(query, " ")).
+			Where(match, strings.Join(query, " "))
+	}
+
+	if mediaSearchFTS.Load() {
+		query := make([]string, len(words))
+		for i, word := range words {
+			query[i] = `"` + word + `"*`
+		}
+
+		// bm25 is lower for better matches, matches in the title are weighted ten times as much as in the content
+		return db.Table(MediaSearchFTSTable).
+			Select(fmt.Sprintf("rowid AS media_id, -bm25(%s, 10.0, 1.0) AS relevance", MediaSearchFTSTable)).
+			Where(MediaSearchFTSTable+" MATCH ?", strings.Join(query, " "))
+	}
+
+	return nil
+}
+
+// scanMatchQuery returns a query of the matches of the words found by scanning the documents,
+// `prefixWords` match at the start of words of the documents and `anywhereWords` anywhere in them
+func scanMatchQuery(db *gorm.DB, prefixWords []string, anywhereWords []string) *gorm.DB {
+	conditions := make([]string, 0, len(prefixWords)+len(anywhereWords))
+	relevance := make([]string, 0, len(prefixWords)+len(anywhereWords))
+	vars := make([]any, 0, len(prefixWords)+len(anywhereWords))
+
+	addWord := func(pattern string) {
+		conditions = append(conditions, searchDocumentText(db, "title", "content")+" LIKE ?")
+		relevance = append(relevance, "(CASE WHEN "+searchDocumentText(db, "title")+" LIKE ? THEN 10 ELSE 1 END)")
+		vars = append(vars, pattern)
+	}
+
+	for _, word := range prefixWords {
+		addWord("% " + word + "%")
+	}
+	for _, word := range anywhereWords {
+		addWord("%" + word + "%")
+	}
+
+	return db.Table("media_search_documents").
+		Select("media_id, "+strings.Join(relevance, " + ")+" AS relevance", vars...).
+		Where(strings.Join(conditions, " AND "), vars...)
+}
@@ -0,0 +1,15 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchWords(t *testing.T) {
+	assert.Equal(t, []string{"cafe", "de", "flore", "jpg"}, models.SearchWords("Café de Flore.jpg"))
+	assert.Equal(t, []string{"photos", "2021", "img", "0001", "jpg"}, models.SearchWords("/photos/2021/IMG_0001.JPG"))
+	assert.Equal(t, []string{"zoe", "søren", "東京"}, models.SearchWords("  Zoë, Søren & 東京!"))
+	assert.Empty(t, models.SearchWords("-- !"))
+}
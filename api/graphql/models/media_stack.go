@@ -0,0 +1,37 @@
+package models
+
+import "gorm.io/gorm"
+
+// MediaStack groups the frames of a burst or exposure bracketing sequence,
+// only the cover of the stack is listed in the timeline and albums
+type MediaStack struct {
+	Model
+	AlbumID int   `gorm:"not null;index"`
+	Album   Album `gorm:"constraint:OnDelete:CASCADE;"`
+	CoverID int   `gorm:"not null"`
+	// Manual stacks have been split by a user, the scanner leaves their media as they are
+	Manual bool `gorm:"not null;default:false"`
+}
+
+func (MediaStack) TableName() string {
+	return "media_stacks"
+}
+
+// GetMedia returns the media of the stack, ordered by the time they were shot
+func (s *MediaStack) GetMedia(db *gorm.DB) ([]*Media, error) {
+	var media []*Media
+	if err := db.Where("stack_id = ?", s.ID).Order("date_shot, id").Find(&media).Error; err != nil {
+		return nil, err
+	}
+
+	return media, nil
+}
+
+// HiddenStackMediaQuery returns a subquery of the ids of media that are part of a stack without being its cover,
+// they are only listed when the stack is expanded
+func HiddenStackMediaQuery(db *gorm.DB) *gorm.DB {
+	return db.Table("media AS stacked_media").
+		Select("stacked_media.id").
+		Joins("JOIN media_stacks ON stacked_media.stack_id = media_stacks.id").
+		Where("stacked_media.id <> media_stacks.cover_id")
+}
@@ -51,6 +51,34 @@ func TestMediaURLCachePath(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "media_cache/2/1/media_thumb.jpg", path)
 
+	mediaUrl.Purpose = models.RenditionPurpose("large")
+	mediaUrl.MediaName = "media_large.jpg"
+
+	path, err = mediaUrl.CachedPath()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "media_cache/2/1/media_large.jpg", path)
+}
+
+func TestBestFittingMediaURL(t *testing.T) {
+	small := &models.MediaURL{Width: 512, Height: 384, Purpose: models.RenditionPurpose("small")}
+	thumbnail := &models.MediaURL{Width: 768, Height: 1024, Purpose: models.PhotoThumbnail}
+	large := &models.MediaURL{Width: 2048, Height: 1536, Purpose: models.RenditionPurpose("large")}
+	urls := []*models.MediaURL{thumbnail, large, small}
+
+	assert.Equal(t, small, models.BestFittingMediaURL(urls, 256))
+	assert.Equal(t, small, models.BestFittingMediaURL(urls, 512))
+	assert.Equal(t, thumbnail, models.BestFittingMediaURL(urls, 600))
+	assert.Equal(t, large, models.BestFittingMediaURL(urls, 1500))
+	assert.Equal(t, large, models.BestFittingMediaURL(urls, 4096), "largest url should be used if none are big enough")
+	assert.Nil(t, models.BestFittingMediaURL(nil, 512))
+
+	name, isRendition := large.Purpose.RenditionName()
+	assert.True(t, isRendition)
+	assert.Equal(t, "large", name)
+
+	_, isRendition = thumbnail.Purpose.RenditionName()
+	assert.False(t, isRendition)
 }
 
 func TestMediaURLGetURL(t *testing.T) {
@@ -71,6 +99,30 @@ func TestMediaURLGetURL(t *testing.T) {
 	}
 
 	assert.Equal(t, "/api/video/video.mp4", video.URL())
+
+	preview := models.MediaURL{
+		MediaName:   "video_preview.mp4",
+		ContentType: "video/mp4",
+		Purpose:     models.VideoPreview,
+	}
+
+	assert.Equal(t, "/api/video/video_preview.mp4", preview.URL())
+
+	hls := models.MediaURL{
+		MediaName:   "hls_video",
+		ContentType: "application/vnd.apple.mpegurl",
+		Purpose:     models.VideoHLS,
+	}
+
+	assert.Equal(t, "/api/video/hls/hls_video/master.m3u8", hls.URL())
+
+	motion := models.MediaURL{
+		MediaName:   "motion_video.mp4",
+		ContentType: "video/mp4",
+		Purpose:     models.MotionVideo,
+	}
+
+	assert.Equal(t, "/api/video/motion_video.mp4", motion.URL())
 }
 
 func TestMediaGetThumbnail(t *testing.T) {
@@ -125,3 +177,10 @@ func TestMediaGetThumbnail(t *testing.T) {
 	assert.Equal(t, thumb.MediaName, "video-thumbnail.jpg")
 	assert.NotNil(t, thumb.Media)
 }
+
+func TestMediaTypeForms(t *testing.T) {
+	assert.Equal(t, models.MediaTypeVideo, models.MediaType("Video").Normalized())
+	assert.Equal(t, models.MediaTypePhoto, models.MediaTypePhoto.Normalized())
+	assert.Equal(t, models.MediaType("Video"), models.MediaTypeVideo.Formatted())
+	assert.Equal(t, models.MediaType("Photo"), models.MediaType("Photo").Formatted())
+}
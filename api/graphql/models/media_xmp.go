@@ -0,0 +1,18 @@
+package models
+
+// MediaXMP stores the curation metadata of the XMP sidecar of a media, as written by tools like darktable and Lightroom
+type MediaXMP struct {
+	Model
+	// Star rating from 0 to 5, -1 for rejected media
+	Rating      *int
+	Label       *string
+	Title       *string
+	Description *string
+	Keywords    []string `gorm:"type:text;serializer:json"`
+	// Hierarchical keywords, with the levels separated by |
+	HierarchicalKeywords []string `gorm:"type:text;serializer:json"`
+}
+
+func (MediaXMP) TableName() string {
+	return "media_xmp"
+}
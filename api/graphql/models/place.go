@@ -0,0 +1,46 @@
+package models
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Place is the location of media, resolved from its GPS coordinates
+type Place struct {
+	// ISO 3166 code of the country
+	CountryCode string  `json:"countryCode"`
+	Country     string  `json:"country"`
+	Region      *string `json:"region,omitempty"`
+	// Nil if the coordinates are too far from a city
+	City *string `json:"city,omitempty"`
+}
+
+// columns returns the values of the place columns of MediaEXIF and MediaOverride
+func (p *Place) columns() (countryCode, country, region, city *string) {
+	if p == nil {
+		return nil, nil, nil, nil
+	}
+
+	return &p.CountryCode, &p.Country, p.Region, p.City
+}
+
+// placeColumn returns the SQL of a place column of media,
+// the place of coordinates corrected by users takes precedence over the place of the exif data
+func placeColumn(column string) string {
+	return fmt.Sprintf("CASE WHEN media_overrides.gps_latitude IS NOT NULL "+
+		"THEN media_overrides.%[1]s ELSE media_exif.%[1]s END", column)
+}
+
+// MediaPlacesQuery returns a query of the places of media,
+// with the columns `media_id`, `country_code`, `country`, `region` and `city`
+func MediaPlacesQuery(db *gorm.DB) *gorm.DB {
+	return db.Table("media").
+		Select("media.id AS media_id, " +
+			placeColumn("country_code") + " AS country_code, " +
+			placeColumn("country") + " AS country, " +
+			placeColumn("region") + " AS region, " +
+			placeColumn("city") + " AS city").
+		Joins("LEFT JOIN media_exif ON media.exif_id = media_exif.id").
+		Joins("LEFT JOIN media_overrides ON media.id = media_overrides.media_id")
+}
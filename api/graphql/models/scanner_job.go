@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// ScannerJob is a persisted scan of a single album,
+// it is used to resume pending scans after a restart and to keep a history of past scans
+type ScannerJob struct {
+	Model
+	AlbumID        *int               `gorm:"index"`
+	Album          *Album             `gorm:"constraint:OnDelete:SET NULL;"`
+	AlbumPath      string             `gorm:"not null"`
+	Status         ScannerJobStatus   `gorm:"not null;index"`
+	Priority       ScannerJobPriority `gorm:"not null;default:Normal"`
+	StartedAt      *time.Time
+	FinishedAt     *time.Time
+	Error          *string
+	MediaFound     int `gorm:"not null;default:0"`
+	MediaProcessed int `gorm:"not null;default:0"`
+	MediaSkipped   int `gorm:"not null;default:0"`
+	MediaFailed    int `gorm:"not null;default:0"`
+}
+
+func (ScannerJob) TableName() string {
+	return "scanner_jobs"
+}
@@ -15,6 +15,9 @@ type ShareToken struct {
 	Album    *Album `gorm:"constraint:OnDelete:CASCADE;"`
 	MediaID  *int   `gorm:"index"`
 	Media    *Media `gorm:"constraint:OnDelete:CASCADE;"`
+	// Smart albums are shared with the media matching their filter for the owner of the smart album
+	SmartAlbumID *int        `gorm:"index"`
+	SmartAlbum   *SmartAlbum `gorm:"constraint:OnDelete:CASCADE;"`
 }
 
 func (share *ShareToken) Token() string {
@@ -10,6 +10,9 @@ type SiteInfo struct {
 	InitialSetup         bool `gorm:"not null"`
 	PeriodicScanInterval int  `gorm:"not null"`
 	ConcurrentWorkers    int  `gorm:"not null"`
+	FilesystemWatcher    bool `gorm:"not null;default:false"`
+	// Regular expressions to find the date of media without a date in its metadata, in the names of its file and folders
+	FilenameDatePatterns []string `gorm:"type:text;serializer:json"`
 }
 
 func (SiteInfo) TableName() string {
@@ -26,6 +29,7 @@ func DefaultSiteInfo(db *gorm.DB) SiteInfo {
 		InitialSetup:         true,
 		PeriodicScanInterval: 0,
 		ConcurrentWorkers:    defaultConcurrentWorkers,
+		FilesystemWatcher:    false,
 	}
 }
 
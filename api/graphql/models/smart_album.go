@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// SmartAlbum is a saved filter of a user, that is shown like an album of the media of the user matching the filter.
+// The media are found when the smart album is queried, so it is always up to date with the library.
+type SmartAlbum struct {
+	Model
+	Title   string           `gorm:"not null"`
+	OwnerID int              `gorm:"not null;index"`
+	Owner   User             `gorm:"constraint:OnDelete:CASCADE;"`
+	Filter  SmartAlbumFilter `gorm:"type:text;serializer:json"`
+}
+
+// SmartAlbumFilter are the conditions media must match to be in a smart album, nil conditions are left out
+type SmartAlbumFilter struct {
+	// Dates in the local time the media was shot, like MediaEXIF.DateShot
+	FromDate *time.Time `json:"fromDate,omitempty"`
+	ToDate   *time.Time `json:"toDate,omitempty"`
+	// Matched against the camera model and maker
+	Camera        *string     `json:"camera,omitempty"`
+	OnlyFavorites *bool       `json:"onlyFavorites,omitempty"`
+	MinRating     *int        `json:"minRating,omitempty"`
+	ColorLabel    *ColorLabel `json:"colorLabel,omitempty"`
+	// The media must show all of the people
+	FaceGroupIDs []int      `json:"faceGroupIds,omitempty"`
+	MediaType    *MediaType `json:"mediaType,omitempty"`
+	// Media in the album or its sub albums
+	AlbumID     *int    `json:"albumId,omitempty"`
+	CountryCode *string `json:"countryCode,omitempty"`
+	Region      *string `json:"region,omitempty"`
+	City        *string `json:"city,omitempty"`
+	// Durations of videos in seconds
+	MinDuration *float64 `json:"minDuration,omitempty"`
+	MaxDuration *float64 `json:"maxDuration,omitempty"`
+}
+
+// Normalized returns a copy of the filter with the media type as it is stored in Media.Type
+func (f SmartAlbumFilter) Normalized() SmartAlbumFilter {
+	if f.MediaType != nil {
+		mediaType := f.MediaType.Normalized()
+		f.MediaType = &mediaType
+	}
+
+	return f
+}
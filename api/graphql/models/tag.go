@@ -0,0 +1,177 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Tag labels media. Tags of a user are only visible to that user, tags without a user are keywords
+// embedded in the media files, they are shared by all users who can see the media.
+type Tag struct {
+	Model
+	Name   string `gorm:"not null"`
+	UserID *int   `gorm:"index"`
+	User   *User  `gorm:"constraint:OnDelete:CASCADE;"`
+	// Hash of the owner and name of the tag, to keep the names unique for each user
+	NameHash string `gorm:"not null;unique"`
+}
+
+// MediaTag links a tag to a media
+type MediaTag struct {
+	MediaID int   `gorm:"primaryKey;autoIncrement:false"`
+	Media   Media `gorm:"constraint:OnDelete:CASCADE;"`
+	TagID   int   `gorm:"primaryKey;autoIncrement:false;index"`
+	Tag     Tag   `gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+func (t *Tag) BeforeSave(tx *gorm.DB) error {
+	t.NameHash = TagNameHash(t.UserID, t.Name)
+	return nil
+}
+
+// Keyword returns true if the tag is a keyword from the media files, rather than a tag of a user
+func (t *Tag) Keyword() bool {
+	return t.UserID == nil
+}
+
+// VisibleTo returns true if the tag is a keyword or a tag of the user, only keywords are visible if `user` is nil
+func (t *Tag) VisibleTo(user *User) bool {
+	return t.Keyword() || (user != nil && *t.UserID == user.ID)
+}
+
+// TagNameHash returns the hash that identifies the tag `name` of the user, or the keyword `name` if `userID` is nil
+func TagNameHash(userID *int, name string) string {
+	owner := "keyword"
+	if userID != nil {
+		owner = fmt.Sprintf("user:%d", *userID)
+	}
+
+	return MD5Hash(owner + "/" + name)
+}
+
+// NormalizeTagNames trims the names and removes empty and duplicate names, keeping the order of the rest
+func NormalizeTagNames(names []string) []string {
+	result := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+
+		seen[name] = true
+		result = append(result, name)
+	}
+
+	return result
+}
+
+// FindOrCreateTags returns the tags of the user with the given names, or the keywords if `userID` is nil.
+// Tags that don't exist yet are created.
+func FindOrCreateTags(tx *gorm.DB, userID *int, names []string) ([]*Tag, error) {
+	names = NormalizeTagNames(names)
+	if len(names) == 0 {
+		return []*Tag{}, nil
+	}
+
+	hashes := make([]string, 0, len(names))
+	newTags := make([]*Tag, 0, len(names))
+	for _, name := range names {
+		hashes = append(hashes, TagNameHash(userID, name))
+		newTags = append(newTags, &Tag{Name: name, UserID: userID})
+	}
+
+	// Tags may be created at the same time by another scanner worker, the unique hash resolves the conflict
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&newTags).Error; err != nil {
+		return nil, errors.Wrap(err, "create tags")
+	}
+
+	var tags []*Tag
+	if err := tx.Where("name_hash IN ?", hashes).Order("name").Find(&tags).Error; err != nil {
+		return nil, errors.Wrap(err, "get tags")
+	}
+
+	return tags, nil
+}
+
+// SetMediaKeywords replaces the keyword tags of the media with the given keywords, and updates its search document.
+// Tags of users on the media are kept.
+func SetMediaKeywords(tx *gorm.DB, media *Media, keywords []string) error {
+	tags, err := FindOrCreateTags(tx, nil, keywords)
+	if err != nil {
+		return err
+	}
+
+	tagIDs := make([]int, 0, len(tags))
+	for _, tag := range tags {
+		tagIDs = append(tagIDs, tag.ID)
+	}
+
+	unlinkQuery := tx.
+		Where("media_id = ?", media.ID).
+		Where("tag_id IN (?)", tx.Model(&Tag{}).Select("id").Where("user_id IS NULL"))
+	if len(tagIDs) > 0 {
+		unlinkQuery = unlinkQuery.Where("tag_id NOT IN ?", tagIDs)
+	}
+
+	if err := unlinkQuery.Delete(&MediaTag{}).Error; err != nil {
+		return errors.Wrap(err, "remove keywords of media")
+	}
+
+	if err := LinkMediaTags(tx, []int{media.ID}, tagIDs); err != nil {
+		return err
+	}
+
+	return UpdateMediaSearchDocuments(tx, []int{media.ID})
+}
+
+// DeleteOrphanKeywords deletes the keywords that are no longer embedded in any media.
+// Tags of users are kept until they delete them, even if they are not on any media.
+func DeleteOrphanKeywords(db *gorm.DB) error {
+	err := db.
+		Where("user_id IS NULL").
+		Where("id NOT IN (?)", db.Model(&MediaTag{}).Select("tag_id")).
+		Delete(&Tag{}).Error
+	if err != nil {
+		return errors.Wrap(err, "delete orphan keywords")
+	}
+
+	return nil
+}
+
+// LinkMediaTags adds the tags to all the given media, links that already exist are left as they are
+func LinkMediaTags(tx *gorm.DB, mediaIDs []int, tagIDs []int) error {
+	links := make([]MediaTag, 0, len(mediaIDs)*len(tagIDs))
+	for _, mediaID := range mediaIDs {
+		for _, tagID := range tagIDs {
+			links = append(links, MediaTag{MediaID: mediaID, TagID: tagID})
+		}
+	}
+
+	if len(links) == 0 {
+		return nil
+	}
+
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error; err != nil {
+		return errors.Wrap(err, "add tags to media")
+	}
+
+	return nil
+}
+
+// UserTagsQuery returns a query of the tags visible to the user, that is the tags of the user
+// and the keywords of the media in the albums of the user
+func UserTagsQuery(db *gorm.DB, user *User) *gorm.DB {
+	keywordsQuery := db.Table("media_tags").
+		Select("media_tags.tag_id").
+		Joins("JOIN media ON media.id = media_tags.media_id").
+		Where("media.album_id IN (?)", db.Table("user_albums").Select("user_albums.album_id").Where("user_albums.user_id = ?", user.ID))
+
+	return db.Model(&Tag{}).
+		Where("tags.user_id = ? OR (tags.user_id IS NULL AND tags.id IN (?))", user.ID, keywordsQuery)
+}
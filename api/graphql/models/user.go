@@ -25,6 +25,13 @@ type UserMediaData struct {
 	UserID   int  `gorm:"primaryKey;autoIncrement:false"`
 	MediaID  int  `gorm:"primaryKey;autoIncrement:false"`
 	Favorite bool `gorm:"not null;default:false"`
+	// Hidden media are left out of the timeline of the user, used to hide duplicate copies
+	Hidden bool `gorm:"not null;default:false"`
+	// Star rating from 0 to 5, nil if the user hasn't rated the media, then the rating of the XMP sidecar is used
+	Rating *int
+	// Colour label, nil if the user hasn't labelled the media, then the label of the XMP sidecar is used.
+	// An empty label removes the label of the XMP sidecar.
+	ColorLabel *string `gorm:"size:16"`
 }
 
 type UserAlbums struct {
@@ -187,7 +194,10 @@ func (user *User) FavoriteMedia(db *gorm.DB, mediaID int, favorite bool) (*Media
 		Favorite: favorite,
 	}
 
-	if err := db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&userMediaData).Error; err != nil {
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "media_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"favorite", "updated_at"}),
+	}).Create(&userMediaData).Error; err != nil {
 		return nil, errors.Wrapf(err, "update user favorite media in database")
 	}
 
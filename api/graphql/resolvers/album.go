@@ -18,26 +18,10 @@ import (
 )
 
 // Media is the resolver for the media field.
-func (r *albumResolver) Media(ctx context.Context, obj *models.Album, order *models.Ordering, paginate *models.Pagination, onlyFavorites *bool) ([]*models.Media, error) {
-	db := r.DB(ctx)
-
-	query := db.
-		Where("media.album_id = ?", obj.ID).
-		Where("media.id IN (?)", db.Model(&models.MediaURL{}).
-			Select("media_urls.media_id").
-			Where("media_urls.media_id = media.id"))
-
-	if onlyFavorites != nil && *onlyFavorites == true {
-		user := auth.UserFromContext(ctx)
-		if user == nil {
-			return nil, errors.New("cannot get favorite media without being authorized")
-		}
-
-		favoriteQuery := db.Model(&models.UserMediaData{
-			UserID: user.ID,
-		}).Where("user_media_data.media_id = media.id").Where("user_media_data.favorite = true")
-
-		query = query.Where("EXISTS (?)", favoriteQuery)
+func (r *albumResolver) Media(ctx context.Context, obj *models.Album, order *models.Ordering, paginate *models.Pagination, onlyFavorites *bool, minRating *int, colorLabel *models.ColorLabel) ([]*models.Media, error) {
+	query, err := albumMediaQuery(ctx, r.DB(ctx), obj, onlyFavorites, minRating, colorLabel)
+	if err != nil {
+		return nil, err
 	}
 
 	query = models.FormatSQL(query, order, paginate)
@@ -50,6 +34,25 @@ func (r *albumResolver) Media(ctx context.Context, obj *models.Album, order *mod
 	return media, nil
 }
 
+// MediaConnection is the resolver for the mediaConnection field.
+func (r *albumResolver) MediaConnection(ctx context.Context, obj *models.Album, first *int, after *string, last *int, before *string, orderDirection *models.OrderDirection, onlyFavorites *bool, minRating *int, colorLabel *models.ColorLabel) (*models.MediaConnection, error) {
+	db := r.DB(ctx)
+	query, err := albumMediaQuery(ctx, db, obj, onlyFavorites, minRating, colorLabel)
+	if err != nil {
+		return nil, err
+	}
+
+	desc := orderDirection != nil && *orderDirection == models.OrderDirectionDesc
+	args := models.ConnectionArgs{First: first, After: after, Last: last, Before: before}
+	media, pageInfo, err := models.PaginateConnection(query,
+		models.MediaDateShotColumn, models.MediaIDColumn, desc, args, models.MediaCursor)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewMediaConnection(media, pageInfo), nil
+}
+
 // SubAlbums is the resolver for the subAlbums field.
 func (r *albumResolver) SubAlbums(ctx context.Context, obj *models.Album, order *models.Ordering, paginate *models.Pagination) ([]*models.Album, error) {
 	var albums []*models.Album
@@ -115,6 +118,22 @@ func (r *mutationResolver) SetAlbumCover(ctx context.Context, coverID int) (*mod
 	return actions.SetAlbumCover(r.DB(ctx), user, coverID)
 }
 
+// SetAlbumXMPWriteback is the resolver for the setAlbumXMPWriteback field.
+func (r *mutationResolver) SetAlbumXMPWriteback(ctx context.Context, albumID int, enabled bool) (*models.Album, error) {
+	db := r.DB(ctx)
+
+	var album models.Album
+	if err := db.First(&album, albumID).Error; err != nil {
+		return nil, fmt.Errorf("get album (%d): %w", albumID, err)
+	}
+
+	if err := db.Model(&album).Update("xmp_writeback", enabled).Error; err != nil {
+		return nil, fmt.Errorf("update XMP writeback of album (%d): %w", albumID, err)
+	}
+
+	return &album, nil
+}
+
 // MyAlbums is the resolver for the myAlbums field.
 func (r *queryResolver) MyAlbums(ctx context.Context, order *models.Ordering, paginate *models.Pagination, onlyRoot *bool, showEmpty *bool, onlyWithFavorites *bool) ([]*models.Album, error) {
 	user := auth.UserFromContext(ctx)
@@ -0,0 +1,47 @@
+package resolvers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/photoview/photoview/api/graphql/auth"
+	"github.com/photoview/photoview/api/graphql/models"
+	"gorm.io/gorm"
+)
+
+// albumMediaQuery returns a query of the processed media of the album, that aren't hidden in a stack
+func albumMediaQuery(ctx context.Context, db *gorm.DB, album *models.Album, onlyFavorites *bool, minRating *int,
+	colorLabel *models.ColorLabel) (*gorm.DB, error) {
+
+	query := db.Model(&models.Media{}).
+		Where("media.album_id = ?", album.ID).
+		Where("media.id IN (?)", db.Model(&models.MediaURL{}).
+			Select("media_urls.media_id").
+			Where("media_urls.media_id = media.id")).
+		Where("media.id NOT IN (?)", models.HiddenStackMediaQuery(db))
+
+	if onlyFavorites != nil && *onlyFavorites == true {
+		user := auth.UserFromContext(ctx)
+		if user == nil {
+			return nil, errors.New("cannot get favorite media without being authorized")
+		}
+
+		favoriteQuery := db.Model(&models.UserMediaData{
+			UserID: user.ID,
+		}).Where("user_media_data.media_id = media.id").Where("user_media_data.favorite = true")
+
+		query = query.Where("EXISTS (?)", favoriteQuery)
+	}
+
+	if minRating != nil || colorLabel != nil {
+		// Media shared by a share token are filtered by the ratings of their XMP sidecars
+		userID := 0
+		if user := auth.UserFromContext(ctx); user != nil {
+			userID = user.ID
+		}
+
+		query = models.FilterMediaByRating(query, userID, minRating, colorLabel)
+	}
+
+	return query, nil
+}
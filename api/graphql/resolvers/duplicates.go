@@ -0,0 +1,44 @@
+package resolvers
+
+// This file will be automatically regenerated based on the schema, any resolver
+// implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.91
+
+import (
+	"context"
+
+	"github.com/photoview/photoview/api/graphql/auth"
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/graphql/models/actions"
+)
+
+// HideDuplicateMedia is the resolver for the hideDuplicateMedia field.
+func (r *mutationResolver) HideDuplicateMedia(ctx context.Context, preferredMediaID int, mediaIds []int) ([]*models.Media, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.HideDuplicateMedia(r.DB(ctx), user, preferredMediaID, mediaIds)
+}
+
+// UnhideMedia is the resolver for the unhideMedia field.
+func (r *mutationResolver) UnhideMedia(ctx context.Context, mediaIds []int) (int, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return 0, auth.ErrUnauthorized
+	}
+
+	return actions.UnhideMedia(r.DB(ctx), user, mediaIds)
+}
+
+// DuplicateGroups is the resolver for the duplicateGroups field.
+func (r *queryResolver) DuplicateGroups(ctx context.Context, threshold *int, paginate *models.Pagination) ([]*models.DuplicateGroup, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.DuplicateGroups(r.DB(ctx), user, threshold, paginate)
+}
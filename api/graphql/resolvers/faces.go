@@ -14,6 +14,7 @@ import (
 	"github.com/photoview/photoview/api/graphql/models"
 	"github.com/photoview/photoview/api/scanner/face_detection"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ImageFaces is the resolver for the imageFaces field.
@@ -28,20 +29,11 @@ func (r *faceGroupResolver) ImageFaces(ctx context.Context, obj *models.FaceGrou
 		return nil, ErrFaceDetectorNotInitialized
 	}
 
-	if err := user.FillAlbums(db); err != nil {
+	query, err := faceGroupImageFacesQuery(db, user, obj)
+	if err != nil {
 		return nil, err
 	}
 
-	userAlbumIDs := make([]int, len(user.Albums))
-	for i, album := range user.Albums {
-		userAlbumIDs[i] = album.ID
-	}
-
-	query := db.
-		Joins("Media").
-		Where(faceGroupIDIsQuestion, obj.ID).
-		Where("album_id IN (?)", userAlbumIDs)
-
 	query = models.FormatSQL(query, nil, paginate)
 
 	var imageFaces []*models.ImageFace
@@ -52,6 +44,33 @@ func (r *faceGroupResolver) ImageFaces(ctx context.Context, obj *models.FaceGrou
 	return imageFaces, nil
 }
 
+// ImageFaceConnection is the resolver for the imageFaceConnection field.
+func (r *faceGroupResolver) ImageFaceConnection(ctx context.Context, obj *models.FaceGroup, first *int, after *string, last *int, before *string) (*models.ImageFaceConnection, error) {
+	db := r.DB(ctx)
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, errors.New("unauthorized")
+	}
+
+	if face_detection.GlobalFaceDetector == nil {
+		return nil, ErrFaceDetectorNotInitialized
+	}
+
+	query, err := faceGroupImageFacesQuery(db, user, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	args := models.ConnectionArgs{First: first, After: after, Last: last, Before: before}
+	imageFaces, pageInfo, err := models.PaginateConnection(query,
+		clause.Column{Table: "Media", Name: "date_shot"}, clause.Column{Table: "image_faces", Name: "id"}, true, args, models.ImageFaceCursor)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewImageFaceConnection(imageFaces, pageInfo), nil
+}
+
 // ImageFaceCount is the resolver for the imageFaceCount field.
 func (r *faceGroupResolver) ImageFaceCount(ctx context.Context, obj *models.FaceGroup) (int, error) {
 	db := r.DB(ctx)
@@ -137,6 +156,10 @@ func (r *mutationResolver) SetFaceGroupLabel(ctx context.Context, faceGroupID in
 		return nil, err
 	}
 
+	if err := models.UpdateFaceGroupSearchDocuments(db, faceGroup.ID); err != nil {
+		return nil, err
+	}
+
 	return faceGroup, nil
 }
 
@@ -212,7 +235,7 @@ func (r *mutationResolver) CombineFaceGroups(ctx context.Context, destinationFac
 		if err != nil {
 			return err
 		}
-		return nil
+		return models.UpdateFaceGroupSearchDocuments(tx, destinationFaceGroup.ID)
 	})
 
 	if updateError != nil {
@@ -284,7 +307,7 @@ func (r *mutationResolver) MoveImageFaces(ctx context.Context, imageFaceIDs []in
 			return err
 		}
 
-		return nil
+		return models.UpdateFaceGroupSearchDocuments(tx, destFaceGroup.ID)
 	})
 
 	if transErr != nil {
@@ -313,8 +336,16 @@ func (r *mutationResolver) RecognizeUnlabeledFaces(ctx context.Context) ([]*mode
 	transactionError := db.Transaction(func(tx *gorm.DB) error {
 		var err error
 		updatedImageFaces, err = face_detection.GlobalFaceDetector.RecognizeUnlabeledFaces(tx, user)
+		if err != nil {
+			return err
+		}
+
+		mediaIDs := make([]int, 0, len(updatedImageFaces))
+		for _, imageFace := range updatedImageFaces {
+			mediaIDs = append(mediaIDs, imageFace.MediaID)
+		}
 
-		return err
+		return models.UpdateMediaSearchDocuments(tx, mediaIDs)
 	})
 
 	if transactionError != nil {
@@ -361,7 +392,7 @@ func (r *mutationResolver) DetachImageFaces(ctx context.Context, imageFaceIDs []
 			return err
 		}
 
-		return nil
+		return models.UpdateFaceGroupSearchDocuments(tx, newFaceGroup.ID)
 	})
 
 	if transactionError != nil {
@@ -161,3 +161,22 @@ func deleteFaceGroups(sourceFaceGroups []*models.FaceGroup, tx *gorm.DB) error {
 	}
 	return nil
 }
+
+// faceGroupImageFacesQuery returns a query of the faces of the face group on media of the user, joined with their media
+func faceGroupImageFacesQuery(db *gorm.DB, user *models.User, faceGroup *models.FaceGroup) (*gorm.DB, error) {
+	if err := user.FillAlbums(db); err != nil {
+		return nil, err
+	}
+
+	userAlbumIDs := make([]int, len(user.Albums))
+	for i, album := range user.Albums {
+		userAlbumIDs[i] = album.ID
+	}
+
+	query := db.
+		Joins("Media").
+		Where(faceGroupIDIsQuestion, faceGroup.ID).
+		Where("album_id IN (?)", userAlbumIDs)
+
+	return query, nil
+}
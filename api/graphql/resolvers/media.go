@@ -15,13 +15,34 @@ import (
 	"github.com/photoview/photoview/api/graphql/models"
 	"github.com/photoview/photoview/api/graphql/models/actions"
 	"github.com/photoview/photoview/api/scanner/face_detection"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
 )
 
+// Title is the resolver for the title field.
+func (r *mediaResolver) Title(ctx context.Context, obj *models.Media) (string, error) {
+	override, err := dataloader.For(ctx).MediaOverride.Load(obj.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if override != nil && override.Title != nil {
+		return *override.Title, nil
+	}
+
+	return obj.Title, nil
+}
+
 // Thumbnail is the resolver for the thumbnail field.
-func (r *mediaResolver) Thumbnail(ctx context.Context, obj *models.Media) (*models.MediaURL, error) {
-	return dataloader.For(ctx).MediaThumbnail.Load(obj.ID)
+func (r *mediaResolver) Thumbnail(ctx context.Context, obj *models.Media, size *int) (*models.MediaURL, error) {
+	if size == nil {
+		return dataloader.For(ctx).MediaThumbnail.Load(obj.ID)
+	}
+
+	mediaURLs, err := dataloader.For(ctx).MediaScaledURLs.Load(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get thumbnails for media (%s): %w", obj.Path, err)
+	}
+
+	return models.BestFittingMediaURL(mediaURLs, *size), nil
 }
 
 // HighRes is the resolver for the highRes field.
@@ -42,6 +63,56 @@ func (r *mediaResolver) VideoWeb(ctx context.Context, obj *models.Media) (*model
 	return dataloader.For(ctx).MediaVideoWeb.Load(obj.ID)
 }
 
+// VideoPreview is the resolver for the videoPreview field.
+func (r *mediaResolver) VideoPreview(ctx context.Context, obj *models.Media) (*models.MediaURL, error) {
+	if obj.Type != models.MediaTypeVideo {
+		return nil, nil
+	}
+
+	return dataloader.For(ctx).MediaVideoPreview.Load(obj.ID)
+}
+
+// VideoHls is the resolver for the videoHls field.
+func (r *mediaResolver) VideoHls(ctx context.Context, obj *models.Media) (*models.MediaURL, error) {
+	if obj.Type != models.MediaTypeVideo {
+		return nil, nil
+	}
+
+	return dataloader.For(ctx).MediaVideoHLS.Load(obj.ID)
+}
+
+// MotionVideo is the resolver for the motionVideo field.
+func (r *mediaResolver) MotionVideo(ctx context.Context, obj *models.Media) (*models.MediaURL, error) {
+	if obj.Type != models.MediaTypePhoto {
+		return nil, nil
+	}
+
+	return dataloader.For(ctx).MediaMotionVideo.Load(obj.ID)
+}
+
+// Renditions is the resolver for the renditions field.
+func (r *mediaResolver) Renditions(ctx context.Context, obj *models.Media) ([]*models.MediaRendition, error) {
+	mediaURLs, err := dataloader.For(ctx).MediaScaledURLs.Load(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get renditions for media (%s): %w", obj.Path, err)
+	}
+
+	renditions := make([]*models.MediaRendition, 0, len(mediaURLs))
+	for _, url := range mediaURLs {
+		name, isRendition := url.Purpose.RenditionName()
+		if !isRendition {
+			continue
+		}
+
+		renditions = append(renditions, &models.MediaRendition{
+			Name:     name,
+			MediaURL: url,
+		})
+	}
+
+	return renditions, nil
+}
+
 // Album is the resolver for the album field.
 func (r *mediaResolver) Album(ctx context.Context, obj *models.Media) (*models.Album, error) {
 	var album models.Album
@@ -54,8 +125,13 @@ func (r *mediaResolver) Album(ctx context.Context, obj *models.Media) (*models.A
 
 // Exif is the resolver for the exif field.
 func (r *mediaResolver) Exif(ctx context.Context, obj *models.Media) (*models.MediaEXIF, error) {
+	override, err := dataloader.For(ctx).MediaOverride.Load(obj.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	if obj.Exif != nil {
-		return obj.Exif, nil
+		return override.ApplyToEXIF(obj.Exif), nil
 	}
 
 	var exif models.MediaEXIF
@@ -63,7 +139,25 @@ func (r *mediaResolver) Exif(ctx context.Context, obj *models.Media) (*models.Me
 		return nil, err
 	}
 
-	return &exif, nil
+	return override.ApplyToEXIF(&exif), nil
+}
+
+// Xmp is the resolver for the xmp field.
+func (r *mediaResolver) Xmp(ctx context.Context, obj *models.Media) (*models.MediaXMP, error) {
+	if obj.XMP != nil {
+		return obj.XMP, nil
+	}
+
+	if obj.XMPID == nil {
+		return nil, nil
+	}
+
+	var xmp models.MediaXMP
+	if err := r.DB(ctx).First(&xmp, *obj.XMPID).Error; err != nil {
+		return nil, fmt.Errorf("get XMP metadata of media (%s): %w", obj.Path, err)
+	}
+
+	return &xmp, nil
 }
 
 // Favorite is the resolver for the favorite field.
@@ -79,10 +173,43 @@ func (r *mediaResolver) Favorite(ctx context.Context, obj *models.Media) (bool,
 	})
 }
 
+// Rating is the resolver for the rating field.
+func (r *mediaResolver) Rating(ctx context.Context, obj *models.Media) (int, error) {
+	rating, err := userMediaRating(ctx, obj)
+	if err != nil {
+		return 0, err
+	}
+
+	return rating.GetRating(), nil
+}
+
+// ColorLabel is the resolver for the colorLabel field.
+func (r *mediaResolver) ColorLabel(ctx context.Context, obj *models.Media) (*models.ColorLabel, error) {
+	rating, err := userMediaRating(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return rating.GetColorLabel(), nil
+}
+
+// Hidden is the resolver for the hidden field.
+func (r *mediaResolver) Hidden(ctx context.Context, obj *models.Media) (bool, error) {
+	if user := auth.UserFromContext(ctx); user == nil {
+		return false, auth.ErrUnauthorized
+	}
+
+	userData, err := userMediaRating(ctx, obj)
+	if err != nil {
+		return false, fmt.Errorf("get hidden state of media (%s): %w", obj.Path, err)
+	}
+
+	return userData.Hidden, nil
+}
+
 // Type is the resolver for the type field.
 func (r *mediaResolver) Type(ctx context.Context, obj *models.Media) (models.MediaType, error) {
-	formattedType := models.MediaType(cases.Title(language.Und).String(string(obj.Type)))
-	return formattedType, nil
+	return obj.Type.Formatted(), nil
 }
 
 // Shares is the resolver for the shares field.
@@ -105,6 +232,10 @@ func (r *mediaResolver) Downloads(ctx context.Context, obj *models.Media) ([]*mo
 	downloads := make([]*models.MediaDownload, 0)
 
 	for _, url := range mediaUrls {
+		// HLS streams are a directory of segments, that can't be downloaded as a single file
+		if url.Purpose == models.VideoHLS {
+			continue
+		}
 
 		var title string
 		switch {
@@ -118,6 +249,14 @@ func (r *mediaResolver) Downloads(ctx context.Context, obj *models.Media) ([]*mo
 			title = "Video thumbnail"
 		case url.Purpose == models.VideoWeb:
 			title = "Web optimized video"
+		case url.Purpose == models.VideoPreview:
+			title = "Video preview"
+		case url.Purpose == models.MotionVideo:
+			title = "Motion video"
+		default:
+			if name, isRendition := url.Purpose.RenditionName(); isRendition {
+				title = fmt.Sprintf("Rendition %s", name)
+			}
 		}
 
 		downloads = append(downloads, &models.MediaDownload{
@@ -157,6 +296,26 @@ func (r *mutationResolver) FavoriteMedia(ctx context.Context, mediaID int, favor
 	return user.FavoriteMedia(r.DB(ctx), mediaID, favorite)
 }
 
+// RateMedia is the resolver for the rateMedia field.
+func (r *mutationResolver) RateMedia(ctx context.Context, mediaIds []int, rating int) ([]*models.Media, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.RateMedia(r.DB(ctx), user, mediaIds, rating)
+}
+
+// LabelMedia is the resolver for the labelMedia field.
+func (r *mutationResolver) LabelMedia(ctx context.Context, mediaIds []int, label *models.ColorLabel) ([]*models.Media, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.LabelMedia(r.DB(ctx), user, mediaIds, label)
+}
+
 // MyMedia is the resolver for the myMedia field.
 func (r *queryResolver) MyMedia(ctx context.Context, order *models.Ordering, paginate *models.Pagination) ([]*models.Media, error) {
 	user := auth.UserFromContext(ctx)
@@ -167,6 +326,17 @@ func (r *queryResolver) MyMedia(ctx context.Context, order *models.Ordering, pag
 	return actions.MyMedia(r.DB(ctx), user, order, paginate)
 }
 
+// MyMediaConnection is the resolver for the myMediaConnection field.
+func (r *queryResolver) MyMediaConnection(ctx context.Context, first *int, after *string, last *int, before *string, orderDirection *models.OrderDirection) (*models.MediaConnection, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	args := models.ConnectionArgs{First: first, After: after, Last: last, Before: before}
+	return actions.MyMediaConnection(r.DB(ctx), user, args, orderDirection)
+}
+
 // Media is the resolver for the media field.
 func (r *queryResolver) Media(ctx context.Context, id int, tokenCredentials *models.ShareTokenCredentials) (*models.Media, error) {
 	db := r.DB(ctx)
@@ -177,9 +347,24 @@ func (r *queryResolver) Media(ctx context.Context, id int, tokenCredentials *mod
 			return nil, err
 		}
 
-		if *shareToken.MediaID == id {
+		if shareToken.MediaID != nil && *shareToken.MediaID == id {
 			return shareToken.Media, nil
 		}
+
+		if shareToken.SmartAlbum != nil {
+			contains, err := actions.SmartAlbumContainsMedia(db, shareToken.SmartAlbum, id)
+			if err != nil {
+				return nil, err
+			}
+
+			if contains {
+				var media models.Media
+				if err := db.First(&media, id).Error; err != nil {
+					return nil, fmt.Errorf("get media of shared smart album: %w", err)
+				}
+				return &media, nil
+			}
+		}
 	}
 
 	user := auth.UserFromContext(ctx)
@@ -0,0 +1,23 @@
+package resolvers
+
+import (
+	"context"
+
+	"github.com/photoview/photoview/api/dataloader"
+	"github.com/photoview/photoview/api/graphql/auth"
+	"github.com/photoview/photoview/api/graphql/models"
+)
+
+// userMediaRating loads the data of the media for the logged in user, like its rating, colour label and hidden state.
+// Without a logged in user, for media shared by a share token, only the values of the XMP sidecar are used.
+func userMediaRating(ctx context.Context, media *models.Media) (*models.UserMediaData, error) {
+	userID := 0
+	if user := auth.UserFromContext(ctx); user != nil {
+		userID = user.ID
+	}
+
+	return dataloader.For(ctx).UserMediaRating.Load(&models.UserMediaData{
+		UserID:  userID,
+		MediaID: media.ID,
+	})
+}
@@ -23,16 +23,19 @@ func (r *queryResolver) MyMediaGeoJSON(ctx context.Context) (any, error) {
 
 	var media []*geoMedia
 
+	// Coordinates corrected by users take precedence over the coordinates of the exif data
 	err := r.DB(ctx).Table("media").
-		Select("media.id AS media_id, media.title AS media_title, "+
+		Select("media.id AS media_id, COALESCE(media_overrides.title, media.title) AS media_title, "+
 			"media_urls.media_name AS thumbnail_name, media_urls.width AS thumbnail_width, "+
-			"media_urls.height AS thumbnail_height, media_exif.gps_latitude AS latitude, "+
-			"media_exif.gps_longitude AS longitude").
-		Joins("INNER JOIN media_exif ON media.exif_id = media_exif.id").
+			"media_urls.height AS thumbnail_height, "+
+			"COALESCE(media_overrides.gps_latitude, media_exif.gps_latitude) AS latitude, "+
+			"COALESCE(media_overrides.gps_longitude, media_exif.gps_longitude) AS longitude").
+		Joins("LEFT JOIN media_exif ON media.exif_id = media_exif.id").
+		Joins("LEFT JOIN media_overrides ON media.id = media_overrides.media_id").
 		Joins("INNER JOIN media_urls ON media.id = media_urls.media_id").
 		Joins("INNER JOIN user_albums ON media.album_id = user_albums.album_id").
-		Where("media_exif.gps_latitude IS NOT NULL").
-		Where("media_exif.gps_longitude IS NOT NULL").
+		Where("COALESCE(media_overrides.gps_latitude, media_exif.gps_latitude) IS NOT NULL").
+		Where("COALESCE(media_overrides.gps_longitude, media_exif.gps_longitude) IS NOT NULL").
 		Where("media_urls.purpose = 'thumbnail'").
 		Where("user_albums.user_id = ?", user.ID).
 		Scan(&media).Error
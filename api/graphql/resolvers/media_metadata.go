@@ -0,0 +1,73 @@
+package resolvers
+
+// This file will be automatically regenerated based on the schema, any resolver
+// implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.91
+
+import (
+	"context"
+
+	"github.com/photoview/photoview/api/dataloader"
+	"github.com/photoview/photoview/api/graphql/auth"
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/graphql/models/actions"
+)
+
+// CorrectedMetadata is the resolver for the correctedMetadata field.
+func (r *mediaResolver) CorrectedMetadata(ctx context.Context, obj *models.Media) ([]models.MediaMetadataField, error) {
+	override, err := dataloader.For(ctx).MediaOverride.Load(obj.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]models.MediaMetadataField, 0)
+	if override == nil {
+		return fields, nil
+	}
+
+	if override.Title != nil {
+		fields = append(fields, models.MediaMetadataFieldTitle)
+	}
+	if override.Description != nil {
+		fields = append(fields, models.MediaMetadataFieldDescription)
+	}
+	if override.DateShot != nil {
+		fields = append(fields, models.MediaMetadataFieldDateShot)
+	}
+	if override.GPSLatitude != nil && override.GPSLongitude != nil {
+		fields = append(fields, models.MediaMetadataFieldCoordinates)
+	}
+
+	return fields, nil
+}
+
+// EditMediaMetadata is the resolver for the editMediaMetadata field.
+func (r *mutationResolver) EditMediaMetadata(ctx context.Context, mediaIds []int, metadata models.MediaMetadataInput) ([]*models.Media, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.EditMediaMetadata(r.DB(ctx), user, mediaIds, metadata)
+}
+
+// ResetMediaMetadata is the resolver for the resetMediaMetadata field.
+func (r *mutationResolver) ResetMediaMetadata(ctx context.Context, mediaIds []int, fields []models.MediaMetadataField) ([]*models.Media, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.ResetMediaMetadata(r.DB(ctx), user, mediaIds, fields)
+}
+
+// ShiftMediaTime is the resolver for the shiftMediaTime field.
+func (r *mutationResolver) ShiftMediaTime(ctx context.Context, filter models.TimeShiftFilter, offsetSeconds int, timezoneOffsetSeconds *int, dryRun bool) ([]*models.TimeShiftResult, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.ShiftMediaTime(r.DB(ctx), user, filter, offsetSeconds, timezoneOffsetSeconds, dryRun)
+}
@@ -0,0 +1,34 @@
+package resolvers
+
+// This file will be automatically regenerated based on the schema, any resolver
+// implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.91
+
+import (
+	"context"
+
+	"github.com/photoview/photoview/api/graphql/auth"
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/graphql/models/actions"
+)
+
+// Places is the resolver for the places field.
+func (r *queryResolver) Places(ctx context.Context, level models.PlaceLevel, countryCode *string) ([]*models.PlaceMediaCount, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.Places(r.DB(ctx), user, level, countryCode)
+}
+
+// PlaceMedia is the resolver for the placeMedia field.
+func (r *queryResolver) PlaceMedia(ctx context.Context, countryCode string, region *string, city *string, order *models.Ordering, paginate *models.Pagination) ([]*models.Media, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.PlaceMedia(r.DB(ctx), user, countryCode, region, city, order, paginate)
+}
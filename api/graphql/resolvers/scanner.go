@@ -13,6 +13,8 @@ import (
 
 	"github.com/photoview/photoview/api/database/drivers"
 	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/scanner/filename_date"
+	"github.com/photoview/photoview/api/scanner/filesystem_watcher"
 	"github.com/photoview/photoview/api/scanner/periodic_scanner"
 	"github.com/photoview/photoview/api/scanner/scanner_queue"
 	"gorm.io/gorm"
@@ -20,7 +22,7 @@ import (
 
 // ScanAll is the resolver for the scanAll field.
 func (r *mutationResolver) ScanAll(ctx context.Context) (*models.ScannerResult, error) {
-	err := scanner_queue.AddAllToQueue()
+	err := scanner_queue.AddAllToQueue(models.ScannerJobPriorityNormal)
 	if err != nil {
 		return nil, err
 	}
@@ -41,7 +43,9 @@ func (r *mutationResolver) ScanUser(ctx context.Context, userID int) (*models.Sc
 		return nil, fmt.Errorf("get user from database: %w", err)
 	}
 
-	scanner_queue.AddUserToQueue(&user)
+	if err := scanner_queue.AddUserToQueue(&user, models.ScannerJobPriorityHigh); err != nil {
+		return nil, err
+	}
 
 	startMessage := "Scanner started"
 	return &models.ScannerResult{
@@ -106,3 +110,188 @@ func (r *mutationResolver) SetScannerConcurrentWorkers(ctx context.Context, work
 
 	return siteInfo.ConcurrentWorkers, nil
 }
+
+// SetFilesystemWatcher is the resolver for the setFilesystemWatcher field.
+func (r *mutationResolver) SetFilesystemWatcher(ctx context.Context, enabled bool) (bool, error) {
+	db := r.DB(ctx)
+
+	if err := db.
+		Session(&gorm.Session{AllowGlobalUpdate: true}).
+		Model(&models.SiteInfo{}).
+		Update("filesystem_watcher", enabled).
+		Error; err != nil {
+
+		return false, err
+	}
+
+	var siteInfo models.SiteInfo
+	if err := db.First(&siteInfo).Error; err != nil {
+		return false, err
+	}
+
+	filesystem_watcher.ChangeFilesystemWatcher(siteInfo.FilesystemWatcher)
+
+	return siteInfo.FilesystemWatcher, nil
+}
+
+// SetFilenameDatePatterns is the resolver for the setFilenameDatePatterns field.
+func (r *mutationResolver) SetFilenameDatePatterns(ctx context.Context, patterns []string) ([]string, error) {
+	for _, pattern := range patterns {
+		if _, err := filename_date.CompilePattern(pattern); err != nil {
+			return nil, err
+		}
+	}
+
+	db := r.DB(ctx)
+
+	// Updates with a struct, so the patterns are serialized
+	if err := db.
+		Session(&gorm.Session{AllowGlobalUpdate: true}).
+		Model(&models.SiteInfo{}).
+		Select("filename_date_patterns").
+		Updates(models.SiteInfo{FilenameDatePatterns: patterns}).
+		Error; err != nil {
+
+		return nil, err
+	}
+	filename_date.ResetSiteMatcher()
+
+	var siteInfo models.SiteInfo
+	if err := db.First(&siteInfo).Error; err != nil {
+		return nil, err
+	}
+
+	return siteInfo.FilenameDatePatterns, nil
+}
+
+// ClearMediaScanErrors is the resolver for the clearMediaScanErrors field.
+func (r *mutationResolver) ClearMediaScanErrors(ctx context.Context, ids []int) (int, error) {
+	query := r.DB(ctx).Session(&gorm.Session{AllowGlobalUpdate: true})
+	if ids != nil {
+		query = query.Where("id IN (?)", ids)
+	}
+
+	result := query.Delete(&models.MediaScanError{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("delete media scan errors: %w", result.Error)
+	}
+
+	return int(result.RowsAffected), nil
+}
+
+// RetryMediaScanErrors is the resolver for the retryMediaScanErrors field.
+func (r *mutationResolver) RetryMediaScanErrors(ctx context.Context, ids []int) ([]*models.MediaScanError, error) {
+	db := r.DB(ctx)
+
+	query := db.Preload("Media")
+	if ids != nil {
+		query = query.Where("id IN (?)", ids)
+	}
+
+	var scanErrors []*models.MediaScanError
+	if err := query.Find(&scanErrors).Error; err != nil {
+		return nil, fmt.Errorf("get media scan errors from database: %w", err)
+	}
+
+	// Errors of media that have been deleted can't be retried, so they are removed instead
+	retryErrors := make([]*models.MediaScanError, 0, len(scanErrors))
+	orphanIDs := make([]int, 0)
+	for _, scanError := range scanErrors {
+		if scanError.Media == nil {
+			orphanIDs = append(orphanIDs, scanError.ID)
+			continue
+		}
+		retryErrors = append(retryErrors, scanError)
+	}
+	scanErrors = retryErrors
+
+	if len(orphanIDs) > 0 {
+		if err := db.Where("id IN (?)", orphanIDs).Delete(&models.MediaScanError{}).Error; err != nil {
+			return nil, fmt.Errorf("delete media scan errors of deleted media: %w", err)
+		}
+	}
+
+	if len(scanErrors) == 0 {
+		return scanErrors, nil
+	}
+
+	now := time.Now()
+	albumIDs := make([]int, 0)
+	scanErrorIDs := make([]int, len(scanErrors))
+	for i, scanError := range scanErrors {
+		scanError.NextAttempt = now
+		scanErrorIDs[i] = scanError.ID
+		albumIDs = append(albumIDs, scanError.Media.AlbumID)
+	}
+
+	if err := db.Model(&models.MediaScanError{}).Where("id IN (?)", scanErrorIDs).Update("next_attempt", now).Error; err != nil {
+		return nil, fmt.Errorf("reset backoff of media scan errors: %w", err)
+	}
+
+	var albums []*models.Album
+	if err := db.Where("id IN (?)", albumIDs).Find(&albums).Error; err != nil {
+		return nil, fmt.Errorf("get albums of media scan errors: %w", err)
+	}
+
+	for _, album := range albums {
+		if err := scanner_queue.AddAlbumToQueue(album, models.ScannerJobPriorityHigh); err != nil {
+			return nil, fmt.Errorf("add album to scanner queue (album_id: %d): %w", album.ID, err)
+		}
+	}
+
+	return scanErrors, nil
+}
+
+// CancelScannerJob is the resolver for the cancelScannerJob field.
+func (r *mutationResolver) CancelScannerJob(ctx context.Context, id int) (*models.ScannerJob, error) {
+	db := r.DB(ctx)
+
+	if err := scanner_queue.CancelJob(id); err != nil {
+		return nil, err
+	}
+
+	var job models.ScannerJob
+	if err := db.Preload("Album").First(&job, id).Error; err != nil {
+		return nil, fmt.Errorf("get scanner job from database: %w", err)
+	}
+
+	// A running job may still be stopping, and mark itself as running until it notices the cancellation
+	job.Status = models.ScannerJobStatusCancelled
+
+	return &job, nil
+}
+
+// CancelAllScannerJobs is the resolver for the cancelAllScannerJobs field.
+func (r *mutationResolver) CancelAllScannerJobs(ctx context.Context) (int, error) {
+	return scanner_queue.CancelAllJobs(), nil
+}
+
+// ScannerJobs is the resolver for the scannerJobs field.
+func (r *queryResolver) ScannerJobs(ctx context.Context, status *models.ScannerJobStatus, paginate *models.Pagination) ([]*models.ScannerJob, error) {
+	query := r.DB(ctx).Preload("Album").Order("created_at DESC").Order("id DESC")
+	if status != nil {
+		query = query.Where("status = ?", *status)
+	}
+
+	var jobs []*models.ScannerJob
+	if err := models.FormatSQL(query, nil, paginate).Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("get scanner jobs from database: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// MediaScanErrors is the resolver for the mediaScanErrors field.
+func (r *queryResolver) MediaScanErrors(ctx context.Context, task *models.MediaScanTask, paginate *models.Pagination) ([]*models.MediaScanError, error) {
+	query := r.DB(ctx).Preload("Media").Order("last_attempt DESC").Order("id DESC")
+	if task != nil {
+		query = query.Where("task = ?", *task)
+	}
+
+	var scanErrors []*models.MediaScanError
+	if err := models.FormatSQL(query, nil, paginate).Find(&scanErrors).Error; err != nil {
+		return nil, fmt.Errorf("get media scan errors from database: %w", err)
+	}
+
+	return scanErrors, nil
+}
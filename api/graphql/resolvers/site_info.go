@@ -23,6 +23,15 @@ func (r *siteInfoResolver) FaceDetectionEnabled(ctx context.Context, obj *models
 	return face_detection.GlobalFaceDetector != nil, nil
 }
 
+// FilenameDatePatterns is the resolver for the filenameDatePatterns field.
+func (r *siteInfoResolver) FilenameDatePatterns(ctx context.Context, obj *models.SiteInfo) ([]string, error) {
+	if obj.FilenameDatePatterns == nil {
+		return []string{}, nil
+	}
+
+	return obj.FilenameDatePatterns, nil
+}
+
 // SiteInfo returns api.SiteInfoResolver implementation.
 func (r *Resolver) SiteInfo() api.SiteInfoResolver { return &siteInfoResolver{r} }
 
@@ -0,0 +1,160 @@
+package resolvers
+
+// This file will be automatically regenerated based on the schema, any resolver
+// implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.91
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	api "github.com/photoview/photoview/api/graphql"
+	"github.com/photoview/photoview/api/graphql/auth"
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/graphql/models/actions"
+)
+
+// CreateSmartAlbum is the resolver for the createSmartAlbum field.
+func (r *mutationResolver) CreateSmartAlbum(ctx context.Context, title string, filter models.SmartAlbumFilter) (*models.SmartAlbum, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.CreateSmartAlbum(r.DB(ctx), user, title, filter)
+}
+
+// UpdateSmartAlbum is the resolver for the updateSmartAlbum field.
+func (r *mutationResolver) UpdateSmartAlbum(ctx context.Context, id int, title *string, filter *models.SmartAlbumFilter) (*models.SmartAlbum, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.UpdateSmartAlbum(r.DB(ctx), user, id, title, filter)
+}
+
+// DeleteSmartAlbum is the resolver for the deleteSmartAlbum field.
+func (r *mutationResolver) DeleteSmartAlbum(ctx context.Context, id int) (*models.SmartAlbum, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.DeleteSmartAlbum(r.DB(ctx), user, id)
+}
+
+// ShareSmartAlbum is the resolver for the shareSmartAlbum field.
+func (r *mutationResolver) ShareSmartAlbum(ctx context.Context, smartAlbumID int, expire *time.Time, password *string) (*models.ShareToken, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.AddSmartAlbumShare(r.DB(ctx), user, smartAlbumID, expire, password)
+}
+
+// MySmartAlbums is the resolver for the mySmartAlbums field.
+func (r *queryResolver) MySmartAlbums(ctx context.Context, order *models.Ordering, paginate *models.Pagination) ([]*models.SmartAlbum, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.MySmartAlbums(r.DB(ctx), user, order, paginate)
+}
+
+// SmartAlbum is the resolver for the smartAlbum field.
+func (r *queryResolver) SmartAlbum(ctx context.Context, id int, tokenCredentials *models.ShareTokenCredentials) (*models.SmartAlbum, error) {
+	if tokenCredentials != nil {
+		shareToken, err := r.ShareToken(ctx, *tokenCredentials)
+		if err != nil {
+			return nil, err
+		}
+
+		if shareToken.SmartAlbumID != nil && *shareToken.SmartAlbumID == id {
+			return actions.SharedSmartAlbum(shareToken, id)
+		}
+	}
+
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.SmartAlbum(r.DB(ctx), user, id)
+}
+
+// Owner is the resolver for the owner field.
+func (r *smartAlbumResolver) Owner(ctx context.Context, obj *models.SmartAlbum) (*models.User, error) {
+	var owner models.User
+	if err := r.DB(ctx).First(&owner, obj.OwnerID).Error; err != nil {
+		return nil, fmt.Errorf("get owner of smart album: %w", err)
+	}
+
+	return &owner, nil
+}
+
+// Media is the resolver for the media field.
+func (r *smartAlbumResolver) Media(ctx context.Context, obj *models.SmartAlbum, order *models.Ordering, paginate *models.Pagination) ([]*models.Media, error) {
+	return actions.SmartAlbumMedia(r.DB(ctx), obj, order, paginate)
+}
+
+// MediaCount is the resolver for the mediaCount field.
+func (r *smartAlbumResolver) MediaCount(ctx context.Context, obj *models.SmartAlbum) (int, error) {
+	return actions.SmartAlbumMediaCount(r.DB(ctx), obj)
+}
+
+// Thumbnail is the resolver for the thumbnail field.
+func (r *smartAlbumResolver) Thumbnail(ctx context.Context, obj *models.SmartAlbum) (*models.Media, error) {
+	limit := 1
+	media, err := actions.SmartAlbumMedia(r.DB(ctx), obj, nil, &models.Pagination{Limit: &limit})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(media) == 0 {
+		return nil, nil
+	}
+
+	return media[0], nil
+}
+
+// Shares is the resolver for the shares field.
+func (r *smartAlbumResolver) Shares(ctx context.Context, obj *models.SmartAlbum) ([]*models.ShareToken, error) {
+	// Only the owner can see the share tokens of the smart album, not users of a share token
+	user := auth.UserFromContext(ctx)
+	if user == nil || user.ID != obj.OwnerID {
+		return []*models.ShareToken{}, nil
+	}
+
+	var shareTokens []*models.ShareToken
+	if err := r.DB(ctx).Where("smart_album_id = ?", obj.ID).Find(&shareTokens).Error; err != nil {
+		return nil, err
+	}
+
+	return shareTokens, nil
+}
+
+// MediaType is the resolver for the mediaType field.
+func (r *smartAlbumFilterResolver) MediaType(ctx context.Context, obj *models.SmartAlbumFilter) (*models.MediaType, error) {
+	if obj.MediaType == nil {
+		return nil, nil
+	}
+
+	formattedType := obj.MediaType.Formatted()
+	return &formattedType, nil
+}
+
+// SmartAlbum returns api.SmartAlbumResolver implementation.
+func (r *Resolver) SmartAlbum() api.SmartAlbumResolver { return &smartAlbumResolver{r} }
+
+// SmartAlbumFilter returns api.SmartAlbumFilterResolver implementation.
+func (r *Resolver) SmartAlbumFilter() api.SmartAlbumFilterResolver {
+	return &smartAlbumFilterResolver{r}
+}
+
+type smartAlbumResolver struct{ *Resolver }
+type smartAlbumFilterResolver struct{ *Resolver }
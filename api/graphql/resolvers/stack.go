@@ -0,0 +1,71 @@
+package resolvers
+
+// This file will be automatically regenerated based on the schema, any resolver
+// implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.91
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/photoview/photoview/api/dataloader"
+	api "github.com/photoview/photoview/api/graphql"
+	"github.com/photoview/photoview/api/graphql/auth"
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/graphql/models/actions"
+)
+
+// Stack is the resolver for the stack field.
+func (r *mediaResolver) Stack(ctx context.Context, obj *models.Media) (*models.MediaStack, error) {
+	if obj.StackID == nil {
+		return nil, nil
+	}
+
+	stack, err := dataloader.For(ctx).MediaStack.Load(*obj.StackID)
+	if err != nil {
+		return nil, fmt.Errorf("get stack of media (%s): %w", obj.Path, err)
+	}
+
+	return stack, nil
+}
+
+// Cover is the resolver for the cover field.
+func (r *mediaStackResolver) Cover(ctx context.Context, obj *models.MediaStack) (*models.Media, error) {
+	var cover models.Media
+	if err := r.DB(ctx).First(&cover, obj.CoverID).Error; err != nil {
+		return nil, fmt.Errorf("get cover of stack (%d): %w", obj.ID, err)
+	}
+
+	return &cover, nil
+}
+
+// Media is the resolver for the media field.
+func (r *mediaStackResolver) Media(ctx context.Context, obj *models.MediaStack) ([]*models.Media, error) {
+	return obj.GetMedia(r.DB(ctx))
+}
+
+// SetMediaStackCover is the resolver for the setMediaStackCover field.
+func (r *mutationResolver) SetMediaStackCover(ctx context.Context, mediaID int) (*models.MediaStack, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.SetMediaStackCover(r.DB(ctx), user, mediaID)
+}
+
+// SplitMediaStack is the resolver for the splitMediaStack field.
+func (r *mutationResolver) SplitMediaStack(ctx context.Context, mediaIds []int) (*models.MediaStack, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.SplitMediaStack(r.DB(ctx), user, mediaIds)
+}
+
+// MediaStack returns api.MediaStackResolver implementation.
+func (r *Resolver) MediaStack() api.MediaStackResolver { return &mediaStackResolver{r} }
+
+type mediaStackResolver struct{ *Resolver }
@@ -0,0 +1,120 @@
+package resolvers
+
+// This file will be automatically regenerated based on the schema, any resolver
+// implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.91
+
+import (
+	"context"
+
+	"github.com/photoview/photoview/api/dataloader"
+	api "github.com/photoview/photoview/api/graphql"
+	"github.com/photoview/photoview/api/graphql/auth"
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/graphql/models/actions"
+)
+
+// Tags is the resolver for the tags field.
+func (r *mediaResolver) Tags(ctx context.Context, obj *models.Media) ([]*models.Tag, error) {
+	tags, err := dataloader.For(ctx).MediaTags.Load(obj.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the keywords are visible for media shared by a share token
+	user := auth.UserFromContext(ctx)
+	visible := make([]*models.Tag, 0, len(tags))
+	for _, tag := range tags {
+		if tag.VisibleTo(user) {
+			visible = append(visible, tag)
+		}
+	}
+
+	return visible, nil
+}
+
+// AddMediaTags is the resolver for the addMediaTags field.
+func (r *mutationResolver) AddMediaTags(ctx context.Context, mediaIds []int, names []string) ([]*models.Tag, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.AddMediaTags(r.DB(ctx), user, mediaIds, names)
+}
+
+// RemoveMediaTags is the resolver for the removeMediaTags field.
+func (r *mutationResolver) RemoveMediaTags(ctx context.Context, mediaIds []int, tagIds []int) ([]*models.Tag, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.RemoveMediaTags(r.DB(ctx), user, mediaIds, tagIds)
+}
+
+// RenameTag is the resolver for the renameTag field.
+func (r *mutationResolver) RenameTag(ctx context.Context, tagID int, name string) (*models.Tag, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.RenameTag(r.DB(ctx), user, tagID, name)
+}
+
+// DeleteTag is the resolver for the deleteTag field.
+func (r *mutationResolver) DeleteTag(ctx context.Context, tagID int) (*models.Tag, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.DeleteTag(r.DB(ctx), user, tagID)
+}
+
+// MyTags is the resolver for the myTags field.
+func (r *queryResolver) MyTags(ctx context.Context, paginate *models.Pagination) ([]*models.Tag, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.MyTags(r.DB(ctx), user, paginate)
+}
+
+// Tag is the resolver for the tag field.
+func (r *queryResolver) Tag(ctx context.Context, id int) (*models.Tag, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.UserTag(r.DB(ctx), user, id)
+}
+
+// Media is the resolver for the media field.
+func (r *tagResolver) Media(ctx context.Context, obj *models.Tag, order *models.Ordering, paginate *models.Pagination) ([]*models.Media, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	return actions.TagMedia(r.DB(ctx), user, obj, order, paginate)
+}
+
+// MediaCount is the resolver for the mediaCount field.
+func (r *tagResolver) MediaCount(ctx context.Context, obj *models.Tag) (int, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return -1, auth.ErrUnauthorized
+	}
+
+	return actions.TagMediaCount(r.DB(ctx), user, obj)
+}
+
+// Tag returns api.TagResolver implementation.
+func (r *Resolver) Tag() api.TagResolver { return &tagResolver{r} }
+
+type tagResolver struct{ *Resolver }
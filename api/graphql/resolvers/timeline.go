@@ -15,11 +15,53 @@ import (
 )
 
 // MyTimeline is the resolver for the myTimeline field.
-func (r *queryResolver) MyTimeline(ctx context.Context, paginate *models.Pagination, onlyFavorites *bool, fromDate *time.Time) ([]*models.Media, error) {
+func (r *queryResolver) MyTimeline(ctx context.Context, paginate *models.Pagination, onlyFavorites *bool, minRating *int, colorLabel *models.ColorLabel, fromDate *time.Time, mediaType *models.MediaType, albumID *int) ([]*models.Media, error) {
 	user := auth.UserFromContext(ctx)
 	if user == nil {
 		return nil, auth.ErrUnauthorized
 	}
 
-	return actions.MyTimeline(r.DB(ctx), user, paginate, onlyFavorites, fromDate)
+	filter := actions.TimelineFilter{
+		OnlyFavorites: onlyFavorites,
+		MinRating:     minRating,
+		ColorLabel:    colorLabel,
+		MediaType:     mediaType,
+		AlbumID:       albumID,
+	}
+	return actions.MyTimeline(r.DB(ctx), user, paginate, filter, fromDate)
+}
+
+// MyTimelineConnection is the resolver for the myTimelineConnection field.
+func (r *queryResolver) MyTimelineConnection(ctx context.Context, first *int, after *string, last *int, before *string, onlyFavorites *bool, minRating *int, colorLabel *models.ColorLabel, mediaType *models.MediaType, albumID *int) (*models.MediaConnection, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	args := models.ConnectionArgs{First: first, After: after, Last: last, Before: before}
+	filter := actions.TimelineFilter{
+		OnlyFavorites: onlyFavorites,
+		MinRating:     minRating,
+		ColorLabel:    colorLabel,
+		MediaType:     mediaType,
+		AlbumID:       albumID,
+	}
+	return actions.MyTimelineConnection(r.DB(ctx), user, args, filter)
+}
+
+// MyTimelineBuckets is the resolver for the myTimelineBuckets field.
+func (r *queryResolver) MyTimelineBuckets(ctx context.Context, granularity models.TimelineGranularity, onlyFavorites *bool, minRating *int, colorLabel *models.ColorLabel, mediaType *models.MediaType, albumID *int) ([]*models.TimelineBucket, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	filter := actions.TimelineFilter{
+		OnlyFavorites: onlyFavorites,
+		MinRating:     minRating,
+		ColorLabel:    colorLabel,
+		MediaType:     mediaType,
+		AlbumID:       albumID,
+	}
+	return actions.MyTimelineBuckets(r.DB(ctx), user, granularity, filter)
 }
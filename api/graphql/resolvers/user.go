@@ -16,6 +16,7 @@ import (
 	"github.com/photoview/photoview/api/graphql/models"
 	"github.com/photoview/photoview/api/graphql/models/actions"
 	"github.com/photoview/photoview/api/scanner"
+	"github.com/photoview/photoview/api/scanner/filesystem_watcher"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
@@ -186,6 +187,8 @@ func (r *mutationResolver) UserAddRootPath(ctx context.Context, id int, rootPath
 		return nil, err
 	}
 
+	filesystem_watcher.RefreshFilesystemWatcher()
+
 	return newAlbum, nil
 }
 
@@ -240,6 +243,8 @@ func (r *mutationResolver) UserRemoveRootAlbum(ctx context.Context, userID int,
 		return nil, err
 	}
 
+	filesystem_watcher.RefreshFilesystemWatcher()
+
 	return &album, nil
 }
 
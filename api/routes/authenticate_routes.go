@@ -7,6 +7,7 @@ import (
 
 	"github.com/photoview/photoview/api/graphql/auth"
 	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/graphql/models/actions"
 
 	// "github.com/photoview/photoview/api/log"
 	"github.com/pkg/errors"
@@ -151,5 +152,26 @@ func shareTokenFromRequest(db *gorm.DB, r *http.Request, mediaID *int, albumID *
 		return false, "unauthorized", http.StatusForbidden, errors.New("invalid share token")
 	}
 
+	if shareToken.SmartAlbumID != nil {
+		// Smart album share tokens only give access to the media currently matching the filter of the smart album
+		if mediaID == nil {
+			return false, "unauthorized", http.StatusForbidden, errors.New("invalid share token")
+		}
+
+		var smartAlbum models.SmartAlbum
+		if err := db.First(&smartAlbum, *shareToken.SmartAlbumID).Error; err != nil {
+			return false, internalServerError, http.StatusInternalServerError, err
+		}
+
+		contains, err := actions.SmartAlbumContainsMedia(db, &smartAlbum, *mediaID)
+		if err != nil {
+			return false, internalServerError, http.StatusInternalServerError, err
+		}
+
+		if !contains {
+			return false, "unauthorized", http.StatusForbidden, errors.New("invalid share token")
+		}
+	}
+
 	return true, "", 0, nil
 }
@@ -3,6 +3,8 @@ package routes
 import (
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"gorm.io/gorm"
@@ -10,6 +12,7 @@ import (
 	"github.com/photoview/photoview/api/graphql/models"
 	"github.com/photoview/photoview/api/log"
 	"github.com/photoview/photoview/api/scanner"
+	"github.com/photoview/photoview/api/scanner/media_encoding"
 )
 
 func RegisterPhotoRoutes(db *gorm.DB, router *mux.Router) {
@@ -70,12 +73,87 @@ func RegisterPhotoRoutes(db *gorm.DB, router *mux.Router) {
 			}
 		}
 
+		contentType := mediaURL.ContentType
+		if mediaURL.Purpose != models.MediaOriginal && contentType == "image/jpeg" {
+			cachedPath, contentType = negotiateImageFormat(r, cachedPath, contentType)
+			w.Header().Add("Vary", "Accept")
+		}
+
 		// Allow caching the resource
 		w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
-		if mediaURL.ContentType != "" {
-			w.Header().Set("Content-Type", mediaURL.ContentType)
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
 		}
 
 		http.ServeFile(w, r, cachedPath)
 	})
 }
+
+// negotiateImageFormat returns the path and content type of the image format with the highest quality value
+// in the Accept header, among the formats that were encoded next to the generated JPEG, or the JPEG itself.
+// Alternative formats must be listed explicitly, and are preferred over the JPEG when their quality is the same.
+func negotiateImageFormat(r *http.Request, jpegPath string, jpegContentType string) (string, string) {
+	qualities := acceptedQualities(r.Header.Get("Accept"))
+	if len(qualities) == 0 {
+		return jpegPath, jpegContentType
+	}
+
+	jpegQuality := 0.0
+	for _, mediaRange := range []string{jpegContentType, "image/*", "*/*"} {
+		if quality, found := qualities[mediaRange]; found {
+			jpegQuality = quality
+			break
+		}
+	}
+
+	bestPath, bestContentType, bestQuality := "", "", 0.0
+	for _, format := range media_encoding.SupportedImageFormats {
+		// Formats earlier in the list are preferred, when their quality is the same
+		quality := qualities[format.ContentType]
+		if quality <= bestQuality {
+			continue
+		}
+
+		formatPath := media_encoding.AlternativeImagePath(jpegPath, format)
+		if stat, err := os.Stat(formatPath); err != nil || stat.IsDir() {
+			continue
+		}
+
+		bestPath, bestContentType, bestQuality = formatPath, format.ContentType, quality
+	}
+
+	if bestPath == "" || bestQuality < jpegQuality {
+		return jpegPath, jpegContentType
+	}
+
+	return bestPath, bestContentType
+}
+
+// acceptedQualities returns the quality values of the media ranges of an Accept header, 1 when it is not given
+func acceptedQualities(accept string) map[string]float64 {
+	qualities := make(map[string]float64)
+
+	for _, part := range strings.Split(strings.ToLower(accept), ",") {
+		params := strings.Split(part, ";")
+		mediaRange := strings.TrimSpace(params[0])
+		if mediaRange == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range params[1:] {
+			name, value, found := strings.Cut(param, "=")
+			if !found || strings.TrimSpace(name) != "q" {
+				continue
+			}
+
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				quality = parsed
+			}
+		}
+
+		qualities[mediaRange] = quality
+	}
+
+	return qualities
+}
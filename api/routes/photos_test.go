@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path"
+	"strings"
 	"testing"
 	"time"
 
@@ -121,4 +122,71 @@ func TestPhotoRoutes(t *testing.T) {
 		assert.Equal(t, "private, max-age=31536000, immutable", rec.Header().Get("Cache-Control"))
 		assert.Equal(t, "image/jpeg", rec.Header().Get("Content-Type"))
 	})
+
+	t.Run("serves modern image formats accepted by the client", func(t *testing.T) {
+		cachedPath, err := mediaURL.CachedPath()
+		assert.NoError(t, err)
+		assert.NoError(t, os.MkdirAll(path.Dir(cachedPath), 0755))
+		assert.NoError(t, os.WriteFile(cachedPath, []byte("jpeg-binary"), 0644))
+		assert.NoError(t, os.WriteFile(strings.TrimSuffix(cachedPath, ".jpg")+".webp", []byte("webp-binary"), 0644))
+
+		tests := []struct {
+			accept      string
+			body        string
+			contentType string
+		}{
+			{"image/avif,image/webp,*/*", "webp-binary", "image/webp"},
+			{"image/webp;q=0.8,image/png", "webp-binary", "image/webp"},
+			{"image/webp;q=0,image/jpeg", "jpeg-binary", "image/jpeg"},
+			{"image/avif,image/jpeg", "jpeg-binary", "image/jpeg"},
+			{"", "jpeg-binary", "image/jpeg"},
+		}
+
+		for _, test := range tests {
+			req := httptest.NewRequest("GET", "/test_image.jpg", nil)
+			req.Header.Set("Accept", test.accept)
+			req = req.WithContext(auth.AddUserToContext(req.Context(), user))
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equalf(t, test.body, rec.Body.String(), "Accept: %s", test.accept)
+			assert.Equalf(t, test.contentType, rec.Header().Get("Content-Type"), "Accept: %s", test.accept)
+			assert.Equal(t, "Accept", rec.Header().Get("Vary"))
+		}
+	})
+
+	t.Run("honours the quality values of the accepted formats", func(t *testing.T) {
+		cachedPath, err := mediaURL.CachedPath()
+		assert.NoError(t, err)
+		assert.NoError(t, os.MkdirAll(path.Dir(cachedPath), 0755))
+		assert.NoError(t, os.WriteFile(cachedPath, []byte("jpeg-binary"), 0644))
+		assert.NoError(t, os.WriteFile(strings.TrimSuffix(cachedPath, ".jpg")+".webp", []byte("webp-binary"), 0644))
+		assert.NoError(t, os.WriteFile(strings.TrimSuffix(cachedPath, ".jpg")+".avif", []byte("avif-binary"), 0644))
+
+		tests := []struct {
+			accept      string
+			body        string
+			contentType string
+		}{
+			{"image/avif,image/webp,*/*", "avif-binary", "image/avif"},
+			{"image/avif;q=0.1, image/webp", "webp-binary", "image/webp"},
+			{"image/avif;q=0.9, image/webp;q=0.5", "avif-binary", "image/avif"},
+			{"image/jpeg, image/webp;q=0.5", "jpeg-binary", "image/jpeg"},
+			{"image/webp;q=0.5, */*;q=0.8", "jpeg-binary", "image/jpeg"},
+			{"*/*", "jpeg-binary", "image/jpeg"},
+		}
+
+		for _, test := range tests {
+			req := httptest.NewRequest("GET", "/test_image.jpg", nil)
+			req.Header.Set("Accept", test.accept)
+			req = req.WithContext(auth.AddUserToContext(req.Context(), user))
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equalf(t, test.body, rec.Body.String(), "Accept: %s", test.accept)
+			assert.Equalf(t, test.contentType, rec.Header().Get("Content-Type"), "Accept: %s", test.accept)
+		}
+	})
 }
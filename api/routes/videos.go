@@ -3,14 +3,18 @@ package routes
 import (
 	"context"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
+	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/photoview/photoview/api/graphql/models"
 	"github.com/photoview/photoview/api/log"
 	"github.com/photoview/photoview/api/scanner"
+	"github.com/photoview/photoview/api/scanner/scanner_queue"
 	"github.com/photoview/photoview/api/utils"
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
@@ -20,6 +24,19 @@ var processSingleMediaFn = func(ctx context.Context, db *gorm.DB, media *models.
 	return scanner.ProcessSingleMedia(ctx, db, media)
 }
 
+// queueMediaAlbumFn adds the album of the media to the scanner queue, which encodes the missing files of its media
+var queueMediaAlbumFn = func(db *gorm.DB, media *models.Media) error {
+	var album models.Album
+	if err := db.First(&album, media.AlbumID).Error; err != nil {
+		return errors.Wrapf(err, "get album of media (media_id: %d)", media.ID)
+	}
+
+	return scanner_queue.AddAlbumToQueue(&album, models.ScannerJobPriorityHigh)
+}
+
+// hlsRetryAfter is the number of seconds clients are asked to wait for a HLS stream that is being encoded
+const hlsRetryAfter = "30"
+
 func handleVideoRequest(
 	w http.ResponseWriter,
 	r *http.Request,
@@ -31,7 +48,7 @@ func handleVideoRequest(
 	var mediaURLs []models.MediaURL
 	if err := db.Model(&models.MediaURL{}).
 		Preload("Media").
-		Where("media_urls.media_name = ? AND media_urls.purpose = ?", mediaName, models.VideoWeb).
+		Where("media_urls.media_name = ? AND media_urls.purpose IN ?", mediaName, []models.MediaPurpose{models.VideoWeb, models.VideoPreview, models.MotionVideo}).
 		Order("created_at DESC").
 		Find(&mediaURLs).
 		Error; err != nil || len(mediaURLs) == 0 || mediaURLs[0].Media == nil {
@@ -66,7 +83,7 @@ func handleVideoRequest(
 
 	var cachedPath string
 
-	if mediaURL.Purpose == models.VideoWeb {
+	if mediaURL.Purpose == models.VideoWeb || mediaURL.Purpose == models.VideoPreview || mediaURL.Purpose == models.MotionVideo {
 		// Use the provided cache path function
 		cachedPath = getCachePathFn(int(media.AlbumID), int(mediaURL.MediaID), mediaURL.MediaName)
 	} else {
@@ -124,6 +141,123 @@ func handleVideoRequest(
 	http.ServeFile(w, r, cachedPath)
 }
 
+// hlsFileRegex matches the names of the playlists and segments inside the HLS directory of a video
+var hlsFileRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+\.(m3u8|ts)$`)
+
+func handleHLSRequest(
+	w http.ResponseWriter,
+	r *http.Request,
+	db *gorm.DB,
+	mediaName string,
+	fileName string,
+	authenticateFn func(*models.Media, *gorm.DB, *http.Request) (bool, string, int, error),
+	getCachePathFn func(albumID, mediaID int, filename string) string,
+) {
+	if !hlsFileRegex.MatchString(fileName) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not found"))
+		return
+	}
+
+	var mediaURLs []models.MediaURL
+	if err := db.Model(&models.MediaURL{}).
+		Preload("Media").
+		Where("media_urls.media_name = ? AND media_urls.purpose = ?", mediaName, models.VideoHLS).
+		Order("created_at DESC").
+		Find(&mediaURLs).
+		Error; err != nil || len(mediaURLs) == 0 || mediaURLs[0].Media == nil {
+
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not found"))
+		return
+	}
+
+	mediaURL := mediaURLs[0]
+	var media = mediaURL.Media
+
+	if success, response, status, err := authenticateFn(media, db, r); !success {
+		if err != nil {
+			log.Warn(r.Context(), "got error authenticating HLS stream",
+				"error", err,
+				"media ID", media.ID,
+				"media path", media.Path)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(response))
+		return
+	}
+
+	hlsPath := getCachePathFn(int(media.AlbumID), int(mediaURL.MediaID), mediaURL.MediaName)
+	masterPath := path.Join(hlsPath, models.HLSMasterPlaylist)
+
+	// Encoding a stream takes too long to wait for in the request, so the scanner encodes it in the background
+	if _, err := os.Stat(masterPath); os.IsNotExist(err) {
+		if err := queueMediaAlbumFn(db, media); err != nil {
+			log.Error(r.Context(), "queueing HLS stream not found in cache",
+				"error", err,
+				"media ID", media.ID,
+				"media path", media.Path)
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(internalServerError))
+			return
+		}
+
+		w.Header().Set("Retry-After", hlsRetryAfter)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("stream is being encoded"))
+		return
+	}
+
+	filePath := path.Join(hlsPath, fileName)
+	if _, err := os.Stat(filePath); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not found"))
+		return
+	}
+
+	// Allow caching the resource
+	w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+
+	if path.Ext(fileName) != ".m3u8" {
+		w.Header().Set("Content-Type", "video/mp2t")
+		http.ServeFile(w, r, filePath)
+		return
+	}
+
+	playlist, err := os.ReadFile(filePath)
+	if err != nil {
+		log.Error(r.Context(), "reading HLS playlist",
+			"error", err,
+			"media ID", media.ID,
+			"playlist", filePath)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(internalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaURL.ContentType)
+	w.Write(addTokenToPlaylist(playlist, r.URL.Query().Get("token")))
+}
+
+// addTokenToPlaylist adds the share token to the URIs in a HLS playlist,
+// so players requesting the variants and segments are authorized the same way as for the playlist
+func addTokenToPlaylist(playlist []byte, token string) []byte {
+	if token == "" {
+		return playlist
+	}
+
+	lines := strings.Split(string(playlist), "\n")
+	for i, line := range lines {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		lines[i] = line + "?token=" + url.QueryEscape(token)
+	}
+
+	return []byte(strings.Join(lines, "\n"))
+}
+
 func generateCacheFilename(albumID, mediaID int, filename string) string {
 	return path.Join(utils.MediaCachePath(), strconv.Itoa(albumID), strconv.Itoa(mediaID), filename)
 }
@@ -134,4 +268,9 @@ func RegisterVideoRoutes(db *gorm.DB, router *mux.Router) {
 		mediaName := mux.Vars(r)["name"]
 		handleVideoRequest(w, r, db, mediaName, authenticateMedia, generateCacheFilename)
 	})
+
+	router.HandleFunc("/hls/{name}/{file}", func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		handleHLSRequest(w, r, db, vars["name"], vars["file"], authenticateMedia, generateCacheFilename)
+	})
 }
@@ -469,3 +469,166 @@ func TestVideoRoutes(t *testing.T) {
 		})
 	}
 }
+
+func TestVideoPreviewRoute(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	_, album, media, _, _, cachePath, _, _ := createTestResources(t, db, "preview")
+
+	restorePath := setTestCachePath(cachePath)
+	t.Cleanup(restorePath)
+
+	previewURL := &models.MediaURL{
+		MediaID:     media.ID,
+		MediaName:   "video_preview_hover.mp4",
+		Width:       320,
+		Height:      180,
+		Purpose:     models.VideoPreview,
+		ContentType: "video/mp4",
+		FileSize:    1024,
+	}
+	require.NoError(t, db.Create(previewURL).Error)
+
+	mediaDir := filepath.Join(cachePath, strconv.Itoa(album.ID), strconv.Itoa(media.ID))
+	require.NoError(t, os.MkdirAll(mediaDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(mediaDir, previewURL.MediaName), []byte("preview content"), 0644))
+
+	router := mux.NewRouter()
+	registerMockVideoRoutesForTesting(db, router, cachePath)
+
+	req := httptest.NewRequest("GET", "/"+previewURL.MediaName, nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "preview content", rr.Body.String())
+	assert.Equal(t, "video/mp4", rr.Header().Get("Content-Type"))
+}
+
+func TestMotionVideoRoute(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	_, album, media, _, _, cachePath, _, _ := createTestResources(t, db, "motion")
+
+	restorePath := setTestCachePath(cachePath)
+	t.Cleanup(restorePath)
+
+	motionURL := &models.MediaURL{
+		MediaID:     media.ID,
+		MediaName:   "motion_video_live.mp4",
+		Width:       1080,
+		Height:      1440,
+		Purpose:     models.MotionVideo,
+		ContentType: "video/mp4",
+		FileSize:    1024,
+	}
+	require.NoError(t, db.Create(motionURL).Error)
+
+	mediaDir := filepath.Join(cachePath, strconv.Itoa(album.ID), strconv.Itoa(media.ID))
+	require.NoError(t, os.MkdirAll(mediaDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(mediaDir, motionURL.MediaName), []byte("motion content"), 0644))
+
+	router := mux.NewRouter()
+	registerMockVideoRoutesForTesting(db, router, cachePath)
+
+	req := httptest.NewRequest("GET", "/"+motionURL.MediaName, nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "motion content", rr.Body.String())
+	assert.Equal(t, "video/mp4", rr.Header().Get("Content-Type"))
+}
+
+func TestHLSRoutes(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	_, album, media, _, _, cachePath, tokenValue, tokenPassword := createTestResources(t, db, "hls")
+
+	restorePath := setTestCachePath(cachePath)
+	t.Cleanup(restorePath)
+
+	hlsURL := &models.MediaURL{
+		MediaID:     media.ID,
+		MediaName:   "hls_video_hls",
+		Width:       1280,
+		Height:      720,
+		Purpose:     models.VideoHLS,
+		ContentType: "application/vnd.apple.mpegurl",
+		FileSize:    1024,
+	}
+	require.NoError(t, db.Create(hlsURL).Error)
+
+	hlsDir := filepath.Join(cachePath, strconv.Itoa(album.ID), strconv.Itoa(media.ID), hlsURL.MediaName)
+	require.NoError(t, os.MkdirAll(hlsDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(hlsDir, models.HLSMasterPlaylist),
+		[]byte("#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=928000,RESOLUTION=640x360\n360p.m3u8\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(hlsDir, "360p_00000.ts"), []byte("segment content"), 0644))
+
+	router := mux.NewRouter()
+	RegisterVideoRoutes(db, router)
+
+	request := func(file string, token string) *httptest.ResponseRecorder {
+		target := "/hls/" + hlsURL.MediaName + "/" + file
+		if token != "" {
+			target += "?token=" + token
+		}
+
+		req := httptest.NewRequest("GET", target, nil)
+		req.AddCookie(&http.Cookie{
+			Name:  fmt.Sprintf("share-token-pw-%s", tokenValue),
+			Value: tokenPassword,
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("master playlist passes on the share token", func(t *testing.T) {
+		rr := request(models.HLSMasterPlaylist, tokenValue)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/vnd.apple.mpegurl", rr.Header().Get("Content-Type"))
+		assert.Contains(t, rr.Body.String(), "\n360p.m3u8?token="+tokenValue+"\n")
+		assert.Contains(t, rr.Body.String(), "#EXT-X-STREAM-INF:BANDWIDTH=928000,RESOLUTION=640x360\n")
+	})
+
+	t.Run("segment", func(t *testing.T) {
+		rr := request("360p_00000.ts", tokenValue)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "video/mp2t", rr.Header().Get("Content-Type"))
+		assert.Equal(t, "segment content", rr.Body.String())
+	})
+
+	t.Run("missing share token", func(t *testing.T) {
+		rr := request("360p_00000.ts", "")
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("invalid file name", func(t *testing.T) {
+		rr := request("segment.txt", tokenValue)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("missing segment", func(t *testing.T) {
+		rr := request("360p_00001.ts", tokenValue)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+	t.Run("missing stream is queued for encoding", func(t *testing.T) {
+		var queued []int
+		savedFn := queueMediaAlbumFn
+		queueMediaAlbumFn = func(db *gorm.DB, media *models.Media) error {
+			queued = append(queued, media.AlbumID)
+			return nil
+		}
+		t.Cleanup(func() { queueMediaAlbumFn = savedFn })
+
+		require.NoError(t, os.Rename(hlsDir, hlsDir+"_moved"))
+		t.Cleanup(func() { os.Rename(hlsDir+"_moved", hlsDir) })
+
+		rr := request(models.HLSMasterPlaylist, tokenValue)
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.Equal(t, hlsRetryAfter, rr.Header().Get("Retry-After"))
+		assert.Equal(t, []int{album.ID}, queued)
+	})
+}
@@ -2,6 +2,7 @@ package exif
 
 import (
 	"fmt"
+	"math"
 	"sync"
 
 	"github.com/photoview/photoview/api/graphql/models"
@@ -54,6 +55,8 @@ func Parse(filepath string) (*models.MediaEXIF, error) {
 		exiftool.PhotoMeta
 		exiftool.TimeAll
 		exiftool.GPS
+		exiftool.Sequence
+		exiftool.Keywords
 	}
 	if err := globalExifParser.QueryJSONTagsByNumber(filepath, &values); err != nil {
 		return nil, err
@@ -73,6 +76,9 @@ func Parse(filepath string) (*models.MediaEXIF, error) {
 		Aperture:        values.Aperture,
 		FocalLength:     values.FocalLength,
 		Description:     values.ImageDescription,
+		BurstID:         values.BurstUUID,
+		SequenceNumber:  values.SequenceNumber,
+		Keywords:        values.Keywords.All(),
 	}
 
 	dateShot := values.TimeAll.TimeInLocal()
@@ -93,6 +99,43 @@ func Parse(filepath string) (*models.MediaEXIF, error) {
 	return &ret, nil
 }
 
+// ParseXMP parses the curation metadata of the XMP sidecar at `filepath`
+func ParseXMP(filepath string) (*models.MediaXMP, error) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+
+	if globalExifParser == nil {
+		return nil, fmt.Errorf("no exif parser initialized")
+	}
+
+	var values exiftool.XMP
+	if err := globalExifParser.QueryJSONTagsByNumber(filepath, &values); err != nil {
+		return nil, err
+	}
+
+	ret := models.MediaXMP{
+		Label:                values.Label.First(),
+		Title:                values.Title.First(),
+		Description:          values.Description.First(),
+		Keywords:             []string(values.Subject),
+		HierarchicalKeywords: []string(values.HierarchicalSubject),
+	}
+
+	if ret.Keywords == nil {
+		ret.Keywords = []string{}
+	}
+	if ret.HierarchicalKeywords == nil {
+		ret.HierarchicalKeywords = []string{}
+	}
+
+	if values.Rating != nil && !math.IsNaN(*values.Rating) {
+		rating := min(max(int(math.Round(*values.Rating)), -1), 5)
+		ret.Rating = &rating
+	}
+
+	return &ret, nil
+}
+
 func MIMEType(filepath string) (string, error) {
 	globalMu.Lock()
 	defer globalMu.Unlock()
@@ -113,3 +156,29 @@ func MIMEType(filepath string) (string, error) {
 	return *mime.MIMEType, nil
 
 }
+
+// SaveMotionPhotoVideo extracts the video embedded in the Motion Photo at `filepath` to `outputPath`,
+// it returns false if the photo has no embedded video.
+func SaveMotionPhotoVideo(filepath string, outputPath string) (bool, error) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+
+	if globalExifParser == nil {
+		return false, fmt.Errorf("no exif parser initialized")
+	}
+
+	return globalExifParser.SaveMotionPhotoVideo(filepath, outputPath)
+}
+
+// WriteXMPSidecar writes the values of `update` to the XMP sidecar `sidecarPath` of the media at `filepath`,
+// the sidecar is created if it doesn't exist. The media file itself is never modified.
+func WriteXMPSidecar(filepath string, sidecarPath string, update exiftool.XMPUpdate) error {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+
+	if globalExifParser == nil {
+		return fmt.Errorf("no exif parser initialized")
+	}
+
+	return globalExifParser.WriteXMPSidecar(filepath, sidecarPath, update)
+}
@@ -2,6 +2,7 @@ package exif
 
 import (
 	"os"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
@@ -69,6 +70,49 @@ func TestMIMEType(t *testing.T) {
 	}
 }
 
+func TestParseXMP(t *testing.T) {
+	resetForTest()
+
+	cleanup, err := Initialize()
+	if err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+	defer cleanup()
+
+	filename := "./test_data/darktable.xmp"
+
+	xmp, err := ParseXMP(filename)
+	if err != nil {
+		t.Fatalf("ParseXMP(%q) returns an error: %v", filename, err)
+	}
+
+	if xmp.Rating == nil || *xmp.Rating != 4 {
+		t.Errorf("xmp.Rating = %v, want: 4", xmp.Rating)
+	}
+
+	for _, tc := range []struct {
+		name string
+		got  *string
+		want string
+	}{
+		{"Label", xmp.Label, "Green"},
+		{"Title", xmp.Title, "Sunset over the harbour"},
+		{"Description", xmp.Description, "Taken from the lighthouse"},
+	} {
+		if tc.got == nil || *tc.got != tc.want {
+			t.Errorf("xmp.%s = %v, want: %q", tc.name, tc.got, tc.want)
+		}
+	}
+
+	if got, want := xmp.Keywords, []string{"sunset", "harbour"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("xmp.Keywords = %q, want: %q", got, want)
+	}
+
+	if got, want := xmp.HierarchicalKeywords, []string{"Places|Europe|Lisbon"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("xmp.HierarchicalKeywords = %q, want: %q", got, want)
+	}
+}
+
 func fileModifyDateLiteralInUTC(t *testing.T, file string) time.Time {
 	fstat, err := os.Stat(file)
 	if err != nil {
@@ -7,7 +7,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"slices"
+	"strings"
 	"sync"
 )
 
@@ -204,6 +207,15 @@ func (e *Exiftool) rawSaveEmbedFile(outputPath string, args ...string) (hasEmbed
 }
 
 func (e *Exiftool) rawUpdateFile(args ...string) (err error) {
+	// A file that already has the written values is reported as unchanged
+	return e.rawWriteFile([]string{"1 image files updated", "0 image files updated 1 image files unchanged"}, args...)
+}
+
+func (e *Exiftool) rawCreateFile(args ...string) (err error) {
+	return e.rawWriteFile([]string{"1 image files created"}, args...)
+}
+
+func (e *Exiftool) rawWriteFile(expectedOutputs []string, args ...string) (err error) {
 	if err = e.rawSendCommand(args...); err != nil {
 		return
 	}
@@ -217,8 +229,9 @@ func (e *Exiftool) rawUpdateFile(args ...string) (err error) {
 		return
 	}
 
-	outStr := string(bytes.Trim(output, " \n\r\t"))
-	if outStr != "1 image files updated" {
+	// The counts are printed on separate lines, which are joined to compare them
+	outStr := strings.Join(strings.Fields(string(output)), " ")
+	if !slices.Contains(expectedOutputs, outStr) {
 		err = fmt.Errorf("invalid output: %s", outStr)
 		return
 	}
@@ -226,6 +239,33 @@ func (e *Exiftool) rawUpdateFile(args ...string) (err error) {
 	return
 }
 
+// WriteXMPSidecar writes the values of `update` to the XMP sidecar `sidecarPath` of the media file `src`.
+// A sidecar that doesn't exist yet is created with the metadata of `src`. The file `src` is never modified.
+func (e *Exiftool) WriteXMPSidecar(src string, sidecarPath string, update XMPUpdate) error {
+	assignments := update.Args()
+	if len(assignments) == 0 {
+		return nil
+	}
+
+	_, err := os.Stat(sidecarPath)
+	switch {
+	case err == nil:
+		args := append(append([]string{"-overwrite_original"}, assignments...), sidecarPath)
+		if err := e.rawUpdateFile(args...); err != nil {
+			return fmt.Errorf("update XMP sidecar %q error: %w", sidecarPath, err)
+		}
+	case errors.Is(err, os.ErrNotExist):
+		args := append(append([]string{"-o", sidecarPath}, assignments...), src)
+		if err := e.rawCreateFile(args...); err != nil {
+			return fmt.Errorf("create XMP sidecar %q for %q error: %w", sidecarPath, src, err)
+		}
+	default:
+		return fmt.Errorf("check XMP sidecar %q error: %w", sidecarPath, err)
+	}
+
+	return nil
+}
+
 // QueryJSONTagsByNumber queries the exif info of `file` with a given struct `value`. Tags are fields of the `value`. The values are a number if possible.
 // See values.go for example structs.
 func (e *Exiftool) QueryJSONTagsByNumber(file string, value any) error {
@@ -258,3 +298,21 @@ func (e *Exiftool) SaveJPEGPreview(src string, previewOutput string) (bool, erro
 
 	return true, nil
 }
+
+// SaveMotionPhotoVideo saves the video embedded in the Motion Photo `src` to `videoOutput`.
+// It returns false if `src` has no embedded video.
+func (e *Exiftool) SaveMotionPhotoVideo(src string, videoOutput string) (bool, error) {
+	// Google stores the video in the MotionPhotoVideo tag, Samsung in EmbeddedVideoFile
+	for _, tag := range []string{"-MotionPhotoVideo", "-EmbeddedVideoFile"} {
+		saved, err := e.rawSaveEmbedFile(videoOutput, tag, src)
+		if err != nil {
+			return false, fmt.Errorf("save motion photo video for %q error: %w", src, err)
+		}
+
+		if saved {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -1,6 +1,7 @@
 package exiftool
 
 import (
+	"bytes"
 	"fmt"
 	"io/fs"
 	"os"
@@ -312,6 +313,61 @@ func TestExiftoolQueryGPS(t *testing.T) {
 	}
 }
 
+func TestExiftoolWriteXMPSidecar(t *testing.T) {
+	instance, err := New()
+	if err != nil {
+		t.Fatalf("new error: %v", err)
+	}
+	defer instance.Close()
+
+	file := "./test_data/no_timezone.jpg"
+	sidecar := filepath.Join(t.TempDir(), "no_timezone.jpg.xmp")
+
+	original, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("read %q error: %v", file, err)
+	}
+
+	rating := 3
+	title := "Created"
+	if err := instance.WriteXMPSidecar(file, sidecar, XMPUpdate{Rating: &rating, Title: &title}); err != nil {
+		t.Fatalf("WriteXMPSidecar(%q, %q) create error: %v", file, sidecar, err)
+	}
+
+	rating = 5
+	keywords := []string{"sunset", "harbour"}
+	if err := instance.WriteXMPSidecar(file, sidecar, XMPUpdate{Rating: &rating, AddKeywords: keywords}); err != nil {
+		t.Fatalf("WriteXMPSidecar(%q, %q) update error: %v", file, sidecar, err)
+	}
+
+	if err := instance.WriteXMPSidecar(file, sidecar, XMPUpdate{Rating: &rating}); err != nil {
+		t.Fatalf("WriteXMPSidecar(%q, %q) unchanged error: %v", file, sidecar, err)
+	}
+
+	var xmp XMP
+	if err := instance.QueryJSONTagsByNumber(sidecar, &xmp); err != nil {
+		t.Fatalf("QueryJSONTagsByNumber(%q) error: %v", sidecar, err)
+	}
+
+	if xmp.Rating == nil || *xmp.Rating != 5 {
+		t.Errorf("sidecar Rating = %v, want: 5", xmp.Rating)
+	}
+	if got := xmp.Title.First(); got == nil || *got != title {
+		t.Errorf("sidecar Title = %v, want: %q", got, title)
+	}
+	if !reflect.DeepEqual([]string(xmp.Subject), keywords) {
+		t.Errorf("sidecar Subject = %q, want: %q", xmp.Subject, keywords)
+	}
+
+	after, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("read %q error: %v", file, err)
+	}
+	if !bytes.Equal(original, after) {
+		t.Errorf("WriteXMPSidecar() modified the original file %q", file)
+	}
+}
+
 func TestExiftoolSaveJPEGPreview(t *testing.T) {
 	tests := []struct {
 		file   string
@@ -373,6 +429,30 @@ func TestExiftoolSaveJPEGPreview(t *testing.T) {
 
 }
 
+func TestExiftoolSaveMotionPhotoVideo(t *testing.T) {
+	instance, err := New()
+	if err != nil {
+		t.Fatalf("new error: %v", err)
+	}
+	defer instance.Close()
+
+	file := "./test_data/no_timezone.jpg"
+	output := filepath.Join(t.TempDir(), "motion.mp4")
+
+	ok, err := instance.SaveMotionPhotoVideo(file, output)
+	if err != nil {
+		t.Fatalf("SaveMotionPhotoVideo(%q, %q) error: %v", file, output, err)
+	}
+
+	if ok {
+		t.Errorf("SaveMotionPhotoVideo(%q, %q) = %v, want: %v", file, output, ok, false)
+	}
+
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("SaveMotionPhotoVideo(%q, %q) created the output file, want no file", file, output)
+	}
+}
+
 func TestExiftoolError(t *testing.T) {
 	instance, err := New()
 	if err != nil {
@@ -1,6 +1,8 @@
 package exiftool
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"math"
 	"strings"
@@ -166,6 +168,169 @@ func (m *PhotoMeta) SanitizeFloats() {
 	}
 }
 
+// Sequence stores tags that identify the frames of a burst or exposure bracketing sequence.
+type Sequence struct {
+	// Shared by all frames of an iPhone burst
+	BurstUUID *string
+	// Position of the frame in continuous shooting or bracketing, 0 for single shots
+	SequenceNumber *int64
+}
+
+// Strings stores a tag with one or more text values.
+// Exiftool returns a single value as a string, or as a number if it looks like one, and multiple values as an array.
+type Strings []string
+
+func (s *Strings) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+
+	values, isArray := value.([]any)
+	if !isArray {
+		values = []any{value}
+	}
+
+	*s = make(Strings, 0, len(values))
+	for _, v := range values {
+		switch v := v.(type) {
+		case nil:
+			continue
+		case string:
+			*s = append(*s, v)
+		default:
+			*s = append(*s, fmt.Sprint(v))
+		}
+	}
+
+	return nil
+}
+
+// First returns the first value, or nil if there are no values.
+func (s Strings) First() *string {
+	if len(s) == 0 {
+		return nil
+	}
+
+	return &s[0]
+}
+
+// XMP stores the curation tags of a XMP sidecar, as written by darktable and Lightroom.
+type XMP struct {
+	Rating              *float64
+	Label               Strings
+	Title               Strings
+	Description         Strings
+	Subject             Strings
+	HierarchicalSubject Strings
+}
+
+// Keywords stores the keywords embedded in the IPTC and XMP metadata of a file.
+type Keywords struct {
+	Keywords Strings
+	Subject  Strings
+}
+
+// All returns the keywords of both tags, without duplicates.
+func (k Keywords) All() []string {
+	ret := make([]string, 0, len(k.Keywords)+len(k.Subject))
+	seen := make(map[string]bool, cap(ret))
+
+	for _, keyword := range append(append([]string{}, k.Keywords...), k.Subject...) {
+		keyword = strings.TrimSpace(keyword)
+		if keyword == "" || seen[keyword] {
+			continue
+		}
+
+		seen[keyword] = true
+		ret = append(ret, keyword)
+	}
+
+	return ret
+}
+
+// XMPUpdate stores the values to write to an XMP sidecar. Values that are nil are left as they are in the sidecar.
+type XMPUpdate struct {
+	// Star rating from 0 to 5
+	Rating *int
+	// Colour label, an empty label removes it
+	Label       *string
+	Title       *string
+	Description *string
+	// Keywords to add to and remove from the keywords of the sidecar, other keywords are kept
+	AddKeywords    []string
+	RemoveKeywords []string
+	// Date and time the media was shot in local time, the timezone of the value is ignored
+	DateShot *time.Time
+	// Offset of the local time of DateShot to UTC, if known
+	OffsetSecShot *int
+	GPSLatitude   *float64
+	GPSLongitude  *float64
+}
+
+// Args returns the exiftool arguments that assign the values of the update.
+func (u XMPUpdate) Args() []string {
+	args := make([]string, 0)
+
+	if u.Rating != nil {
+		args = append(args, fmt.Sprintf("-XMP-xmp:Rating=%d", *u.Rating))
+	}
+
+	for _, value := range []struct {
+		tag   string
+		value *string
+	}{
+		{"XMP-xmp:Label", u.Label},
+		{"XMP-dc:Title", u.Title},
+		{"XMP-dc:Description", u.Description},
+	} {
+		if value.value != nil {
+			args = append(args, "-"+value.tag+"="+argValue(*value.value))
+		}
+	}
+
+	for _, keyword := range u.RemoveKeywords {
+		args = append(args, "-XMP-dc:Subject-="+argValue(keyword))
+	}
+
+	// Added keywords are removed first, so they are not listed twice if the sidecar already has them
+	for _, keyword := range u.AddKeywords {
+		args = append(args, "-XMP-dc:Subject-="+argValue(keyword), "-XMP-dc:Subject+="+argValue(keyword))
+	}
+
+	if u.DateShot != nil {
+		date := u.DateShot.Format("2006:01:02 15:04:05")
+		if u.OffsetSecShot != nil {
+			offset := *u.OffsetSecShot
+			sign := '+'
+			if offset < 0 {
+				sign = '-'
+				offset = -offset
+			}
+			date += fmt.Sprintf("%c%02d:%02d", sign, offset/3600, offset%3600/60)
+		}
+
+		args = append(args, "-XMP-exif:DateTimeOriginal="+date, "-XMP-photoshop:DateCreated="+date)
+	}
+
+	if u.GPSLatitude != nil && u.GPSLongitude != nil {
+		// Exiftool writes negative coordinates as south and west
+		args = append(args,
+			fmt.Sprintf("-XMP-exif:GPSLatitude=%.9f", *u.GPSLatitude),
+			fmt.Sprintf("-XMP-exif:GPSLongitude=%.9f", *u.GPSLongitude))
+	}
+
+	return args
+}
+
+// argValue replaces line breaks in the value of an argument, exiftool reads one argument per line.
+func argValue(value string) string {
+	return strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(value)
+}
+
 type MIMEType struct {
 	MIMEType *string
 }
@@ -1,7 +1,9 @@
 package exiftool
 
 import (
+	"encoding/json"
 	"math"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -195,3 +197,87 @@ func TestTimeAllOffsetSecsEmptyLocal(t *testing.T) {
 		t.Errorf("timeAll.OffsetSecs() = (%d, %v), want: (%d, %v)", got, ok, 0, false)
 	}
 }
+
+func TestStringsUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Strings
+	}{
+		{`"landscape"`, Strings{"landscape"}},
+		{`2019`, Strings{"2019"}},
+		{`20190101123456`, Strings{"20190101123456"}},
+		{`["landscape", "Places|Europe|Paris", 42]`, Strings{"landscape", "Places|Europe|Paris", "42"}},
+		{`[]`, Strings{}},
+		{`null`, Strings{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			var got Strings
+			if err := json.Unmarshal([]byte(tc.input), &got); err != nil {
+				t.Fatalf("json.Unmarshal(%q) error: %v", tc.input, err)
+			}
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("json.Unmarshal(%q) = %#v, want: %#v", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestKeywordsAll(t *testing.T) {
+	keywords := Keywords{
+		Keywords: Strings{"landscape", " sunset ", ""},
+		Subject:  Strings{"sunset", "harbour"},
+	}
+
+	want := []string{"landscape", "sunset", "harbour"}
+	if got := keywords.All(); !reflect.DeepEqual(got, want) {
+		t.Errorf("keywords.All() = %q, want: %q", got, want)
+	}
+}
+
+func TestXMPUpdateArgs(t *testing.T) {
+	rating := 4
+	label := ""
+	description := "First line\nSecond line"
+	dateShot := time.Date(2024, 5, 1, 18, 30, 15, 0, time.UTC)
+	offset := -(5*3600 + 30*60)
+	latitude, longitude := 38.7, -9.14
+
+	tests := []struct {
+		name   string
+		update XMPUpdate
+		want   []string
+	}{
+		{"Empty", XMPUpdate{}, []string{}},
+		{"RatingAndLabel", XMPUpdate{Rating: &rating, Label: &label}, []string{"-XMP-xmp:Rating=4", "-XMP-xmp:Label="}},
+		{"Description", XMPUpdate{Description: &description}, []string{"-XMP-dc:Description=First line Second line"}},
+		{"Keywords", XMPUpdate{AddKeywords: []string{"sunset"}, RemoveKeywords: []string{"sunrise"}}, []string{
+			"-XMP-dc:Subject-=sunrise",
+			"-XMP-dc:Subject-=sunset",
+			"-XMP-dc:Subject+=sunset",
+		}},
+		{"DateWithoutOffset", XMPUpdate{DateShot: &dateShot}, []string{
+			"-XMP-exif:DateTimeOriginal=2024:05:01 18:30:15",
+			"-XMP-photoshop:DateCreated=2024:05:01 18:30:15",
+		}},
+		{"DateWithOffset", XMPUpdate{DateShot: &dateShot, OffsetSecShot: &offset}, []string{
+			"-XMP-exif:DateTimeOriginal=2024:05:01 18:30:15-05:30",
+			"-XMP-photoshop:DateCreated=2024:05:01 18:30:15-05:30",
+		}},
+		{"GPS", XMPUpdate{GPSLatitude: &latitude, GPSLongitude: &longitude}, []string{
+			"-XMP-exif:GPSLatitude=38.700000000",
+			"-XMP-exif:GPSLongitude=-9.140000000",
+		}},
+		{"PartialGPS", XMPUpdate{GPSLatitude: &latitude}, []string{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.update.Args(); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("XMPUpdate.Args() = %q, want: %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+// Package filename_date finds the date media was shot in the names of its file and folders,
+// for media without a date in its metadata, like scanned photos or images sent with messengers.
+package filename_date
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// The parts of dates in the built-in patterns, limited to plausible values so other numbers aren't taken for dates
+const (
+	yearPattern   = `(?P<year>(?:19|20)\d{2})`
+	monthPattern  = `(?P<month>0[1-9]|1[0-2])`
+	dayPattern    = `(?P<day>0[1-9]|[12]\d|3[01])`
+	hourPattern   = `(?P<hour>[01]\d|2[0-3])`
+	minutePattern = `(?P<minute>[0-5]\d)`
+	secondPattern = `(?P<second>[0-5]\d)`
+)
+
+// filePatterns match the names of files like `IMG_20210614_153012`, `PXL_20210614_153012345`,
+// `WhatsApp Image 2021-06-14 at 15.30.12`, `Screenshot_20210614-153012` and `IMG-20210614-WA0001`
+var filePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?:^|\D)` + yearPattern + `-?` + monthPattern + `-?` + dayPattern +
+		`(?:[ _T-]+(?:at )?` + hourPattern + `[.:-]?` + minutePattern + `[.:-]?` + secondPattern + `|\D|$)`),
+}
+
+// folderPatterns match the names of folders like `2019`, `2019-07 Vacation` and `2019_07_14 Birthday`.
+// Numbers that continue after a year or month, like the resolutions `1920x1080` and `2048-1536`, are no dates.
+var folderPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?:^|\D)` + yearPattern + `[-_. ]` + monthPattern + `[-_. ]` + dayPattern + `(?:\D|$)`),
+	regexp.MustCompile(`(?:^|\D)` + yearPattern + `(?:[-_. ]` + monthPattern + `)?(?:$|[^-_. xX×\d]|[-_. xX×]\D)`),
+}
+
+// CompilePattern compiles a custom pattern, that must have a named group `year`,
+// and may have the named groups `month`, `day`, `hour`, `minute` and `second`
+func CompilePattern(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	if re.SubexpIndex("year") == -1 {
+		return nil, fmt.Errorf("pattern %q has no named group `year`", pattern)
+	}
+
+	return re, nil
+}
+
+// Matcher finds dates in the paths of media
+type Matcher struct {
+	customPatterns []*regexp.Regexp
+}
+
+// NewMatcher returns a matcher that tries the custom patterns before the built-in patterns
+func NewMatcher(customPatterns []string) (*Matcher, error) {
+	matcher := Matcher{}
+
+	for _, pattern := range customPatterns {
+		re, err := CompilePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		matcher.customPatterns = append(matcher.customPatterns, re)
+	}
+
+	return &matcher, nil
+}
+
+// siteMatcher is the matcher with the custom patterns of the site settings, nil until it is first used
+var siteMatcher atomic.Pointer[Matcher]
+
+// SiteMatcher returns the matcher with the custom patterns of the site settings.
+// The patterns are read once, until they are changed with ResetSiteMatcher.
+func SiteMatcher(db *gorm.DB) (*Matcher, error) {
+	if matcher := siteMatcher.Load(); matcher != nil {
+		return matcher, nil
+	}
+
+	siteInfo, err := models.GetSiteInfo(db)
+	if err != nil {
+		return nil, err
+	}
+
+	matcher, err := NewMatcher(siteInfo.FilenameDatePatterns)
+	if err != nil {
+		return nil, errors.Wrap(err, "filename date patterns of site settings")
+	}
+
+	siteMatcher.Store(matcher)
+	return matcher, nil
+}
+
+// ResetSiteMatcher makes SiteMatcher read the patterns of the site settings again, after they have been changed
+func ResetSiteMatcher() {
+	siteMatcher.Store(nil)
+}
+
+// Match returns the date found in the name of the file, or else in the names of its folders starting with the closest,
+// up to the folder `rootPath` of the root album. The date is in local time labelled as UTC, like the dates of the exif data.
+// Nil is returned if no date is found.
+func (m *Matcher) Match(mediaPath string, rootPath string) *time.Time {
+	fileName := path.Base(mediaPath)
+	fileName = fileName[:len(fileName)-len(path.Ext(fileName))]
+
+	if date := matchPatterns(fileName, m.customPatterns, filePatterns); date != nil {
+		return date
+	}
+
+	rootPath = path.Clean(rootPath)
+	for dir := path.Dir(mediaPath); dir != "/" && dir != "."; dir = path.Dir(dir) {
+		if date := matchPatterns(path.Base(dir), m.customPatterns, folderPatterns); date != nil {
+			return date
+		}
+
+		// The folders above the root album are not part of the library, like the mount point of the photos
+		if dir == rootPath {
+			break
+		}
+	}
+
+	return nil
+}
+
+func matchPatterns(name string, patternLists ...[]*regexp.Regexp) *time.Time {
+	for _, patterns := range patternLists {
+		for _, re := range patterns {
+			if date := matchDate(re, name); date != nil {
+				return date
+			}
+		}
+	}
+
+	return nil
+}
+
+// matchDate returns the date of the first match of the pattern in the name,
+// or nil if the pattern doesn't match or the date is invalid
+func matchDate(re *regexp.Regexp, name string) *time.Time {
+	match := re.FindStringSubmatch(name)
+	if match == nil {
+		return nil
+	}
+
+	group := func(name string, defaultValue int) int {
+		index := re.SubexpIndex(name)
+		if index == -1 || match[index] == "" {
+			return defaultValue
+		}
+
+		value, err := strconv.Atoi(match[index])
+		if err != nil {
+			return -1
+		}
+		return value
+	}
+
+	year, month, day := group("year", -1), group("month", 1), group("day", 1)
+	hour, minute, second := group("hour", 0), group("minute", 0), group("second", 0)
+
+	if year < 1900 || year > time.Now().Year()+1 {
+		return nil
+	}
+
+	date := time.Date(year, time.Month(month), day, hour, minute, second, 0, time.UTC)
+
+	// time.Date normalizes values out of range, like the 31st of June, which means the match isn't a valid date
+	if date.Year() != year || int(date.Month()) != month || date.Day() != day ||
+		date.Hour() != hour || date.Minute() != minute || date.Second() != second {
+		return nil
+	}
+
+	return &date
+}
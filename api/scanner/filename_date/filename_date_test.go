@@ -0,0 +1,71 @@
+package filename_date
+
+import (
+	"testing"
+	"time"
+
+	"github.com/photoview/photoview/api/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	test_utils.UnitTestRun(m)
+}
+
+func TestMatch(t *testing.T) {
+	matcher, err := NewMatcher([]string{`^scan (?P<day>\d{2})\.(?P<month>\d{2})\.(?P<year>\d{4})$`})
+	require.NoError(t, err)
+
+	tests := []struct {
+		path     string
+		expected *time.Time
+	}{
+		{"/photos/IMG_20210614_153012.jpg", date(2021, 6, 14, 15, 30, 12)},
+		{"/photos/PXL_20210614_153012345.MP.jpg", date(2021, 6, 14, 15, 30, 12)},
+		{"/photos/WhatsApp Image 2021-06-14 at 15.30.12.jpeg", date(2021, 6, 14, 15, 30, 12)},
+		{"/photos/Screenshot_20210614-153012.png", date(2021, 6, 14, 15, 30, 12)},
+		{"/photos/Screenshot 2021-06-14 at 15.30.12.png", date(2021, 6, 14, 15, 30, 12)},
+		{"/photos/IMG-20210614-WA0001.jpg", date(2021, 6, 14, 0, 0, 0)},
+		{"/photos/2019/2019-07 Vacation/DSC_1234.jpg", date(2019, 7, 1, 0, 0, 0)},
+		{"/photos/2019/Vacation/DSC_1234.jpg", date(2019, 1, 1, 0, 0, 0)},
+		{"/photos/2019/2019_07_14 Birthday/DSC_1234.jpg", date(2019, 7, 14, 0, 0, 0)},
+		{"/photos/2019-07 Vacation/IMG_20190712_101500.jpg", date(2019, 7, 12, 10, 15, 0)},
+		{"/photos/scans/scan 24.12.1987.tiff", date(1987, 12, 24, 0, 0, 0)},
+		{"/photos/DSC_12345678.jpg", nil},
+		{"/photos/IMG_20211345_153012.jpg", nil},
+		{"/photos/Vacation/P1000123.jpg", nil},
+		{"/photos/2019-07-14 15.30 Party/DSC_1234.jpg", date(2019, 7, 14, 0, 0, 0)},
+		{"/photos/wallpapers/1920x1080/DSC_1234.jpg", nil},
+		{"/photos/wallpapers/2048-1536/DSC_1234.jpg", nil},
+		{"/photos/wallpapers/1920×1080/DSC_1234.jpg", nil},
+		{"/photos/exports/20190714123/DSC_1234.jpg", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			assert.Equal(t, test.expected, matcher.Match(test.path, "/photos"))
+		})
+	}
+
+	t.Run("folders above the root album", func(t *testing.T) {
+		assert.Nil(t, matcher.Match("/mnt/2019/photos/Vacation/P1000123.jpg", "/mnt/2019/photos"))
+		assert.Equal(t, date(2019, 1, 1, 0, 0, 0), matcher.Match("/mnt/photos/2019/P1000123.jpg", "/mnt/photos/2019/"))
+	})
+}
+
+func TestCompilePattern(t *testing.T) {
+	_, err := CompilePattern(`(?P<year>\d{4})`)
+	assert.NoError(t, err)
+
+	_, err = CompilePattern(`(\d{4})`)
+	assert.Error(t, err, "pattern without year group")
+
+	_, err = CompilePattern(`(?P<year>\d{4}`)
+	assert.Error(t, err, "invalid regular expression")
+}
+
+func date(year int, month time.Month, day, hour, minute, second int) *time.Time {
+	d := time.Date(year, month, day, hour, minute, second, 0, time.UTC)
+	return &d
+}
@@ -0,0 +1,523 @@
+package filesystem_watcher
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/log"
+	"github.com/photoview/photoview/api/scanner/periodic_scanner"
+	"github.com/photoview/photoview/api/scanner/scanner_queue"
+	"gorm.io/gorm"
+)
+
+// How long to wait for the filesystem to settle before scanning a changed album.
+var debounceDelay = 2 * time.Second
+
+// Scanning of a changed album is never delayed by more than this, even if events keep arriving.
+var maxDebounceDelay = 30 * time.Second
+
+// The periodic scan interval used when the watcher falls back to periodic scanning,
+// while periodic scans are disabled in the site settings.
+const fallbackScanInterval = time.Hour
+
+// ErrWatchLimitReached is returned when the kernel refuses to watch more directories.
+var ErrWatchLimitReached = errors.New("filesystem watch limit reached")
+
+type ScannerQueue interface {
+	AddAllToQueue() error
+	AddAlbumToQueue(album *models.Album) error
+	AddAlbumTreeToQueue(album *models.Album) error
+}
+
+type RealScannerQueue struct{}
+
+func (r *RealScannerQueue) AddAllToQueue() error {
+	return scanner_queue.AddAllToQueue(models.ScannerJobPriorityNormal)
+}
+
+func (r *RealScannerQueue) AddAlbumToQueue(album *models.Album) error {
+	return scanner_queue.AddAlbumToQueue(album, models.ScannerJobPriorityHigh)
+}
+
+func (r *RealScannerQueue) AddAlbumTreeToQueue(album *models.Album) error {
+	return scanner_queue.AddAlbumTreeToQueue(album, models.ScannerJobPriorityHigh)
+}
+
+type filesystemWatcher struct {
+	db           *gorm.DB
+	scannerQueue ScannerQueue
+	watcher      *fsnotify.Watcher
+	done         chan struct{}
+	stopped      chan struct{}
+	refresh      chan struct{}
+
+	// Paths of the root albums being watched
+	rootPaths map[string]bool
+
+	// Directories currently being watched
+	watchedDirs map[string]bool
+
+	// Directories with pending changes, mapped to whether the directory structure below them changed
+	pendingDirs  map[string]bool
+	pendingSince time.Time
+	flushTimer   *time.Timer
+}
+
+var mainWatcher *filesystemWatcher = nil
+var mainWatcherDB *gorm.DB = nil
+var mainWatcherQueue ScannerQueue = nil
+var mainWatcherLocker sync.Mutex
+
+func InitializeFilesystemWatcherWithQueue(db *gorm.DB, queue ScannerQueue) error {
+	mainWatcherLocker.Lock()
+	defer mainWatcherLocker.Unlock()
+
+	if mainWatcherDB != nil {
+		return fmt.Errorf("filesystem watcher has already been initialized")
+	}
+
+	siteInfo, err := models.GetSiteInfo(db)
+	if err != nil {
+		return err
+	}
+
+	mainWatcherDB = db
+	mainWatcherQueue = queue
+
+	if !siteInfo.FilesystemWatcher {
+		log.Info(nil, "Filesystem watcher: disabled")
+		return nil
+	}
+
+	startWatcher()
+	return nil
+}
+
+func InitializeFilesystemWatcher(db *gorm.DB) error {
+	return InitializeFilesystemWatcherWithQueue(db, &RealScannerQueue{})
+}
+
+// ChangeFilesystemWatcher starts or stops watching the filesystem
+func ChangeFilesystemWatcher(enabled bool) {
+	mainWatcherLocker.Lock()
+	defer mainWatcherLocker.Unlock()
+
+	if mainWatcherDB == nil {
+		return
+	}
+
+	stopWatcher()
+
+	if enabled {
+		startWatcher()
+	} else {
+		log.Info(nil, "Filesystem watcher: disabled")
+	}
+}
+
+// RefreshFilesystemWatcher makes the watcher, if it is running, pick up the root albums that were added or removed
+// since it was started. The watches of the other root albums are kept.
+func RefreshFilesystemWatcher() {
+	mainWatcherLocker.Lock()
+	defer mainWatcherLocker.Unlock()
+
+	if mainWatcher == nil {
+		return
+	}
+
+	mainWatcher.requestRefresh()
+}
+
+// ShutdownFilesystemWatcher gracefully shuts down the filesystem watcher
+func ShutdownFilesystemWatcher() {
+	mainWatcherLocker.Lock()
+	defer mainWatcherLocker.Unlock()
+
+	if mainWatcher != nil {
+		log.Info(nil, "Shutting down filesystem watcher")
+	}
+
+	stopWatcher()
+	mainWatcherDB = nil
+	mainWatcherQueue = nil
+}
+
+// mainWatcherLocker must be held when calling this function
+func startWatcher() {
+	watcher, err := newFilesystemWatcher(mainWatcherDB, mainWatcherQueue)
+	if err != nil {
+		log.Error(nil, "Filesystem watcher: could not be started, falling back to periodic scans", "error", err)
+		fallbackToPeriodicScanner(mainWatcherDB)
+		return
+	}
+
+	mainWatcher = watcher
+	go mainWatcher.run()
+
+	log.Info(nil, "Filesystem watcher: enabled", "watched_directories", len(watcher.watchedDirs))
+}
+
+// mainWatcherLocker must be held when calling this function
+func stopWatcher() {
+	if mainWatcher == nil {
+		return
+	}
+
+	mainWatcher.stop()
+	mainWatcher = nil
+}
+
+func newFilesystemWatcher(db *gorm.DB, queue ScannerQueue) (*filesystemWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("create filesystem watcher: %w", err)
+	}
+
+	w := &filesystemWatcher{
+		db:           db,
+		scannerQueue: queue,
+		watcher:      watcher,
+		done:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+		refresh:      make(chan struct{}, 1),
+		rootPaths:    make(map[string]bool),
+		watchedDirs:  make(map[string]bool),
+		pendingDirs:  make(map[string]bool),
+	}
+
+	if err := w.refreshRootAlbums(); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// refreshRootAlbums watches the root albums that aren't watched yet, and stops watching the root albums that were removed
+func (w *filesystemWatcher) refreshRootAlbums() error {
+	var rootAlbums []*models.Album
+	if err := w.db.Where("parent_album_id IS NULL").Find(&rootAlbums).Error; err != nil {
+		return fmt.Errorf("get root albums from database: %w", err)
+	}
+
+	rootPaths := make(map[string]bool, len(rootAlbums))
+	for _, album := range rootAlbums {
+		rootPaths[album.Path] = true
+	}
+
+	var removedPaths []string
+	for rootPath := range w.rootPaths {
+		if !rootPaths[rootPath] {
+			w.unwatchTree(rootPath)
+			removedPaths = append(removedPaths, rootPath)
+		}
+	}
+
+	for rootPath := range rootPaths {
+		// Root albums inside or around a removed root album share directories with it, that are no longer watched
+		overlapsRemoved := slices.ContainsFunc(removedPaths, func(removedPath string) bool {
+			return isWithin(rootPath, removedPath) || isWithin(removedPath, rootPath)
+		})
+
+		if w.rootPaths[rootPath] && !overlapsRemoved {
+			continue
+		}
+
+		if err := w.watchTree(rootPath); err != nil {
+			return err
+		}
+	}
+
+	w.rootPaths = rootPaths
+	return nil
+}
+
+// requestRefresh makes the running watcher refresh its root albums, unless a refresh is already pending
+func (w *filesystemWatcher) requestRefresh() {
+	select {
+	case w.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// watchTree adds a watch for the directory and all of its non-hidden sub-directories
+func (w *filesystemWatcher) watchTree(rootPath string) error {
+	return filepath.WalkDir(rootPath, func(dirPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// The directory might have been removed while walking it
+			log.Warn(nil, "Filesystem watcher: could not read directory", "path", dirPath, "error", err)
+			return nil
+		}
+
+		if !entry.IsDir() {
+			return nil
+		}
+
+		if dirPath != rootPath && isHidden(dirPath) {
+			return filepath.SkipDir
+		}
+
+		if err := w.watcher.Add(dirPath); err != nil {
+			if errors.Is(err, syscall.ENOSPC) {
+				return fmt.Errorf("%w while watching %s, consider raising fs.inotify.max_user_watches", ErrWatchLimitReached, dirPath)
+			}
+			log.Warn(nil, "Filesystem watcher: could not watch directory", "path", dirPath, "error", err)
+			return nil
+		}
+
+		w.watchedDirs[dirPath] = true
+		return nil
+	})
+}
+
+// unwatchTree removes the watches for the directory and all of its sub-directories
+func (w *filesystemWatcher) unwatchTree(rootPath string) {
+	for dirPath := range w.watchedDirs {
+		if isWithin(dirPath, rootPath) {
+			// The watch is removed automatically by the kernel if the directory was deleted
+			_ = w.watcher.Remove(dirPath)
+			delete(w.watchedDirs, dirPath)
+		}
+	}
+}
+
+func (w *filesystemWatcher) stop() {
+	close(w.done)
+	<-w.stopped
+}
+
+func (w *filesystemWatcher) run() {
+	defer close(w.stopped)
+	defer w.watcher.Close()
+
+	for {
+		var flush <-chan time.Time
+		if w.flushTimer != nil {
+			flush = w.flushTimer.C
+		}
+
+		select {
+		case <-w.done:
+			if w.flushTimer != nil {
+				w.flushTimer.Stop()
+			}
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+
+			if err := w.handleEvent(event); err != nil {
+				w.fail(err)
+				return
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				log.Warn(nil, "Filesystem watcher: too many changes, events were lost, scanning everything")
+				if err := w.scannerQueue.AddAllToQueue(); err != nil {
+					log.Error(nil, "Filesystem watcher: failed to add all users to queue", "error", err)
+				}
+			} else {
+				log.Error(nil, "Filesystem watcher: error", "error", err)
+			}
+		case <-w.refresh:
+			if err := w.refreshRootAlbums(); errors.Is(err, ErrWatchLimitReached) {
+				w.fail(err)
+				return
+			} else if err != nil {
+				log.Error(nil, "Filesystem watcher: could not refresh root albums", "error", err)
+			}
+		case <-flush:
+			w.flushTimer = nil
+			w.flushPending()
+		}
+	}
+}
+
+// fail falls back to periodic scans when the watcher cannot continue watching, and waits for it to be stopped
+func (w *filesystemWatcher) fail(err error) {
+	log.Error(nil, "Filesystem watcher: stopping, falling back to periodic scans", "error", err)
+	fallbackToPeriodicScanner(w.db)
+	go w.stopSelf()
+	<-w.done
+}
+
+// stopSelf removes the watcher from the global state, when it cannot continue watching
+func (w *filesystemWatcher) stopSelf() {
+	mainWatcherLocker.Lock()
+	defer mainWatcherLocker.Unlock()
+
+	if mainWatcher == w {
+		stopWatcher()
+	}
+}
+
+func (w *filesystemWatcher) handleEvent(event fsnotify.Event) error {
+	eventPath := path.Clean(event.Name)
+	parentDir := path.Dir(eventPath)
+
+	if isHidden(eventPath) {
+		// Changes to ignore files might change which albums exist
+		if path.Base(eventPath) == ".photoviewignore" {
+			w.markPending(parentDir, true)
+		}
+		return nil
+	}
+
+	switch {
+	case event.Has(fsnotify.Create):
+		if isDir(eventPath) {
+			if err := w.watchTree(eventPath); err != nil {
+				return err
+			}
+			w.markPending(parentDir, true)
+		} else {
+			w.markPending(parentDir, false)
+		}
+	case event.Has(fsnotify.Remove), event.Has(fsnotify.Rename):
+		if w.watchedDirs[eventPath] {
+			w.unwatchTree(eventPath)
+			w.markPending(parentDir, true)
+		} else {
+			w.markPending(parentDir, false)
+		}
+	case event.Has(fsnotify.Write):
+		// Files are created empty while being copied, and are first picked up by the scanner when written
+		w.markPending(parentDir, false)
+	}
+
+	return nil
+}
+
+func (w *filesystemWatcher) markPending(dirPath string, structureChanged bool) {
+	if len(w.pendingDirs) == 0 {
+		w.pendingSince = time.Now()
+	}
+
+	w.pendingDirs[dirPath] = w.pendingDirs[dirPath] || structureChanged
+
+	// Postpone the scan until the filesystem has settled, but not indefinitely
+	delay := debounceDelay
+	if remaining := maxDebounceDelay - time.Since(w.pendingSince); remaining < delay {
+		delay = max(remaining, 0)
+	}
+
+	if w.flushTimer == nil {
+		w.flushTimer = time.NewTimer(delay)
+	} else {
+		w.flushTimer.Reset(delay)
+	}
+}
+
+// flushPending adds an album scanner job for every directory that has changed since the last flush
+func (w *filesystemWatcher) flushPending() {
+	albumJobs := make(map[int]*models.Album)
+	treeJobs := make(map[int]bool)
+
+	for dirPath, structureChanged := range w.pendingDirs {
+		album, exact, err := w.findAlbumForDirectory(dirPath)
+		if err != nil {
+			log.Error(nil, "Filesystem watcher: could not find album for directory", "path", dirPath, "error", err)
+			continue
+		}
+
+		if album == nil {
+			continue
+		}
+
+		albumJobs[album.ID] = album
+
+		// New directories must first be turned into albums, which requires walking their parent album
+		if structureChanged || !exact {
+			treeJobs[album.ID] = true
+		}
+	}
+
+	w.pendingDirs = make(map[string]bool)
+
+	for albumID, album := range albumJobs {
+		var err error
+		if treeJobs[albumID] {
+			log.Info(nil, "Filesystem watcher: albums changed, scanning album tree", "album_path", album.Path)
+			err = w.scannerQueue.AddAlbumTreeToQueue(album)
+		} else {
+			log.Info(nil, "Filesystem watcher: media changed, scanning album", "album_path", album.Path)
+			err = w.scannerQueue.AddAlbumToQueue(album)
+		}
+
+		if err != nil {
+			log.Error(nil, "Filesystem watcher: failed to add album to queue", "album_path", album.Path, "error", err)
+		}
+	}
+}
+
+// findAlbumForDirectory returns the album of the directory, or the album of the closest parent directory.
+// The `exact` result is false, if the album belongs to a parent directory.
+func (w *filesystemWatcher) findAlbumForDirectory(dirPath string) (album *models.Album, exact bool, err error) {
+	exact = true
+
+	for {
+		var albums []*models.Album
+		if err := w.db.Where("path_hash = ?", models.MD5Hash(dirPath)).Limit(1).Find(&albums).Error; err != nil {
+			return nil, false, err
+		}
+
+		if len(albums) > 0 {
+			return albums[0], exact, nil
+		}
+
+		parentDir := path.Dir(dirPath)
+		if parentDir == dirPath {
+			return nil, false, nil
+		}
+
+		dirPath = parentDir
+		exact = false
+	}
+}
+
+// fallbackToPeriodicScanner makes sure changes are still picked up by periodic scans,
+// when the filesystem can not be watched.
+func fallbackToPeriodicScanner(db *gorm.DB) {
+	siteInfo, err := models.GetSiteInfo(db)
+	if err != nil {
+		log.Error(nil, "Filesystem watcher: could not get site info", "error", err)
+		return
+	}
+
+	if siteInfo.PeriodicScanInterval > 0 {
+		return
+	}
+
+	log.Warn(nil, "Filesystem watcher: periodic scans are disabled, enabling them until restart", "interval", fallbackScanInterval.String())
+	periodic_scanner.ChangePeriodicScanInterval(fallbackScanInterval)
+}
+
+// isWithin returns true if the path is the directory `dirPath` or inside it
+func isWithin(filePath string, dirPath string) bool {
+	return filePath == dirPath || strings.HasPrefix(filePath, dirPath+"/")
+}
+
+func isHidden(filePath string) bool {
+	return strings.HasPrefix(path.Base(filePath), ".")
+}
+
+func isDir(filePath string) bool {
+	stat, err := os.Stat(filePath)
+	return err == nil && stat.IsDir()
+}
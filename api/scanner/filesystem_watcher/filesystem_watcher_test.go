@@ -0,0 +1,162 @@
+package filesystem_watcher
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockScannerQueue implements the ScannerQueue interface for testing
+type MockScannerQueue struct {
+	albums     chan *models.Album
+	albumTrees chan *models.Album
+}
+
+func newMockScannerQueue() *MockScannerQueue {
+	return &MockScannerQueue{
+		albums:     make(chan *models.Album, 10),
+		albumTrees: make(chan *models.Album, 10),
+	}
+}
+
+func (m *MockScannerQueue) AddAllToQueue() error {
+	return nil
+}
+
+func (m *MockScannerQueue) AddAlbumToQueue(album *models.Album) error {
+	m.albums <- album
+	return nil
+}
+
+func (m *MockScannerQueue) AddAlbumTreeToQueue(album *models.Album) error {
+	m.albumTrees <- album
+	return nil
+}
+
+func TestMain(m *testing.M) {
+	test_utils.UnitTestRun(m)
+}
+
+func waitForAlbum(t *testing.T, albums chan *models.Album) *models.Album {
+	t.Helper()
+
+	select {
+	case album := <-albums:
+		return album
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for album to be added to the queue")
+		return nil
+	}
+}
+
+func TestFilesystemWatcher(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	oldDebounceDelay := debounceDelay
+	debounceDelay = 50 * time.Millisecond
+	defer func() { debounceDelay = oldDebounceDelay }()
+
+	rootPath := t.TempDir()
+	require.NoError(t, os.Mkdir(path.Join(rootPath, "child"), 0755))
+
+	rootAlbum := models.Album{
+		Title: "root",
+		Path:  rootPath,
+	}
+	require.NoError(t, db.Create(&rootAlbum).Error)
+
+	childAlbum := models.Album{
+		Title:         "child",
+		Path:          path.Join(rootPath, "child"),
+		ParentAlbumID: &rootAlbum.ID,
+	}
+	require.NoError(t, db.Create(&childAlbum).Error)
+
+	mockQueue := newMockScannerQueue()
+	watcher, err := newFilesystemWatcher(db, mockQueue)
+	require.NoError(t, err)
+	assert.True(t, watcher.watchedDirs[rootPath], "root album should be watched")
+	assert.True(t, watcher.watchedDirs[childAlbum.Path], "sub-album should be watched")
+
+	go watcher.run()
+	defer watcher.stop()
+
+	t.Run("new media scans the album", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path.Join(childAlbum.Path, "photo.jpg"), []byte("photo"), 0644))
+
+		album := waitForAlbum(t, mockQueue.albums)
+		assert.Equal(t, childAlbum.ID, album.ID)
+	})
+
+	t.Run("hidden files are ignored", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path.Join(childAlbum.Path, ".hidden.jpg"), []byte("photo"), 0644))
+
+		select {
+		case album := <-mockQueue.albums:
+			t.Errorf("expected no album to be queued, got %s", album.Path)
+		case <-time.After(5 * debounceDelay):
+		}
+	})
+
+	t.Run("new directory scans the parent album tree", func(t *testing.T) {
+		newDir := path.Join(rootPath, "new_dir")
+		require.NoError(t, os.Mkdir(newDir, 0755))
+
+		album := waitForAlbum(t, mockQueue.albumTrees)
+		assert.Equal(t, rootAlbum.ID, album.ID)
+
+		// Files in directories without an album, are picked up by scanning the parent album tree
+		require.NoError(t, os.WriteFile(path.Join(newDir, "photo.jpg"), []byte("photo"), 0644))
+
+		album = waitForAlbum(t, mockQueue.albumTrees)
+		assert.Equal(t, rootAlbum.ID, album.ID)
+	})
+
+	t.Run("removed directory scans the parent album tree", func(t *testing.T) {
+		require.NoError(t, os.RemoveAll(childAlbum.Path))
+
+		album := waitForAlbum(t, mockQueue.albumTrees)
+		assert.Equal(t, rootAlbum.ID, album.ID)
+		assert.False(t, watcher.watchedDirs[childAlbum.Path], "removed directory should no longer be watched")
+	})
+}
+
+func TestRefreshRootAlbums(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	keptPath, removedPath, addedPath := t.TempDir(), t.TempDir(), t.TempDir()
+	nestedPath := path.Join(removedPath, "nested")
+	require.NoError(t, os.Mkdir(nestedPath, 0755))
+	require.NoError(t, os.Mkdir(path.Join(addedPath, "child"), 0755))
+
+	keptAlbum := models.Album{Title: "kept", Path: keptPath}
+	require.NoError(t, db.Create(&keptAlbum).Error)
+	removedAlbum := models.Album{Title: "removed", Path: removedPath}
+	require.NoError(t, db.Create(&removedAlbum).Error)
+	nestedAlbum := models.Album{Title: "nested", Path: nestedPath}
+	require.NoError(t, db.Create(&nestedAlbum).Error)
+
+	watcher, err := newFilesystemWatcher(db, newMockScannerQueue())
+	require.NoError(t, err)
+	defer watcher.watcher.Close()
+
+	require.NoError(t, db.Delete(&removedAlbum).Error)
+	addedAlbum := models.Album{Title: "added", Path: addedPath}
+	require.NoError(t, db.Create(&addedAlbum).Error)
+
+	require.NoError(t, watcher.refreshRootAlbums())
+
+	assert.True(t, watcher.watchedDirs[keptPath], "unchanged root album should still be watched")
+	assert.False(t, watcher.watchedDirs[removedPath], "removed root album should no longer be watched")
+	assert.True(t, watcher.watchedDirs[nestedPath], "root album inside a removed root album should still be watched")
+	assert.True(t, watcher.watchedDirs[addedPath], "added root album should be watched")
+	assert.True(t, watcher.watchedDirs[path.Join(addedPath, "child")], "directories of added root album should be watched")
+	assert.ElementsMatch(t, []string{keptPath, nestedPath, addedPath, path.Join(addedPath, "child")},
+		watcher.watcher.WatchList())
+}
@@ -0,0 +1,258 @@
+// Package geocoding resolves GPS coordinates to the country, region and city they are in,
+// using an offline GeoNames dataset so no coordinates are sent over the network.
+package geocoding
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/log"
+	"github.com/photoview/photoview/api/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxDistanceKm is the max distance to the closest city, to resolve the country and region of coordinates
+	maxDistanceKm = 100
+	// maxCityDistanceKm is the max distance to the closest city, to resolve the city of coordinates
+	maxCityDistanceKm = 30
+	earthRadiusKm     = 6371
+	kmPerDegree       = earthRadiusKm * math.Pi / 180
+)
+
+// citiesFiles are the GeoNames city dumps that can be used, the most detailed one found is loaded
+var citiesFiles = []string{"cities500.txt", "cities1000.txt", "cities5000.txt", "cities15000.txt"}
+
+type city struct {
+	name        string
+	latitude    float64
+	longitude   float64
+	countryCode string
+	admin1Code  string
+}
+
+type cell struct {
+	latitude  int
+	longitude int
+}
+
+// Geocoder looks up the closest city of coordinates
+type Geocoder struct {
+	cities    []city
+	cells     map[cell][]int
+	countries map[string]string
+	// Names of regions by `<country code>.<admin1 code>`
+	regions map[string]string
+}
+
+// GlobalGeocoder is nil if no GeoNames dataset was found
+var GlobalGeocoder *Geocoder = nil
+
+// InitializeGeocoder loads the GeoNames dataset of utils.GeoNamesPath,
+// geocoding is disabled if the dataset is not found
+func InitializeGeocoder() error {
+	dir := utils.GeoNamesPath()
+
+	geocoder, err := Load(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Warn(context.Background(), "GeoNames dataset not found, places will not be resolved from coordinates",
+			"path", dir, "error", err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Info(context.Background(), "Loaded GeoNames dataset", "path", dir, "cities", len(geocoder.cities))
+	GlobalGeocoder = geocoder
+
+	return nil
+}
+
+// Load reads the GeoNames dataset in the directory, which must contain one of the city dumps
+// like `cities15000.txt`, `countryInfo.txt`, and optionally `admin1CodesASCII.txt`
+func Load(dir string) (*Geocoder, error) {
+	g := Geocoder{
+		cells:     make(map[cell][]int),
+		countries: make(map[string]string),
+		regions:   make(map[string]string),
+	}
+
+	citiesPath := ""
+	for _, name := range citiesFiles {
+		if _, err := os.Stat(path.Join(dir, name)); err == nil {
+			citiesPath = path.Join(dir, name)
+			break
+		}
+	}
+	if citiesPath == "" {
+		return nil, fmt.Errorf("no cities file in %q: %w", dir, os.ErrNotExist)
+	}
+
+	if err := readTSV(citiesPath, 15, g.addCity); err != nil {
+		return nil, errors.Wrap(err, "read GeoNames cities")
+	}
+
+	if err := readTSV(path.Join(dir, "countryInfo.txt"), 5, func(fields []string) error {
+		g.countries[fields[0]] = fields[4]
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "read GeoNames countries")
+	}
+
+	err := readTSV(path.Join(dir, "admin1CodesASCII.txt"), 2, func(fields []string) error {
+		g.regions[fields[0]] = fields[1]
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, errors.Wrap(err, "read GeoNames regions")
+	}
+
+	return &g, nil
+}
+
+// readTSV calls `row` with the fields of each line of the tab separated file, skipping comments
+func readTSV(filePath string, minFields int, row func(fields []string) error) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line != "" && !strings.HasPrefix(line, "#") {
+			fields := strings.Split(line, "\t")
+			if len(fields) < minFields {
+				return fmt.Errorf("line %d of %q has %d fields, expected at least %d", lineNumber, filePath, len(fields), minFields)
+			}
+
+			if err := row(fields); err != nil {
+				return fmt.Errorf("line %d of %q: %w", lineNumber, filePath, err)
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// addCity adds a row of a GeoNames cities dump, the columns are documented at https://download.geonames.org/export/dump/
+func (g *Geocoder) addCity(fields []string) error {
+	latitude, err := strconv.ParseFloat(fields[4], 64)
+	if err != nil {
+		return errors.Wrap(err, "parse latitude")
+	}
+
+	longitude, err := strconv.ParseFloat(fields[5], 64)
+	if err != nil {
+		return errors.Wrap(err, "parse longitude")
+	}
+
+	g.cities = append(g.cities, city{
+		name:        fields[1],
+		latitude:    latitude,
+		longitude:   longitude,
+		countryCode: fields[8],
+		admin1Code:  fields[10],
+	})
+
+	c := cellOf(latitude, longitude)
+	g.cells[c] = append(g.cells[c], len(g.cities)-1)
+
+	return nil
+}
+
+func cellOf(latitude, longitude float64) cell {
+	return cell{latitude: int(math.Floor(latitude)), longitude: int(math.Floor(longitude))}
+}
+
+// Lookup returns the place of the coordinates, or nil if there is no city close enough to the coordinates
+func (g *Geocoder) Lookup(latitude, longitude float64) *models.Place {
+	closest, distance := g.closestCity(latitude, longitude)
+	if closest == nil || distance > maxDistanceKm {
+		return nil
+	}
+
+	place := models.Place{
+		CountryCode: closest.countryCode,
+		Country:     g.countries[closest.countryCode],
+	}
+
+	if place.Country == "" {
+		place.Country = closest.countryCode
+	}
+
+	if region, found := g.regions[closest.countryCode+"."+closest.admin1Code]; found {
+		place.Region = &region
+	}
+
+	if distance <= maxCityDistanceKm {
+		name := closest.name
+		place.City = &name
+	}
+
+	return &place
+}
+
+// closestCity searches the cells of the grid within maxDistanceKm of the coordinates
+func (g *Geocoder) closestCity(latitude, longitude float64) (*city, float64) {
+	latitudeCells := int(math.Ceil(maxDistanceKm / kmPerDegree))
+
+	// Degrees of longitude get shorter towards the poles
+	longitudeCells := 180
+	if cos := math.Cos(math.Min(math.Abs(latitude)+float64(latitudeCells), 90) * math.Pi / 180); cos > 0 {
+		longitudeCells = min(int(math.Ceil(maxDistanceKm/(kmPerDegree*cos))), 180)
+	}
+
+	center := cellOf(latitude, longitude)
+
+	var closest *city
+	closestDistance := math.Inf(1)
+	for dLat := -latitudeCells; dLat <= latitudeCells; dLat++ {
+		for dLng := -longitudeCells; dLng <= longitudeCells; dLng++ {
+			c := cell{
+				latitude:  center.latitude + dLat,
+				longitude: (center.longitude+dLng+180+360)%360 - 180,
+			}
+
+			for _, index := range g.cells[c] {
+				candidate := &g.cities[index]
+				distance := distanceKm(latitude, longitude, candidate.latitude, candidate.longitude)
+				if distance < closestDistance {
+					closest = candidate
+					closestDistance = distance
+				}
+			}
+		}
+	}
+
+	return closest, closestDistance
+}
+
+// distanceKm returns the great-circle distance between two coordinates using the haversine formula
+func distanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRadians := math.Pi / 180
+	dLat := (lat2 - lat1) * toRadians
+	dLng := (lng2 - lng1) * toRadians
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRadians)*math.Cos(lat2*toRadians)*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
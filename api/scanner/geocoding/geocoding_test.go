@@ -0,0 +1,60 @@
+package geocoding
+
+import (
+	"os"
+	"testing"
+
+	"github.com/photoview/photoview/api/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	test_utils.UnitTestRun(m)
+}
+
+func TestLookup(t *testing.T) {
+	geocoder, err := Load(test_utils.PathFromAPIRoot("scanner", "geocoding", "test_data"))
+	require.NoError(t, err)
+
+	t.Run("in a city", func(t *testing.T) {
+		place := geocoder.Lookup(38.7223, -9.1393)
+		require.NotNil(t, place)
+		assert.Equal(t, "PT", place.CountryCode)
+		assert.Equal(t, "Portugal", place.Country)
+		assert.Equal(t, "Lisbon", *place.Region)
+		assert.Equal(t, "Lisbon", *place.City)
+	})
+
+	t.Run("closest city", func(t *testing.T) {
+		place := geocoder.Lookup(41.1, -8.6)
+		require.NotNil(t, place)
+		assert.Equal(t, "Porto", *place.City)
+	})
+
+	t.Run("too far from a city", func(t *testing.T) {
+		// About 50km south of Porto
+		place := geocoder.Lookup(40.7, -8.6)
+		require.NotNil(t, place)
+		assert.Equal(t, "Portugal", place.Country)
+		assert.Nil(t, place.City)
+	})
+
+	t.Run("nowhere close", func(t *testing.T) {
+		assert.Nil(t, geocoder.Lookup(30, -40), "middle of the Atlantic")
+	})
+
+	t.Run("across the antimeridian", func(t *testing.T) {
+		require.NoError(t, geocoder.addCity([]string{"", "Somosomo", "", "", "-16.7667", "179.95", "", "", "FJ", "", "03"}))
+
+		place := geocoder.Lookup(-16.7667, -179.95)
+		require.NotNil(t, place)
+		assert.Equal(t, "Fiji", place.Country)
+		assert.Equal(t, "Somosomo", *place.City)
+	})
+}
+
+func TestLoadMissingDataset(t *testing.T) {
+	_, err := Load(t.TempDir())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,111 @@
+package media_encoding
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/scanner/media_encoding/executable_worker"
+)
+
+// hlsAudioBitrate is the audio bitrate of every variant in kbit/s
+const hlsAudioBitrate = 128
+
+// HLSVariant is a quality of a video in a HLS stream
+type HLSVariant struct {
+	Name string
+	// Max width and height in pixels
+	Size int
+	// Video bitrate in kbit/s
+	VideoBitrate int
+	// H.264 level the variant conforms to at up to 60 frames per second, as in the avc1 codec string
+	H264Level string
+}
+
+// hlsAudioCodec is the codec string of the AAC-LC audio of every variant
+const hlsAudioCodec = "mp4a.40.2"
+
+// HLSVariants lists the qualities that videos are encoded to for HLS streaming, from lowest to highest
+var HLSVariants = []HLSVariant{
+	{Name: "360p", Size: 640, VideoBitrate: 800, H264Level: "1f"},
+	{Name: "720p", Size: 1280, VideoBitrate: 2800, H264Level: "20"},
+	{Name: "1080p", Size: 1920, VideoBitrate: 5000, H264Level: "2a"},
+	{Name: "2160p", Size: 3840, VideoBitrate: 14000, H264Level: "34"},
+}
+
+// hlsVariantsForSource returns the variants that are not larger than the source video,
+// or the smallest variant if the source is smaller than all of them
+func hlsVariantsForSource(source Dimension) []HLSVariant {
+	sourceSize := max(source.Width, source.Height)
+
+	variants := make([]HLSVariant, 0)
+	for _, variant := range HLSVariants {
+		if variant.Size <= sourceSize {
+			variants = append(variants, variant)
+		}
+	}
+
+	if len(variants) == 0 {
+		variants = append(variants, HLSVariants[0])
+	}
+
+	return variants
+}
+
+// variantDimension returns the dimension ffmpeg scales the source video to for the variant
+func (v HLSVariant) variantDimension(source Dimension) Dimension {
+	scaled := source.ScaleToFit(v.Size)
+
+	return Dimension{
+		Width:  scaled.Width - scaled.Width%2,
+		Height: scaled.Height - scaled.Height%2,
+	}
+}
+
+// codecs returns the CODECS attribute of the variant in the master playlist, which Safari needs to pick a variant.
+// The codecs of the stream are only known for H.264, which is encoded with the high profile.
+func (v HLSVariant) codecs() string {
+	if !executable_worker.Ffmpeg.EncodesH264() {
+		return ""
+	}
+
+	return fmt.Sprintf(",CODECS=\"avc1.6400%s,%s\"", v.H264Level, hlsAudioCodec)
+}
+
+// EncodeHLS encodes the video at `inputPath` to HLS segments of several bitrate variants inside `outputDir`,
+// and writes a master playlist listing the variants. It returns the dimension of the largest variant.
+func EncodeHLS(inputPath string, outputDir string, source Dimension) (Dimension, error) {
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return Dimension{}, fmt.Errorf("create HLS directory %q error: %w", outputDir, err)
+	}
+
+	variants := hlsVariantsForSource(source)
+
+	var master strings.Builder
+	master.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+
+	var largest Dimension
+	for _, variant := range variants {
+		playlistPath := path.Join(outputDir, variant.Name+".m3u8")
+		segmentPattern := path.Join(outputDir, variant.Name+"_%05d.ts")
+
+		err := executable_worker.Ffmpeg.EncodeHLSVariant(inputPath, playlistPath, segmentPattern, variant.Size, variant.VideoBitrate, hlsAudioBitrate)
+		if err != nil {
+			return Dimension{}, fmt.Errorf("encode HLS variant %s of %q error: %w", variant.Name, inputPath, err)
+		}
+
+		dimension := variant.variantDimension(source)
+		largest = dimension
+
+		fmt.Fprintf(&master, "#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d%s\n%s.m3u8\n",
+			(variant.VideoBitrate+hlsAudioBitrate)*1000, dimension.Width, dimension.Height, variant.codecs(), variant.Name)
+	}
+
+	if err := os.WriteFile(path.Join(outputDir, models.HLSMasterPlaylist), []byte(master.String()), 0644); err != nil {
+		return Dimension{}, fmt.Errorf("write HLS master playlist of %q error: %w", inputPath, err)
+	}
+
+	return largest, nil
+}
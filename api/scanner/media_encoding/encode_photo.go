@@ -23,6 +23,12 @@ type Dimension struct {
 
 // ThumbnailScale generates a new dimension for thumbnails.
 func (d *Dimension) ThumbnailScale() Dimension {
+	return d.ScaleToFit(ThumbnailRendition().Size)
+}
+
+// ScaleToFit generates a new dimension with the same aspect ratio, that fits inside a square of `size` pixels.
+// Images are never scaled up.
+func (d *Dimension) ScaleToFit(size int) Dimension {
 	if d.Height == 0 || d.Width == 0 {
 		return Dimension{Width: 0, Height: 0}
 	}
@@ -32,11 +38,11 @@ func (d *Dimension) ThumbnailScale() Dimension {
 	var width, height int
 
 	if aspect > 1 {
-		width = 1024
-		height = int(1024 / aspect)
+		width = size
+		height = int(float64(size) / aspect)
 	} else {
-		width = int(1024 * aspect)
-		height = 1024
+		width = int(float64(size) * aspect)
+		height = size
 	}
 
 	if width > d.Width {
@@ -64,33 +70,44 @@ func GetPhotoDimensions(imagePath string) (Dimension, error) {
 }
 
 // EncodeThumbnail encodes a thumbnail of `inputPath`, and store it as `outputPath`.
-// It returns the dimension of the thumbnail. The thumbnail will be not bigger than 1024x1024.
+// It returns the dimension of the thumbnail. The thumbnail will be not bigger than the configured thumbnail rendition.
 func EncodeThumbnail(db *gorm.DB, inputPath string, outputPath string) (Dimension, error) {
+	return EncodeRendition(inputPath, outputPath, ThumbnailRendition())
+}
+
+// EncodeRendition encodes a JPEG of `inputPath` scaled down to the size of `rendition`, and store it as `outputPath`.
+// It returns the dimension of the encoded image.
+func EncodeRendition(inputPath string, outputPath string, rendition Rendition) (Dimension, error) {
 	w, h, err := executable_worker.Magick.IdentifyDimension(inputPath)
 	if err != nil {
-		return Dimension{}, fmt.Errorf("can't generate thumbnail of file %q: %w", inputPath, err)
+		return Dimension{}, fmt.Errorf("can't generate %s of file %q: %w", rendition.Name, inputPath, err)
 	}
 
 	origin := Dimension{
 		Width:  int(w),
 		Height: int(h),
 	}
-	thumbnail := origin.ThumbnailScale()
 
-	if err := executable_worker.Magick.GenerateThumbnail(inputPath, outputPath, uint(thumbnail.Width), uint(thumbnail.Height)); err != nil {
-		return Dimension{}, fmt.Errorf("can't generate thumbnail of file %q: %w", inputPath, err)
+	scaled := origin
+	if rendition.Size > 0 {
+		scaled = origin.ScaleToFit(rendition.Size)
 	}
 
+	if err := executable_worker.Magick.GenerateThumbnail(inputPath, outputPath, uint(scaled.Width), uint(scaled.Height), rendition.Quality); err != nil {
+		return Dimension{}, fmt.Errorf("can't generate %s of file %q: %w", rendition.Name, inputPath, err)
+	}
+
+	encodeAlternativeFormats(inputPath, outputPath, scaled, rendition.Quality)
+
 	w, h, err = executable_worker.Magick.IdentifyDimension(outputPath)
 	if err != nil {
-		return Dimension{}, fmt.Errorf("can't generate thumbnail of file %q: %w", inputPath, err)
+		return Dimension{}, fmt.Errorf("can't generate %s of file %q: %w", rendition.Name, inputPath, err)
 	}
-	thumbnail = Dimension{
+
+	return Dimension{
 		Width:  int(w),
 		Height: int(h),
-	}
-
-	return thumbnail, nil
+	}, nil
 }
 
 // EncodeMediaData is used to easily decode media data, with a cache so expensive operations are not repeated
@@ -143,10 +160,20 @@ func (img *EncodeMediaData) EncodeHighRes(outputPath string) error {
 			imgPath = *img.CounterpartPath
 		}
 
-		err := executable_worker.Magick.EncodeJpeg(imgPath, outputPath, 70)
+		highRes := HighResRendition()
+		if highRes.Size > 0 {
+			if _, err := EncodeRendition(imgPath, outputPath, highRes); err != nil {
+				return fmt.Errorf("failed to convert RAW photo %q to JPEG: %w", imgPath, err)
+			}
+			return nil
+		}
+
+		err := executable_worker.Magick.EncodeJpeg(imgPath, outputPath, highRes.Quality)
 		if err != nil {
 			return fmt.Errorf("failed to convert RAW photo %q to JPEG: %w", imgPath, err)
 		}
+
+		encodeAlternativeFormats(imgPath, outputPath, Dimension{}, highRes.Quality)
 	}
 
 	return nil
@@ -71,7 +71,13 @@ func (cli *FfmpegCli) IsInstalled() bool {
 	return cli.err == nil
 }
 
-func (cli *FfmpegCli) EncodeMp4(inputPath string, outputPath string) error {
+// EncodesH264 returns whether videos are encoded with one of the H.264 encoders
+func (cli *FfmpegCli) EncodesH264() bool {
+	return strings.HasPrefix(cli.videoCodec, defaultCodec)
+}
+
+// EncodeMp4 encodes a web optimized video, scaled down to fit inside a square of `maxSize` pixels
+func (cli *FfmpegCli) EncodeMp4(inputPath string, outputPath string, maxSize int) error {
 	if cli.err != nil {
 		return fmt.Errorf("encoding video %q error: ffmpeg: %w", inputPath, cli.err)
 	}
@@ -81,7 +87,7 @@ func (cli *FfmpegCli) EncodeMp4(inputPath string, outputPath string) error {
 		inputPath,
 		"-vcodec", cli.videoCodec,
 		"-acodec", "aac",
-		"-vf", "scale='min(1080,iw)':'min(1080,ih)':force_original_aspect_ratio=decrease:force_divisible_by=2",
+		"-vf", scaleFilter(maxSize),
 		"-movflags", "+faststart+use_metadata_tags",
 		outputPath,
 	}
@@ -95,7 +101,8 @@ func (cli *FfmpegCli) EncodeMp4(inputPath string, outputPath string) error {
 	return nil
 }
 
-func (cli *FfmpegCli) EncodeVideoThumbnail(inputPath string, outputPath string, probeData *ffprobe.ProbeData) error {
+// EncodeVideoThumbnail encodes a frame of the video as thumbnail, scaled down to fit inside a square of `maxSize` pixels
+func (cli *FfmpegCli) EncodeVideoThumbnail(inputPath string, outputPath string, probeData *ffprobe.ProbeData, maxSize int) error {
 	if cli.err != nil {
 		return fmt.Errorf("encoding video thumbnail %q error: ffmpeg: %w", inputPath, cli.err)
 	}
@@ -108,7 +115,7 @@ func (cli *FfmpegCli) EncodeVideoThumbnail(inputPath string, outputPath string,
 		inputPath,
 		"-vframes", "1", // output one frame
 		"-an", // disable audio
-		"-vf", "scale='min(1024,iw)':'min(1024,ih)':force_original_aspect_ratio=decrease:force_divisible_by=2",
+		"-vf", scaleFilter(maxSize),
 		outputPath,
 	}
 
@@ -120,3 +127,87 @@ func (cli *FfmpegCli) EncodeVideoThumbnail(inputPath string, outputPath string,
 
 	return nil
 }
+
+// EncodeVideoPreview encodes a short silent preview clip, made of `clips` parts of `clipSeconds` each, sampled evenly
+// across the video. The clip is scaled down to fit inside a square of `maxSize` pixels.
+func (cli *FfmpegCli) EncodeVideoPreview(inputPath string, outputPath string, probeData *ffprobe.ProbeData, maxSize int, clips int, clipSeconds float64) error {
+	if cli.err != nil {
+		return fmt.Errorf("encoding video preview %q error: ffmpeg: %w", inputPath, cli.err)
+	}
+
+	duration := probeData.Format.DurationSeconds
+	previewFilter := scaleFilter(maxSize)
+	if duration > float64(clips)*clipSeconds {
+		// Keep the first seconds of every interval, and close the gaps between them
+		interval := duration / float64(clips)
+		previewFilter = fmt.Sprintf("select='lt(mod(t,%.3f),%.3f)',setpts=N/FRAME_RATE/TB,%s", interval, clipSeconds, previewFilter)
+	}
+
+	args := []string{
+		"-i",
+		inputPath,
+		"-an", // disable audio
+		"-vcodec", cli.videoCodec,
+		"-vf", previewFilter,
+		"-t", fmt.Sprintf("%.3f", float64(clips)*clipSeconds),
+		"-movflags", "+faststart",
+		outputPath,
+	}
+
+	cmd := exec.Command(cli.path, args...)
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("encoding video preview with %q %v error: %w", cli.path, args, err)
+	}
+
+	return nil
+}
+
+// EncodeHLSVariant encodes one bitrate variant of a HLS stream, as a playlist and segments named by `segmentPattern`.
+// The video is scaled down to fit inside a square of `maxSize` pixels, and bitrates are in kbit/s.
+func (cli *FfmpegCli) EncodeHLSVariant(inputPath string, playlistPath string, segmentPattern string, maxSize int, videoBitrate int, audioBitrate int) error {
+	if cli.err != nil {
+		return fmt.Errorf("encoding HLS variant %q error: ffmpeg: %w", inputPath, cli.err)
+	}
+
+	args := []string{
+		"-i",
+		inputPath,
+		"-vcodec", cli.videoCodec,
+		"-acodec", "aac",
+		"-vf", scaleFilter(maxSize),
+		"-b:v", fmt.Sprintf("%dk", videoBitrate),
+		"-maxrate", fmt.Sprintf("%dk", videoBitrate*107/100),
+		"-bufsize", fmt.Sprintf("%dk", videoBitrate*2),
+		"-b:a", fmt.Sprintf("%dk", audioBitrate),
+	}
+
+	// The profile is fixed, as it is declared in the codecs of the master playlist
+	if cli.EncodesH264() {
+		args = append(args, "-profile:v", "high")
+	}
+	if cli.videoCodec == defaultCodec {
+		args = append(args, "-pix_fmt", "yuv420p")
+	}
+
+	args = append(args,
+		"-f", "hls",
+		"-hls_time", "6",
+		"-hls_playlist_type", "vod",
+		"-hls_segment_filename", segmentPattern,
+		playlistPath,
+	)
+
+	cmd := exec.Command(cli.path, args...)
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("encoding HLS variant with %q %v error: %w", cli.path, args, err)
+	}
+
+	return nil
+}
+
+// scaleFilter returns a ffmpeg filter that scales a video down to fit inside a square of `maxSize` pixels
+func scaleFilter(maxSize int) string {
+	return fmt.Sprintf("scale='min(%d,iw)':'min(%d,ih)':force_original_aspect_ratio=decrease:force_divisible_by=2", maxSize, maxSize)
+}
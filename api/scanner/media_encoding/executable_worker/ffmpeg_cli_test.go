@@ -22,11 +22,11 @@ func TestFfmpegNotExist(t *testing.T) {
 		t.Error("Ffmpeg should not be installed, but is found:", Ffmpeg)
 	}
 
-	if got, want := Ffmpeg.EncodeMp4("input", "output"), ErrNoDependency; !errors.Is(got, want) {
+	if got, want := Ffmpeg.EncodeMp4("input", "output", 1080), ErrNoDependency; !errors.Is(got, want) {
 		t.Errorf("Ffmpge.EncodeMp4() = %v, want: %v", got, want)
 	}
 
-	if got, want := Ffmpeg.EncodeVideoThumbnail("input", "output", nil), ErrNoDependency; !errors.Is(got, want) {
+	if got, want := Ffmpeg.EncodeVideoThumbnail("input", "output", nil, 1024), ErrNoDependency; !errors.Is(got, want) {
 		t.Errorf("Ffmpge.EncodeMp4() = %v, want: %v", got, want)
 	}
 }
@@ -45,11 +45,11 @@ func TestFfmpegVersionFail(t *testing.T) {
 		t.Error("Ffmpeg should not be installed, but is found:", Ffmpeg)
 	}
 
-	if got, want := Ffmpeg.EncodeMp4("input", "output"), ErrNoDependency; !errors.Is(got, want) {
+	if got, want := Ffmpeg.EncodeMp4("input", "output", 1080), ErrNoDependency; !errors.Is(got, want) {
 		t.Errorf("Ffmpge.EncodeMp4() = %v, want: %v", got, want)
 	}
 
-	if got, want := Ffmpeg.EncodeVideoThumbnail("input", "output", nil), ErrNoDependency; !errors.Is(got, want) {
+	if got, want := Ffmpeg.EncodeVideoThumbnail("input", "output", nil, 1024), ErrNoDependency; !errors.Is(got, want) {
 		t.Errorf("Ffmpge.EncodeMp4() = %v, want: %v", got, want)
 	}
 }
@@ -68,11 +68,11 @@ func TestFfmpegIgnore(t *testing.T) {
 		t.Error("Ffmpeg should be ignored (as it is disabled), but is initialized:", Ffmpeg)
 	}
 
-	if got, want := Ffmpeg.EncodeMp4("input", "output"), ErrDisabledFunction; !errors.Is(got, want) {
+	if got, want := Ffmpeg.EncodeMp4("input", "output", 1080), ErrDisabledFunction; !errors.Is(got, want) {
 		t.Errorf("Ffmpge.EncodeMp4() = %v, want: %v", got, want)
 	}
 
-	if got, want := Ffmpeg.EncodeVideoThumbnail("input", "output", nil), ErrDisabledFunction; !errors.Is(got, want) {
+	if got, want := Ffmpeg.EncodeVideoThumbnail("input", "output", nil, 1024), ErrDisabledFunction; !errors.Is(got, want) {
 		t.Errorf("Ffmpge.EncodeMp4() = %v, want: %v", got, want)
 	}
 }
@@ -89,7 +89,7 @@ func TestFfmpeg(t *testing.T) {
 	t.Run("EncodeMp4Failed", func(t *testing.T) {
 		t.Setenv("FAIL_WITH", "expect failure")
 
-		err := Ffmpeg.EncodeMp4("input", "output")
+		err := Ffmpeg.EncodeMp4("input", "output", 1080)
 		if err == nil {
 			t.Fatalf("Ffmpeg.EncodeMp4(...) = nil, should be an error.")
 		}
@@ -99,7 +99,7 @@ func TestFfmpeg(t *testing.T) {
 	})
 
 	t.Run("EncodeMp4Succeeded", func(t *testing.T) {
-		err := Ffmpeg.EncodeMp4("input", "output")
+		err := Ffmpeg.EncodeMp4("input", "output", 1080)
 		if err != nil {
 			t.Fatalf("Ffmpeg.EncodeMp4(...) = %v, should be nil.", err)
 		}
@@ -113,7 +113,7 @@ func TestFfmpeg(t *testing.T) {
 	t.Run("EncodeVideoThumbnailMp4Failed", func(t *testing.T) {
 		t.Setenv("FAIL_WITH", "expect failure")
 
-		err := Ffmpeg.EncodeVideoThumbnail("input", "output", probeData)
+		err := Ffmpeg.EncodeVideoThumbnail("input", "output", probeData, 1024)
 		if err == nil {
 			t.Fatalf("Ffmpeg.EncodeVideoThumbnail(...) = nil, should be an error.")
 		}
@@ -123,11 +123,49 @@ func TestFfmpeg(t *testing.T) {
 	})
 
 	t.Run("EncodeVideoThumbnailSucceeded", func(t *testing.T) {
-		err := Ffmpeg.EncodeVideoThumbnail("input", "output", probeData)
+		err := Ffmpeg.EncodeVideoThumbnail("input", "output", probeData, 1024)
 		if err != nil {
 			t.Fatalf("Ffmpeg.EncodeVideoThumbnail(...) = %v, should be nil.", err)
 		}
 	})
+
+	t.Run("EncodeVideoPreviewFailed", func(t *testing.T) {
+		t.Setenv("FAIL_WITH", "expect failure")
+
+		err := Ffmpeg.EncodeVideoPreview("input", "output", probeData, 320, 4, 1.5)
+		if err == nil {
+			t.Fatalf("Ffmpeg.EncodeVideoPreview(...) = nil, should be an error.")
+		}
+		if got, want := err.Error(), `^encoding video preview with ".*/test_data/mock_bin/ffmpeg" \[-i input -an -vcodec h264 -vf select='lt\(mod\(t,2.500\),1.500\)',setpts=N/FRAME_RATE/TB,scale=.* -t 6.000 .* output\] error: .*$`; !regexp.MustCompile(want).MatchString(got) {
+			t.Errorf("Ffmpeg.EncodeVideoPreview(...) = %q, should be as reg pattern %q", got, want)
+		}
+	})
+
+	t.Run("EncodeVideoPreviewSucceeded", func(t *testing.T) {
+		err := Ffmpeg.EncodeVideoPreview("input", "output", probeData, 320, 4, 1.5)
+		if err != nil {
+			t.Fatalf("Ffmpeg.EncodeVideoPreview(...) = %v, should be nil.", err)
+		}
+	})
+
+	t.Run("EncodeHLSVariantFailed", func(t *testing.T) {
+		t.Setenv("FAIL_WITH", "expect failure")
+
+		err := Ffmpeg.EncodeHLSVariant("input", "720p.m3u8", "720p_%05d.ts", 1280, 2800, 128)
+		if err == nil {
+			t.Fatalf("Ffmpeg.EncodeHLSVariant(...) = nil, should be an error.")
+		}
+		if got, want := err.Error(), `^encoding HLS variant with ".*/test_data/mock_bin/ffmpeg" \[-i input -vcodec h264 .* -b:v 2800k .* -f hls .* -hls_segment_filename 720p_%05d.ts 720p.m3u8\] error: .*$`; !regexp.MustCompile(want).MatchString(got) {
+			t.Errorf("Ffmpeg.EncodeHLSVariant(...) = %q, should be as reg pattern %q", got, want)
+		}
+	})
+
+	t.Run("EncodeHLSVariantSucceeded", func(t *testing.T) {
+		err := Ffmpeg.EncodeHLSVariant("input", "720p.m3u8", "720p_%05d.ts", 1280, 2800, 128)
+		if err != nil {
+			t.Fatalf("Ffmpeg.EncodeHLSVariant(...) = %v, should be nil.", err)
+		}
+	})
 }
 
 func TestFfmpegWithHWAcc(t *testing.T) {
@@ -138,7 +176,7 @@ func TestFfmpegWithHWAcc(t *testing.T) {
 
 	t.Setenv("FAIL_WITH", "expect failure")
 
-	err := Ffmpeg.EncodeMp4("input", "output")
+	err := Ffmpeg.EncodeMp4("input", "output", 1080)
 	if err == nil {
 		t.Fatalf("Ffmpeg.EncodeMp4(...) = nil, should be an error.")
 	}
@@ -155,7 +193,7 @@ func TestFfmpegWithCustomCodec(t *testing.T) {
 
 	t.Setenv("FAIL_WITH", "expect failure")
 
-	err := Ffmpeg.EncodeMp4("input", "output")
+	err := Ffmpeg.EncodeMp4("input", "output", 1080)
 	if err == nil {
 		t.Fatalf("Ffmpeg.EncodeMp4(...) = nil, should be an error.")
 	}
@@ -33,44 +33,34 @@ func (cli *MagickWand) IsInstalled() bool {
 }
 
 func (cli *MagickWand) EncodeJpeg(inputPath string, outputPath string, jpegQuality uint) error {
-	wand, err := cli.createWandFromFile(inputPath)
-	if err != nil {
-		return err
-	}
-	defer wand.Destroy()
-
-	if err := wand.SetFormat("JPEG"); err != nil {
-		return fmt.Errorf("ImagickWand set JPEG format for %q error: %w", inputPath, err)
-	}
-
-	if err := wand.SetImageCompressionQuality(jpegQuality); err != nil {
-		return fmt.Errorf("ImagickWand set JPEG quality %d for %q error: %w", jpegQuality, inputPath, err)
-	}
-
-	if err := wand.WriteImage(outputPath); err != nil {
-		return fmt.Errorf("ImagickWand write %q error: %w", outputPath, err)
-	}
+	return cli.EncodeImage(inputPath, outputPath, "JPEG", 0, 0, jpegQuality)
+}
 
-	return nil
+func (cli *MagickWand) GenerateThumbnail(inputPath string, outputPath string, width, height uint, jpegQuality uint) error {
+	return cli.EncodeImage(inputPath, outputPath, "JPEG", width, height, jpegQuality)
 }
 
-func (cli *MagickWand) GenerateThumbnail(inputPath string, outputPath string, width, height uint) error {
+// EncodeImage encodes `inputPath` in the given ImageMagick format, like JPEG, WEBP or AVIF, and stores it as `outputPath`.
+// The image is scaled to `width` x `height`, unless they are 0.
+func (cli *MagickWand) EncodeImage(inputPath string, outputPath string, format string, width, height uint, quality uint) error {
 	wand, err := cli.createWandFromFile(inputPath)
 	if err != nil {
 		return err
 	}
 	defer wand.Destroy()
 
-	if err := wand.ThumbnailImage(width, height); err != nil {
-		return fmt.Errorf("ImagickWand generate thumbnail for %q error: %w", inputPath, err)
+	if width > 0 && height > 0 {
+		if err := wand.ThumbnailImage(width, height); err != nil {
+			return fmt.Errorf("ImagickWand generate thumbnail for %q error: %w", inputPath, err)
+		}
 	}
 
-	if err := wand.SetFormat("JPEG"); err != nil {
-		return fmt.Errorf("ImagickWand set JPEG format for %q error: %w", inputPath, err)
+	if err := wand.SetFormat(format); err != nil {
+		return fmt.Errorf("ImagickWand set %s format for %q error: %w", format, inputPath, err)
 	}
 
-	if err := wand.SetImageCompressionQuality(70); err != nil {
-		return fmt.Errorf("ImagickWand set JPEG quality %d for %q error: %w", 70, inputPath, err)
+	if err := wand.SetImageCompressionQuality(quality); err != nil {
+		return fmt.Errorf("ImagickWand set %s quality %d for %q error: %w", format, quality, inputPath, err)
 	}
 
 	if err := wand.WriteImage(outputPath); err != nil {
@@ -0,0 +1,92 @@
+package media_encoding
+
+import (
+	"path"
+	"strings"
+	"sync/atomic"
+
+	"github.com/photoview/photoview/api/log"
+	"github.com/photoview/photoview/api/scanner/media_encoding/executable_worker"
+	"github.com/photoview/photoview/api/utils"
+)
+
+// ImageFormat is a modern image format that generated JPEGs are additionally encoded to,
+// it is served instead of the JPEG to browsers that support it
+type ImageFormat struct {
+	Name         string
+	Extension    string
+	ContentType  string
+	MagickFormat string
+}
+
+// SupportedImageFormats lists the alternative image formats, in order of preference when serving images
+var SupportedImageFormats = []ImageFormat{
+	{Name: "avif", Extension: ".avif", ContentType: "image/avif", MagickFormat: "AVIF"},
+	{Name: "webp", Extension: ".webp", ContentType: "image/webp", MagickFormat: "WEBP"},
+}
+
+// enabledImageFormats are the image formats read by InitializeImageFormats
+var enabledImageFormats atomic.Pointer[[]ImageFormat]
+
+// InitializeImageFormats reads the alternative image formats enabled by PHOTOVIEW_IMAGE_FORMATS.
+// It is called at startup, so the variable is only read and reported once.
+func InitializeImageFormats() {
+	formats := make([]ImageFormat, 0)
+
+	value := strings.ToLower(utils.EnvImageFormats.GetValue())
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || name == "jpeg" || name == "jpg" {
+			continue
+		}
+
+		found := false
+		for _, format := range SupportedImageFormats {
+			if format.Name == name {
+				formats = append(formats, format)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			log.Warn(nil, "Unsupported image format in PHOTOVIEW_IMAGE_FORMATS, ignoring it", "format", name)
+		}
+	}
+
+	enabledImageFormats.Store(&formats)
+}
+
+// ImageFormats returns the enabled alternative image formats,
+// they are initialized on first use if InitializeImageFormats wasn't called
+func ImageFormats() []ImageFormat {
+	if formats := enabledImageFormats.Load(); formats != nil {
+		return *formats
+	}
+
+	InitializeImageFormats()
+	return *enabledImageFormats.Load()
+}
+
+// AlternativeImagePath returns the path of the image in the given format, next to the generated JPEG
+func AlternativeImagePath(jpegPath string, format ImageFormat) string {
+	return strings.TrimSuffix(jpegPath, path.Ext(jpegPath)) + format.Extension
+}
+
+// encodeAlternativeFormats encodes `inputPath` to all enabled image formats, next to the generated JPEG at `jpegPath`.
+// The JPEG is always kept as fallback, so a format that fails to encode is logged and skipped.
+func encodeAlternativeFormats(inputPath string, jpegPath string, size Dimension, quality uint) {
+	for _, format := range ImageFormats() {
+		outputPath := AlternativeImagePath(jpegPath, format)
+
+		err := executable_worker.Magick.EncodeImage(inputPath, outputPath, format.MagickFormat, uint(size.Width), uint(size.Height), quality)
+		if err != nil {
+			log.Warn(nil, "Failed to encode alternative image format", "format", format.Name, "path", outputPath, "error", err)
+		}
+	}
+}
+
+// EncodeAlternativeFormats encodes an already generated JPEG to all enabled image formats, keeping its size
+func EncodeAlternativeFormats(jpegPath string, quality uint) {
+	encodeAlternativeFormats(jpegPath, jpegPath, Dimension{}, quality)
+}
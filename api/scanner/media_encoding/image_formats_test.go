@@ -0,0 +1,28 @@
+package media_encoding_test
+
+import (
+	"testing"
+
+	"github.com/photoview/photoview/api/scanner/media_encoding"
+	"github.com/photoview/photoview/api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageFormats(t *testing.T) {
+	// The image formats of the environment are restored for the other tests
+	t.Cleanup(media_encoding.InitializeImageFormats)
+
+	t.Setenv(utils.EnvImageFormats.GetName(), "")
+	media_encoding.InitializeImageFormats()
+	assert.Empty(t, media_encoding.ImageFormats())
+
+	t.Setenv(utils.EnvImageFormats.GetName(), "WebP, jpeg, unknown,avif")
+	media_encoding.InitializeImageFormats()
+	formats := media_encoding.ImageFormats()
+	require.Len(t, formats, 2)
+	assert.Equal(t, "webp", formats[0].Name)
+	assert.Equal(t, "avif", formats[1].Name)
+
+	assert.Equal(t, "/cache/1/2/thumbnail_photo.webp", media_encoding.AlternativeImagePath("/cache/1/2/thumbnail_photo.jpg", formats[0]))
+}
@@ -0,0 +1,171 @@
+package media_encoding
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/photoview/photoview/api/log"
+	"github.com/photoview/photoview/api/utils"
+)
+
+// Names of the renditions that configure the built-in media urls, instead of adding a new rendition
+const (
+	ThumbnailRenditionName = "thumbnail"
+	HighResRenditionName   = "highres"
+	VideoRenditionName     = "video"
+)
+
+const defaultRenditionQuality = 70
+
+var renditionNameRegex = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// Rendition is a named size that photos are encoded to in the media cache
+type Rendition struct {
+	Name string
+	// Max width and height in pixels, 0 keeps the original size
+	Size int
+	// JPEG quality from 1 to 100, not used for videos
+	Quality uint
+}
+
+var defaultRenditions = []Rendition{
+	{Name: ThumbnailRenditionName, Size: 1024, Quality: defaultRenditionQuality},
+	{Name: HighResRenditionName, Size: 0, Quality: defaultRenditionQuality},
+	{Name: VideoRenditionName, Size: 1080},
+}
+
+// ParseRenditions parses a comma separated list of renditions in the format `name:size[:quality]`,
+// for example `thumbnail:512,large:2048:85`.
+func ParseRenditions(value string) ([]Rendition, error) {
+	renditions := make([]Rendition, 0)
+	names := make(map[string]bool)
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.Split(item, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("invalid rendition %q, expected name:size[:quality]", item)
+		}
+
+		rendition := Rendition{
+			Name:    strings.ToLower(parts[0]),
+			Quality: defaultRenditionQuality,
+		}
+
+		if !renditionNameRegex.MatchString(rendition.Name) {
+			return nil, fmt.Errorf("invalid rendition name %q", parts[0])
+		}
+
+		if names[rendition.Name] {
+			return nil, fmt.Errorf("duplicate rendition name %q", rendition.Name)
+		}
+		names[rendition.Name] = true
+
+		size, err := strconv.Atoi(parts[1])
+		if err != nil || size < 0 || (size == 0 && rendition.Name != HighResRenditionName) {
+			return nil, fmt.Errorf("invalid size of rendition %q: %q", rendition.Name, parts[1])
+		}
+		rendition.Size = size
+
+		if len(parts) == 3 {
+			quality, err := strconv.Atoi(parts[2])
+			if err != nil || quality < 1 || quality > 100 {
+				return nil, fmt.Errorf("invalid quality of rendition %q: %q", rendition.Name, parts[2])
+			}
+			rendition.Quality = uint(quality)
+		}
+
+		renditions = append(renditions, rendition)
+	}
+
+	return renditions, nil
+}
+
+// configuredRenditions are the renditions parsed by InitializeRenditions
+var configuredRenditions atomic.Pointer[[]Rendition]
+
+// InitializeRenditions parses the renditions configured by PHOTOVIEW_RENDITIONS,
+// the built-in renditions use their default size when they are not configured.
+// It is called at startup, so the variable is only parsed and reported once.
+func InitializeRenditions() {
+	renditions := make([]Rendition, 0)
+
+	if value := utils.EnvRenditions.GetValue(); value != "" {
+		configured, err := ParseRenditions(value)
+		if err != nil {
+			log.Warn(nil, "Invalid PHOTOVIEW_RENDITIONS value, using default renditions", "value", value, "error", err)
+		} else {
+			renditions = configured
+		}
+	}
+
+	for _, builtin := range defaultRenditions {
+		if _, found := findRendition(renditions, builtin.Name); !found {
+			renditions = append(renditions, builtin)
+		}
+	}
+
+	configuredRenditions.Store(&renditions)
+}
+
+// Renditions returns the configured renditions, they are initialized on first use if InitializeRenditions wasn't called
+func Renditions() []Rendition {
+	if renditions := configuredRenditions.Load(); renditions != nil {
+		return *renditions
+	}
+
+	InitializeRenditions()
+	return *configuredRenditions.Load()
+}
+
+func findRendition(renditions []Rendition, name string) (Rendition, bool) {
+	for _, rendition := range renditions {
+		if rendition.Name == name {
+			return rendition, true
+		}
+	}
+
+	return Rendition{}, false
+}
+
+// GetRendition returns the configured rendition with the given name
+func GetRendition(name string) (Rendition, bool) {
+	return findRendition(Renditions(), name)
+}
+
+// ThumbnailRendition returns the size of photo and video thumbnails
+func ThumbnailRendition() Rendition {
+	rendition, _ := GetRendition(ThumbnailRenditionName)
+	return rendition
+}
+
+// HighResRendition returns the size of the high-res JPEG encoded for photos that browsers can't display
+func HighResRendition() Rendition {
+	rendition, _ := GetRendition(HighResRenditionName)
+	return rendition
+}
+
+// VideoRendition returns the size of the web optimized videos
+func VideoRendition() Rendition {
+	rendition, _ := GetRendition(VideoRenditionName)
+	return rendition
+}
+
+// ExtraRenditions returns the configured renditions that are encoded for photos in addition to the built-in ones
+func ExtraRenditions() []Rendition {
+	extra := make([]Rendition, 0)
+	for _, rendition := range Renditions() {
+		if _, builtin := findRendition(defaultRenditions, rendition.Name); !builtin {
+			extra = append(extra, rendition)
+		}
+	}
+
+	return extra
+}
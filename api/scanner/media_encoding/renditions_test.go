@@ -0,0 +1,86 @@
+package media_encoding_test
+
+import (
+	"testing"
+
+	"github.com/photoview/photoview/api/scanner/media_encoding"
+	"github.com/photoview/photoview/api/test_utils"
+	"github.com/photoview/photoview/api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	test_utils.UnitTestRun(m)
+}
+
+func TestParseRenditions(t *testing.T) {
+	renditions, err := media_encoding.ParseRenditions("small:512, Large:2048:85,highres:0:90")
+	require.NoError(t, err)
+	assert.Equal(t, []media_encoding.Rendition{
+		{Name: "small", Size: 512, Quality: 70},
+		{Name: "large", Size: 2048, Quality: 85},
+		{Name: "highres", Size: 0, Quality: 90},
+	}, renditions)
+
+	invalid := []string{
+		"small",
+		"small:512:70:1",
+		"small:-1",
+		"small:0",
+		"small:abc",
+		"small:512:0",
+		"small:512:101",
+		"sm all:512",
+		"small:512,small:1024",
+	}
+
+	for _, value := range invalid {
+		_, err := media_encoding.ParseRenditions(value)
+		assert.Errorf(t, err, "%q should be invalid", value)
+	}
+}
+
+func TestRenditions(t *testing.T) {
+	// The renditions of the environment are restored for the other tests
+	t.Cleanup(media_encoding.InitializeRenditions)
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv(utils.EnvRenditions.GetName(), "")
+		media_encoding.InitializeRenditions()
+
+		assert.Equal(t, 1024, media_encoding.ThumbnailRendition().Size)
+		assert.Equal(t, 0, media_encoding.HighResRendition().Size)
+		assert.Equal(t, 1080, media_encoding.VideoRendition().Size)
+		assert.Empty(t, media_encoding.ExtraRenditions())
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		t.Setenv(utils.EnvRenditions.GetName(), "thumbnail:512:60,video:720,large:2048")
+		media_encoding.InitializeRenditions()
+
+		assert.Equal(t, media_encoding.Rendition{Name: "thumbnail", Size: 512, Quality: 60}, media_encoding.ThumbnailRendition())
+		assert.Equal(t, 0, media_encoding.HighResRendition().Size)
+		assert.Equal(t, 720, media_encoding.VideoRendition().Size)
+		assert.Equal(t, []media_encoding.Rendition{{Name: "large", Size: 2048, Quality: 70}}, media_encoding.ExtraRenditions())
+	})
+
+	t.Run("invalid falls back to defaults", func(t *testing.T) {
+		t.Setenv(utils.EnvRenditions.GetName(), "thumbnail:abc,large:2048")
+		media_encoding.InitializeRenditions()
+
+		assert.Equal(t, 1024, media_encoding.ThumbnailRendition().Size)
+		assert.Empty(t, media_encoding.ExtraRenditions())
+	})
+}
+
+func TestDimensionScaleToFit(t *testing.T) {
+	landscape := media_encoding.Dimension{Width: 4000, Height: 3000}
+	assert.Equal(t, media_encoding.Dimension{Width: 512, Height: 384}, landscape.ScaleToFit(512))
+
+	portrait := media_encoding.Dimension{Width: 3000, Height: 4000}
+	assert.Equal(t, media_encoding.Dimension{Width: 768, Height: 1024}, portrait.ScaleToFit(1024))
+
+	small := media_encoding.Dimension{Width: 300, Height: 200}
+	assert.Equal(t, small, small.ScaleToFit(1024), "images should not be scaled up")
+}
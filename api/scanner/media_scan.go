@@ -2,21 +2,35 @@ package scanner
 
 import (
 	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/log"
 	"github.com/photoview/photoview/api/scanner/media_encoding"
 	"github.com/photoview/photoview/api/scanner/scanner_task"
 	"github.com/photoview/photoview/api/scanner/scanner_tasks"
 	"github.com/pkg/errors"
 )
 
-func scanMedia(ctx scanner_task.TaskContext, media *models.Media, mediaData *media_encoding.EncodeMediaData, mediaIndex int, mediaTotal int) error {
+// scanMedia processes the media, it returns true without processing it if a previous attempt failed and it is in backoff
+func scanMedia(ctx scanner_task.TaskContext, media *models.Media, mediaData *media_encoding.EncodeMediaData, mediaIndex int, mediaTotal int) (bool, error) {
+	processTask := models.MediaScanTaskProcessPhoto
+	if media.Type == models.MediaTypeVideo {
+		processTask = models.MediaScanTaskProcessVideo
+	}
+
+	scanError := ctx.GetMediaScanError(media.ID, processTask)
+	if scanError != nil && scanError.InBackoff() {
+		log.Info(ctx, "Skipping media that previously failed to process", "media_path", media.Path,
+			"attempts", scanError.Attempts, "next_attempt", scanError.NextAttempt)
+		return true, nil
+	}
+
 	newCtx, err := scanner_tasks.Tasks.BeforeProcessMedia(ctx, mediaData)
 	if err != nil {
-		return errors.Wrapf(err, "before process media (%s)", media.Path)
+		return false, errors.Wrapf(err, "before process media (%s)", media.Path)
 	}
 
 	mediaCachePath, err := media.CachePath()
 	if err != nil {
-		return errors.Wrapf(err, "cache directory error (%s)", media.Path)
+		return false, errors.Wrapf(err, "cache directory error (%s)", media.Path)
 	}
 
 	transactionError := newCtx.DatabaseTransaction(func(ctx scanner_task.TaskContext) error {
@@ -33,8 +47,16 @@ func scanMedia(ctx scanner_task.TaskContext, media *models.Media, mediaData *med
 	})
 
 	if transactionError != nil {
-		return errors.Wrap(transactionError, "process media database transaction")
+		// Errors caused by a cancelled job are not the fault of the media file
+		var taskErr *scanner_task.MediaTaskError
+		if errors.As(transactionError, &taskErr) && ctx.Err() == nil {
+			if err := models.RecordMediaScanError(ctx.GetDB(), media, taskErr.Task, taskErr.Err); err != nil {
+				log.Warn(ctx, "Failed to record media scan error", "media_path", media.Path, "error", err)
+			}
+		}
+
+		return false, errors.Wrap(transactionError, "process media database transaction")
 	}
 
-	return nil
+	return false, nil
 }
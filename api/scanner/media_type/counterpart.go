@@ -22,6 +22,24 @@ func FindRawCounterpart(imagePath string) (string, bool) {
 	})
 }
 
+// FindLivePhotoVideo returns the filename if the image `imagePath` is the still of a Live Photo, which has a QuickTime video with the same basename.
+func FindLivePhotoVideo(imagePath string) (string, bool) {
+	return findCounterpart(imagePath, func(filename string) bool {
+		return GetMediaType(filename) == TypeQuickTime
+	})
+}
+
+// FindLivePhotoStill returns the filename if the video `videoPath` is the clip of a Live Photo, which has an image with the same basename.
+func FindLivePhotoStill(videoPath string) (string, bool) {
+	if GetMediaType(videoPath) != TypeQuickTime {
+		return "", false
+	}
+
+	return findCounterpart(videoPath, func(filename string) bool {
+		return GetMediaType(filename).IsImage()
+	})
+}
+
 func findCounterpart(filename string, acceptFn func(filepath string) bool) (string, bool) {
 	ext := path.Ext(filename)
 	filenamePattern := strings.TrimSuffix(filename, ext) + ".*"
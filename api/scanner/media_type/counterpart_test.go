@@ -64,3 +64,59 @@ func TestFindRawCounterpart(t *testing.T) {
 		}
 	}
 }
+
+func TestFindLivePhotoVideo(t *testing.T) {
+	mediaPath := test_utils.PathFromAPIRoot("scanner", "test_media", "real_media")
+
+	tests := []struct {
+		input    string
+		wantFile string
+		wantOk   bool
+	}{
+		{"live_photo.jpg", "live_photo.mov", true},
+		{"jpg_with_file.jpg", "", false},
+		{"standalone_jpg.jpg", "", false},
+	}
+
+	for _, tc := range tests {
+		input := filepath.Join(mediaPath, tc.input)
+		if _, err := os.Stat(input); err != nil {
+			t.Fatalf("input %q doesn't exist: %v", input, err)
+		}
+
+		file, ok := FindLivePhotoVideo(input)
+		got := strings.TrimLeft(strings.TrimPrefix(file, mediaPath), "/")
+
+		if got != tc.wantFile || ok != tc.wantOk {
+			t.Errorf("FindLivePhotoVideo(%q) = (%q, %v), want: (%q, %v)", tc.input, got, ok, tc.wantFile, tc.wantOk)
+		}
+	}
+}
+
+func TestFindLivePhotoStill(t *testing.T) {
+	mediaPath := test_utils.PathFromAPIRoot("scanner", "test_media", "real_media")
+
+	tests := []struct {
+		input    string
+		wantFile string
+		wantOk   bool
+	}{
+		{"live_photo.mov", "live_photo.jpg", true},
+		{"quicktime.mov", "", false},
+		{"mp4.mp4", "", false},
+	}
+
+	for _, tc := range tests {
+		input := filepath.Join(mediaPath, tc.input)
+		if _, err := os.Stat(input); err != nil {
+			t.Fatalf("input %q doesn't exist: %v", input, err)
+		}
+
+		file, ok := FindLivePhotoStill(input)
+		got := strings.TrimLeft(strings.TrimPrefix(file, mediaPath), "/")
+
+		if got != tc.wantFile || ok != tc.wantOk {
+			t.Errorf("FindLivePhotoStill(%q) = (%q, %v), want: (%q, %v)", tc.input, got, ok, tc.wantFile, tc.wantOk)
+		}
+	}
+}
@@ -44,6 +44,9 @@ var (
 	TypeMPEG = mediaType("video/mpeg")
 	TypeOGG  = mediaType("video/ogg")
 	TypeWEBM = mediaType("video/webm")
+
+	// Video format of the clips of Live Photos
+	TypeQuickTime = mediaType("video/quicktime")
 )
 
 var webImageMimetypes = arrayToSet([]MediaType{
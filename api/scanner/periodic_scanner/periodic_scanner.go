@@ -18,7 +18,7 @@ type ScannerQueue interface {
 type RealScannerQueue struct{}
 
 func (r *RealScannerQueue) AddAllToQueue() error {
-	return scanner_queue.AddAllToQueue()
+	return scanner_queue.AddAllToQueue(models.ScannerJobPriorityLow)
 }
 
 type periodicScanner struct {
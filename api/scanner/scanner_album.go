@@ -84,42 +84,78 @@ func ValidRootPath(rootPath string) bool {
 	return true
 }
 
-func ScanAlbum(ctx scanner_task.TaskContext) error {
+// AlbumScanResult contains the counters of a single album scan
+type AlbumScanResult struct {
+	MediaFound     int
+	MediaProcessed int
+	MediaSkipped   int
+	MediaFailed    int
+}
+
+func ScanAlbum(ctx scanner_task.TaskContext) (AlbumScanResult, error) {
+	var result AlbumScanResult
+
 	newCtx, err := scanner_tasks.Tasks.BeforeScanAlbum(ctx)
 	if err != nil {
-		return errors.Wrapf(err, "before scan album (%s)", ctx.GetAlbum().Path)
+		return result, errors.Wrapf(err, "before scan album (%s)", ctx.GetAlbum().Path)
 	}
 	ctx = newCtx
 
+	// The recorded errors are loaded once, instead of looking them up for every media
+	scanErrors, err := models.GetAlbumMediaScanErrors(ctx.GetDB(), ctx.GetAlbum().ID)
+	if err != nil {
+		return result, errors.Wrapf(err, "get media scan errors of album (%s)", ctx.GetAlbum().Path)
+	}
+	ctx = ctx.WithMediaScanErrors(scanErrors)
+
 	// Scan for photos
-	albumMedia, err := findMediaForAlbum(ctx)
+	albumMedia, failedMedia, err := findMediaForAlbum(ctx)
 	if err != nil {
-		return errors.Wrapf(err, "find media for album (%s): %s", ctx.GetAlbum().Path, err)
+		return result, errors.Wrapf(err, "find media for album (%s): %s", ctx.GetAlbum().Path, err)
 	}
+	result.MediaFound = len(albumMedia) + failedMedia
+	result.MediaFailed = failedMedia
 
 	changedMedia := make([]*models.Media, 0)
 	for i, media := range albumMedia {
+		// Stop early if the job was cancelled, instead of failing every remaining media
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
 		mediaData := media_encoding.NewEncodeMediaData(media)
 
-		if err := scanMedia(ctx, media, &mediaData, i, len(albumMedia)); err != nil {
+		skipped, err := scanMedia(ctx, media, &mediaData, i, len(albumMedia))
+		if err != nil {
 			scanner_utils.ScannerError(ctx, "Error scanning media for album (%d) file (%s): %s\n", ctx.GetAlbum().ID, media.Path, err)
+			result.MediaFailed++
+			continue
 		}
+		if skipped {
+			result.MediaSkipped++
+			continue
+		}
+
+		result.MediaProcessed++
 	}
 
 	if err := scanner_tasks.Tasks.AfterScanAlbum(ctx, changedMedia, albumMedia); err != nil {
-		return errors.Wrap(err, "after scan album")
+		return result, errors.Wrap(err, "after scan album")
 	}
 
-	return nil
+	return result, nil
 }
 
-func findMediaForAlbum(ctx scanner_task.TaskContext) ([]*models.Media, error) {
+// findMediaForAlbum returns the media found in the album directory,
+// along with the number of media files that could not be saved to the database
+func findMediaForAlbum(ctx scanner_task.TaskContext) ([]*models.Media, int, error) {
 
 	albumMedia := make([]*models.Media, 0)
+	failedMedia := 0
 
 	dirContent, err := os.ReadDir(ctx.GetAlbum().Path)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	for _, item := range dirContent {
@@ -135,11 +171,11 @@ func findMediaForAlbum(ctx scanner_task.TaskContext) ([]*models.Media, error) {
 		if !item.IsDir() && !isDirSymlink && ctx.GetCache().IsPathMedia(mediaPath) {
 			itemInfo, err := item.Info()
 			if err != nil {
-				return nil, err
+				return nil, 0, err
 			}
 			skip, err := scanner_tasks.Tasks.MediaFound(ctx, itemInfo, mediaPath)
 			if err != nil {
-				return nil, err
+				return nil, 0, err
 			}
 			if skip {
 				continue
@@ -162,13 +198,14 @@ func findMediaForAlbum(ctx scanner_task.TaskContext) ([]*models.Media, error) {
 
 			if err != nil {
 				scanner_utils.ScannerError(ctx, "Error scanning media for album (%d): %s\n", ctx.GetAlbum().ID, err)
+				failedMedia++
 				continue
 			}
 		}
 
 	}
 
-	return albumMedia, nil
+	return albumMedia, failedMedia, nil
 }
 
 func processMedia(ctx scanner_task.TaskContext, mediaData *media_encoding.EncodeMediaData) ([]*models.MediaURL, error) {
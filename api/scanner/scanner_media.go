@@ -33,6 +33,15 @@ func ScanMedia(tx *gorm.DB, mediaPath string, albumId int, cache *scanner_cache.
 
 		if result.RowsAffected > 0 {
 			// log.Printf("Media already scanned: %s\n", mediaPath)
+
+			// Fingerprint media scanned before fingerprints were introduced, this only happens once for every media
+			if media[0].Fingerprint == nil {
+				fingerprint := mediaFingerprintOrEmpty(mediaPath)
+				if err := tx.Model(media[0]).Update("fingerprint", fingerprint).Error; err != nil {
+					return nil, false, errors.Wrap(err, "save media fingerprint")
+				}
+			}
+
 			return media[0], false, nil
 		}
 	}
@@ -57,12 +66,30 @@ func ScanMedia(tx *gorm.DB, mediaPath string, albumId int, cache *scanner_cache.
 		return nil, false, err
 	}
 
+	fingerprint := mediaFingerprintOrEmpty(mediaPath)
+	if fingerprint != "" {
+		// Keep the existing media, if the file was moved or renamed
+		movedMedia, err := findMovedMedia(tx, fingerprint, mediaTypeText)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if movedMedia != nil {
+			if err := moveMedia(tx, movedMedia, mediaPath, albumId); err != nil {
+				return nil, false, err
+			}
+
+			return movedMedia, false, nil
+		}
+	}
+
 	media := models.Media{
-		Title:    mediaName,
-		Path:     mediaPath,
-		AlbumID:  albumId,
-		Type:     mediaTypeText,
-		DateShot: stat.ModTime(),
+		Title:       mediaName,
+		Path:        mediaPath,
+		AlbumID:     albumId,
+		Type:        mediaTypeText,
+		DateShot:    stat.ModTime(),
+		Fingerprint: &fingerprint,
 	}
 
 	if err := tx.Create(&media).Error; err != nil {
@@ -85,7 +112,7 @@ func ProcessSingleMedia(ctx context.Context, db *gorm.DB, media *models.Media) e
 	mediaData := media_encoding.NewEncodeMediaData(media)
 
 	taskContext := scanner_task.NewTaskContext(ctx, db, &album, albumCache)
-	if err := scanMedia(taskContext, media, &mediaData, 0, 1); err != nil {
+	if _, err := scanMedia(taskContext, media, &mediaData, 0, 1); err != nil {
 		return errors.Wrap(err, "single media scan")
 	}
 
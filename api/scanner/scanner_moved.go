@@ -0,0 +1,196 @@
+package scanner
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/scanner/scanner_cache"
+	"github.com/photoview/photoview/api/scanner/scanner_utils"
+	"github.com/photoview/photoview/api/utils"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// Number of bytes read from the start and the end of a file to compute its fingerprint
+const fingerprintChunkSize = 64 * 1024
+
+// Max number of media files in a new directory, that are fingerprinted to check if the directory was moved
+const maxMovedAlbumSamples = 10
+
+// MediaFingerprint computes a fast fingerprint of the content of a media file.
+// Only the size and the first and last chunk of the file are read, so it is cheap even for large videos,
+// and stays the same when the file is renamed or moved.
+func MediaFingerprint(mediaPath string) (string, error) {
+	file, err := os.Open(mediaPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "open media file for fingerprint (%s)", mediaPath)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return "", errors.Wrapf(err, "stat media file for fingerprint (%s)", mediaPath)
+	}
+	size := stat.Size()
+
+	hash := md5.New()
+	if _, err := io.Copy(hash, io.NewSectionReader(file, 0, min(size, fingerprintChunkSize))); err != nil {
+		return "", errors.Wrapf(err, "read media file for fingerprint (%s)", mediaPath)
+	}
+
+	if size > fingerprintChunkSize {
+		lastChunkStart := max(fingerprintChunkSize, size-fingerprintChunkSize)
+		if _, err := io.Copy(hash, io.NewSectionReader(file, lastChunkStart, size-lastChunkStart)); err != nil {
+			return "", errors.Wrapf(err, "read media file for fingerprint (%s)", mediaPath)
+		}
+	}
+
+	return fmt.Sprintf("%x-%d", hash.Sum(nil), size), nil
+}
+
+// mediaFingerprintOrEmpty returns the fingerprint of the media file, or an empty string if the file could not be read.
+// The empty fingerprint is saved, so unreadable files are not read again on every scan.
+func mediaFingerprintOrEmpty(mediaPath string) string {
+	fingerprint, err := MediaFingerprint(mediaPath)
+	if err != nil {
+		log.Printf("Failed to compute fingerprint: %s\n", err)
+		return ""
+	}
+
+	return fingerprint
+}
+
+// findMovedMedia returns a media with the given fingerprint whose file no longer exists, or nil if there is none.
+func findMovedMedia(tx *gorm.DB, fingerprint string, mediaType models.MediaType) (*models.Media, error) {
+	var candidates []*models.Media
+	if err := tx.Where("fingerprint = ? AND type = ?", fingerprint, mediaType).Find(&candidates).Error; err != nil {
+		return nil, errors.Wrap(err, "find media with same fingerprint")
+	}
+
+	for _, candidate := range candidates {
+		if !scanner_utils.FileExists(candidate.Path) {
+			return candidate, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// moveMedia points an existing media at its new path, and moves its cached files if the album changed.
+// This keeps everything related to the media, like favorites, faces and share tokens.
+func moveMedia(tx *gorm.DB, media *models.Media, mediaPath string, albumID int) error {
+	log.Printf("Media moved from %s to %s\n", media.Path, mediaPath)
+
+	oldAlbumID := media.AlbumID
+
+	media.Title = path.Base(mediaPath)
+	media.Path = mediaPath
+	media.AlbumID = albumID
+
+	if err := tx.Save(media).Error; err != nil {
+		return errors.Wrap(err, "update path of moved media")
+	}
+
+	if oldAlbumID == albumID {
+		return nil
+	}
+
+	oldCachePath := path.Join(utils.MediaCachePath(), strconv.Itoa(oldAlbumID), strconv.Itoa(media.ID))
+	if _, err := os.Stat(oldCachePath); os.IsNotExist(err) {
+		return nil
+	}
+
+	newAlbumCachePath := path.Join(utils.MediaCachePath(), strconv.Itoa(albumID))
+	if err := os.MkdirAll(newAlbumCachePath, os.ModePerm); err != nil {
+		return errors.Wrap(err, "create album cache directory for moved media")
+	}
+
+	if err := os.Rename(oldCachePath, path.Join(newAlbumCachePath, strconv.Itoa(media.ID))); err != nil {
+		return errors.Wrap(err, "move cache directory of moved media")
+	}
+
+	return nil
+}
+
+// findMovedAlbum returns an album whose directory no longer exists, and that contains media
+// with the same content as the media in the given new directory, or nil if there is none.
+func findMovedAlbum(tx *gorm.DB, albumPath string, albumCache *scanner_cache.AlbumScannerCache) (*models.Album, error) {
+	dirContent, err := os.ReadDir(albumPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read directory (%s)", albumPath)
+	}
+
+	fingerprints := make([]string, 0, maxMovedAlbumSamples)
+	for _, item := range dirContent {
+		if len(fingerprints) >= maxMovedAlbumSamples {
+			break
+		}
+
+		mediaPath := path.Join(albumPath, item.Name())
+		if item.IsDir() || !albumCache.IsPathMedia(mediaPath) {
+			continue
+		}
+
+		fingerprint, err := MediaFingerprint(mediaPath)
+		if err != nil {
+			log.Printf("Failed to compute fingerprint: %s\n", err)
+			continue
+		}
+		fingerprints = append(fingerprints, fingerprint)
+	}
+
+	if len(fingerprints) == 0 {
+		return nil, nil
+	}
+
+	var candidates []struct {
+		AlbumID int
+		Matches int
+	}
+	if err := tx.Model(&models.Media{}).
+		Select("album_id, COUNT(*) AS matches").
+		Where("fingerprint IN (?)", fingerprints).
+		Group("album_id").
+		Order("matches DESC").
+		Scan(&candidates).Error; err != nil {
+		return nil, errors.Wrap(err, "find albums with media of same fingerprint")
+	}
+
+	for _, candidate := range candidates {
+		var album models.Album
+		if err := tx.First(&album, candidate.AlbumID).Error; err != nil {
+			return nil, errors.Wrap(err, "get album with media of same fingerprint")
+		}
+
+		// The media was copied, not moved
+		if scanner_utils.FileExists(album.Path) {
+			continue
+		}
+
+		return &album, nil
+	}
+
+	return nil, nil
+}
+
+// moveAlbum points an existing album at its new directory, keeping its media, covers and share tokens.
+// The media inside the album are moved as well, when the album is scanned.
+func moveAlbum(tx *gorm.DB, album *models.Album, albumPath string, parentAlbumID *int) error {
+	log.Printf("Album moved from %s to %s\n", album.Path, albumPath)
+
+	album.Title = path.Base(albumPath)
+	album.Path = albumPath
+	album.ParentAlbumID = parentAlbumID
+
+	if err := tx.Save(album).Error; err != nil {
+		return errors.Wrap(err, "update path of moved album")
+	}
+
+	return nil
+}
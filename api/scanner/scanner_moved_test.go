@@ -0,0 +1,188 @@
+package scanner_test
+
+import (
+	"os"
+	"path"
+	"strconv"
+	"testing"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/scanner"
+	"github.com/photoview/photoview/api/scanner/scanner_cache"
+	"github.com/photoview/photoview/api/test_utils"
+	scanner_utils "github.com/photoview/photoview/api/test_utils/scanner"
+	"github.com/photoview/photoview/api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMediaFingerprint(t *testing.T) {
+	dir := t.TempDir()
+
+	photo, err := os.ReadFile("./test_media/real_media/jpeg.jpg")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path.Join(dir, "a.jpg"), photo, 0644))
+	require.NoError(t, os.WriteFile(path.Join(dir, "b.jpg"), photo, 0644))
+	require.NoError(t, os.WriteFile(path.Join(dir, "c.jpg"), append(photo, 0), 0644))
+
+	fingerprintA, err := scanner.MediaFingerprint(path.Join(dir, "a.jpg"))
+	require.NoError(t, err)
+	fingerprintB, err := scanner.MediaFingerprint(path.Join(dir, "b.jpg"))
+	require.NoError(t, err)
+	fingerprintC, err := scanner.MediaFingerprint(path.Join(dir, "c.jpg"))
+	require.NoError(t, err)
+
+	assert.Equal(t, fingerprintA, fingerprintB, "files with the same content should have the same fingerprint")
+	assert.NotEqual(t, fingerprintA, fingerprintC, "files with different content should have different fingerprints")
+}
+
+func TestMovedMedia(t *testing.T) {
+	test_utils.FilesystemTest(t)
+	db := test_utils.DatabaseTest(t)
+
+	photo, err := os.ReadFile("./test_media/real_media/jpeg.jpg")
+	require.NoError(t, err)
+
+	rootPath := t.TempDir()
+	require.NoError(t, os.Mkdir(path.Join(rootPath, "old"), 0755))
+	require.NoError(t, os.WriteFile(path.Join(rootPath, "old", "photo.jpg"), photo, 0644))
+
+	rootAlbum := models.Album{
+		Title: "root",
+		Path:  rootPath,
+	}
+	require.NoError(t, db.Create(&rootAlbum).Error)
+
+	findAlbum := func(albumPath string) *models.Album {
+		t.Helper()
+
+		albums, errs := scanner.FindAlbumsForAlbum(db, &rootAlbum, scanner_cache.MakeAlbumCache())
+		require.Empty(t, errs)
+
+		for _, album := range albums {
+			if album.Path == albumPath {
+				return album
+			}
+		}
+
+		t.Fatalf("album not found: %s", albumPath)
+		return nil
+	}
+
+	oldAlbum := findAlbum(path.Join(rootPath, "old"))
+	media, isNew, err := scanner.ScanMedia(db, path.Join(rootPath, "old", "photo.jpg"), oldAlbum.ID, scanner_cache.MakeAlbumCache())
+	require.NoError(t, err)
+	require.True(t, isNew)
+	require.NotNil(t, media.Fingerprint)
+
+	t.Run("renamed directory keeps the album", func(t *testing.T) {
+		require.NoError(t, os.Rename(path.Join(rootPath, "old"), path.Join(rootPath, "renamed")))
+
+		album := findAlbum(path.Join(rootPath, "renamed"))
+		assert.Equal(t, oldAlbum.ID, album.ID)
+		assert.Equal(t, "renamed", album.Title)
+		assert.Equal(t, rootAlbum.ID, *album.ParentAlbumID)
+
+		movedMedia, isNew, err := scanner.ScanMedia(db, path.Join(rootPath, "renamed", "photo.jpg"), album.ID, scanner_cache.MakeAlbumCache())
+		require.NoError(t, err)
+		assert.False(t, isNew)
+		assert.Equal(t, media.ID, movedMedia.ID)
+		assert.Equal(t, path.Join(rootPath, "renamed", "photo.jpg"), movedMedia.Path)
+	})
+
+	t.Run("renamed file keeps the media", func(t *testing.T) {
+		require.NoError(t, os.Rename(path.Join(rootPath, "renamed", "photo.jpg"), path.Join(rootPath, "renamed", "other.jpg")))
+
+		movedMedia, isNew, err := scanner.ScanMedia(db, path.Join(rootPath, "renamed", "other.jpg"), oldAlbum.ID, scanner_cache.MakeAlbumCache())
+		require.NoError(t, err)
+		assert.False(t, isNew)
+		assert.Equal(t, media.ID, movedMedia.ID)
+		assert.Equal(t, "other.jpg", movedMedia.Title)
+	})
+
+	t.Run("copied file creates new media", func(t *testing.T) {
+		require.NoError(t, os.Mkdir(path.Join(rootPath, "copy"), 0755))
+		require.NoError(t, os.WriteFile(path.Join(rootPath, "copy", "other.jpg"), photo, 0644))
+
+		copyAlbum := findAlbum(path.Join(rootPath, "copy"))
+		assert.NotEqual(t, oldAlbum.ID, copyAlbum.ID)
+
+		copiedMedia, isNew, err := scanner.ScanMedia(db, path.Join(rootPath, "copy", "other.jpg"), copyAlbum.ID, scanner_cache.MakeAlbumCache())
+		require.NoError(t, err)
+		assert.True(t, isNew)
+		assert.NotEqual(t, media.ID, copiedMedia.ID)
+	})
+
+	t.Run("file moved to another album moves the cache", func(t *testing.T) {
+		var copyAlbum models.Album
+		require.NoError(t, db.Where("path_hash = ?", models.MD5Hash(path.Join(rootPath, "copy"))).First(&copyAlbum).Error)
+
+		cachePath, err := utils.CachePathForMedia(oldAlbum.ID, media.ID)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path.Join(cachePath, "thumbnail.jpg"), photo, 0644))
+
+		require.NoError(t, os.Rename(path.Join(rootPath, "renamed", "other.jpg"), path.Join(rootPath, "copy", "moved.jpg")))
+
+		movedMedia, isNew, err := scanner.ScanMedia(db, path.Join(rootPath, "copy", "moved.jpg"), copyAlbum.ID, scanner_cache.MakeAlbumCache())
+		require.NoError(t, err)
+		assert.False(t, isNew)
+		assert.Equal(t, media.ID, movedMedia.ID)
+		assert.Equal(t, copyAlbum.ID, movedMedia.AlbumID)
+
+		assert.NoFileExists(t, path.Join(cachePath, "thumbnail.jpg"))
+		assert.FileExists(t, path.Join(utils.MediaCachePath(), strconv.Itoa(copyAlbum.ID), strconv.Itoa(media.ID), "thumbnail.jpg"))
+	})
+}
+
+func TestMovedMediaScannerQueue(t *testing.T) {
+	test_utils.FilesystemTest(t)
+	db := test_utils.DatabaseTest(t)
+
+	photo, err := os.ReadFile("./test_media/real_media/jpeg.jpg")
+	require.NoError(t, err)
+
+	rootPath := t.TempDir()
+	require.NoError(t, os.Mkdir(path.Join(rootPath, "a"), 0755))
+	require.NoError(t, os.Mkdir(path.Join(rootPath, "b"), 0755))
+	require.NoError(t, os.WriteFile(path.Join(rootPath, "a", "photo.jpg"), photo, 0644))
+	require.NoError(t, os.WriteFile(path.Join(rootPath, "a", "removed.jpg"), append(photo, 0), 0644))
+	// Both albums keep media, so they aren't deleted along with their media
+	require.NoError(t, os.WriteFile(path.Join(rootPath, "a", "kept.jpg"), append(photo, 0, 0), 0644))
+	require.NoError(t, os.WriteFile(path.Join(rootPath, "b", "kept.jpg"), append(photo, 0, 0, 0), 0644))
+
+	pass := "1234"
+	user, err := models.RegisterUser(db, "user", &pass, true)
+	require.NoError(t, err)
+
+	rootAlbum := models.Album{
+		Title: "root",
+		Path:  rootPath,
+	}
+	require.NoError(t, db.Create(&rootAlbum).Error)
+	require.NoError(t, db.Model(user).Association("Albums").Append(&rootAlbum))
+
+	scanner_utils.RunScannerOnUser(t, db, user)
+
+	var media models.Media
+	require.NoError(t, db.Where("path_hash = ?", models.MD5Hash(path.Join(rootPath, "a", "photo.jpg"))).First(&media).Error)
+	_, err = user.FavoriteMedia(db, media.ID, true)
+	require.NoError(t, err)
+
+	// The source album is scanned before the destination album, and the missing media must survive it
+	require.NoError(t, os.Rename(path.Join(rootPath, "a", "photo.jpg"), path.Join(rootPath, "b", "photo.jpg")))
+	require.NoError(t, os.Remove(path.Join(rootPath, "a", "removed.jpg")))
+	scanner_utils.RunScannerAll(t, db)
+
+	var movedMedia models.Media
+	require.NoError(t, db.First(&movedMedia, media.ID).Error)
+	assert.Equal(t, path.Join(rootPath, "b", "photo.jpg"), movedMedia.Path)
+
+	var userData models.UserMediaData
+	require.NoError(t, db.Where("user_id = ? AND media_id = ?", user.ID, media.ID).First(&userData).Error)
+	assert.True(t, userData.Favorite, "favorite should be kept")
+
+	var count int64
+	require.NoError(t, db.Model(&models.Media{}).Where("path_hash = ?", models.MD5Hash(path.Join(rootPath, "a", "removed.jpg"))).
+		Count(&count).Error)
+	assert.Zero(t, count, "removed media should be deleted once the scanner is done")
+}
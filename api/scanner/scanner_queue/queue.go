@@ -2,6 +2,7 @@ package scanner_queue
 
 import (
 	"context"
+	goerrors "errors"
 	"fmt"
 	"log"
 	"sync"
@@ -12,6 +13,7 @@ import (
 	"github.com/photoview/photoview/api/scanner"
 	"github.com/photoview/photoview/api/scanner/scanner_cache"
 	"github.com/photoview/photoview/api/scanner/scanner_task"
+	"github.com/photoview/photoview/api/scanner/scanner_tasks/cleanup_tasks"
 	"github.com/photoview/photoview/api/scanner/scanner_utils"
 	"github.com/photoview/photoview/api/utils"
 	"github.com/pkg/errors"
@@ -20,23 +22,81 @@ import (
 
 const globalScannerProgress = "global-scanner-progress"
 
+// scannerJobHistoryRetention is how long finished scanner jobs are kept in the database
+const scannerJobHistoryRetention = 30 * 24 * time.Hour
+
+// ErrJobNotFound is returned when cancelling a job that is neither queued nor running
+var ErrJobNotFound = errors.New("scanner job is not queued or running")
+
+// priorityRank orders the job priorities, jobs with a higher rank are run first
+var priorityRank = map[models.ScannerJobPriority]int{
+	models.ScannerJobPriorityLow:    0,
+	models.ScannerJobPriorityNormal: 1,
+	models.ScannerJobPriorityHigh:   2,
+}
+
 // ScannerJob describes a job on the queue to be run by the scanner over a single album
 type ScannerJob struct {
-	ctx scanner_task.TaskContext
+	ctx      scanner_task.TaskContext
+	priority models.ScannerJobPriority
+	// id of the models.ScannerJob record persisting this job, 0 if the job is not persisted
+	id int
+	// seq is assigned by the queue when the job starts running, it is unique even for jobs that are not persisted
+	seq int
 	// album *models.Album
 	// cache *scanner_cache.AlbumScannerCache
 }
 
 func NewScannerJob(ctx scanner_task.TaskContext) ScannerJob {
 	return ScannerJob{
-		ctx,
+		ctx:      ctx,
+		priority: models.ScannerJobPriorityNormal,
 	}
 }
 
+func newPriorityScannerJob(ctx scanner_task.TaskContext, priority models.ScannerJobPriority) *ScannerJob {
+	job := NewScannerJob(ctx)
+	job.priority = priority
+	return &job
+}
+
 func (job *ScannerJob) Run(db *gorm.DB) {
-	err := scanner.ScanAlbum(job.ctx)
-	if err != nil {
+	job.updateRecord(db, map[string]any{
+		"status":     models.ScannerJobStatusRunning,
+		"started_at": time.Now(),
+	})
+
+	result, err := scanner.ScanAlbum(job.ctx)
+
+	updates := map[string]any{
+		"status":          models.ScannerJobStatusDone,
+		"finished_at":     time.Now(),
+		"media_found":     result.MediaFound,
+		"media_processed": result.MediaProcessed,
+		"media_skipped":   result.MediaSkipped,
+		"media_failed":    result.MediaFailed,
+	}
+
+	if errors.Is(err, context.Canceled) {
+		log.Printf("Scanner job for album (%s) was cancelled\n", job.ctx.GetAlbum().Path)
+		updates["status"] = models.ScannerJobStatusCancelled
+	} else if err != nil {
 		scanner_utils.ScannerError(nil, "Failed to scan album: %v", err)
+		updates["status"] = models.ScannerJobStatusFailed
+		updates["error"] = err.Error()
+	}
+
+	job.updateRecord(db, updates)
+}
+
+// updateRecord updates the persisted record of the job, if it has one
+func (job *ScannerJob) updateRecord(db *gorm.DB, updates map[string]any) {
+	if db == nil || job.id == 0 {
+		return
+	}
+
+	if err := db.Model(&models.ScannerJob{}).Where("id = ?", job.id).Updates(updates).Error; err != nil {
+		log.Printf("Failed to update scanner job (%d): %v\n", job.id, err)
 	}
 }
 
@@ -53,6 +113,10 @@ type ScannerQueue struct {
 	settings    ScannerQueueSettings
 	close_chan  *chan bool
 	running     bool
+	// cancel functions of the running jobs, by job seq
+	cancel_funcs map[int]context.CancelFunc
+	// seq of the last job that was started
+	last_seq int
 }
 
 var global_scanner_queue ScannerQueue
@@ -71,13 +135,18 @@ func InitializeScannerQueue(db *gorm.DB) error {
 	log.Printf("Initializing scanner queue with %d workers", concurrentWorkers)
 
 	global_scanner_queue = ScannerQueue{
-		idle_chan:   make(chan bool, 1),
-		in_progress: make([]ScannerJob, 0),
-		up_next:     make([]ScannerJob, 0),
-		db:          db,
-		settings:    ScannerQueueSettings{max_concurrent_tasks: concurrentWorkers},
-		close_chan:  nil,
-		running:     true,
+		idle_chan:    make(chan bool, 1),
+		in_progress:  make([]ScannerJob, 0),
+		up_next:      make([]ScannerJob, 0),
+		db:           db,
+		settings:     ScannerQueueSettings{max_concurrent_tasks: concurrentWorkers},
+		close_chan:   nil,
+		running:      true,
+		cancel_funcs: make(map[int]context.CancelFunc),
+	}
+
+	if err := global_scanner_queue.resumePendingJobs(); err != nil {
+		log.Printf("Failed to resume pending scanner jobs: %v\n", err)
 	}
 
 	go global_scanner_queue.startBackgroundWorker()
@@ -85,6 +154,105 @@ func InitializeScannerQueue(db *gorm.DB) error {
 	return nil
 }
 
+// pruneJobHistory removes finished jobs older than scannerJobHistoryRetention
+func (queue *ScannerQueue) pruneJobHistory() error {
+	historyLimit := time.Now().Add(-scannerJobHistoryRetention)
+	if err := queue.db.
+		Where("status IN (?)", []models.ScannerJobStatus{
+			models.ScannerJobStatusDone, models.ScannerJobStatusFailed, models.ScannerJobStatusCancelled,
+		}).
+		Where("created_at < ?", historyLimit).
+		Delete(&models.ScannerJob{}).Error; err != nil {
+		return errors.Wrap(err, "delete old scanner jobs")
+	}
+
+	return nil
+}
+
+// cleanUpIdleQueue runs once all jobs have finished. Missing media are deleted once all albums are scanned,
+// as they might have been moved to one of them. Keywords removed from files or left by deleted media are deleted,
+// and finished jobs older than scannerJobHistoryRetention are removed.
+func (queue *ScannerQueue) cleanUpIdleQueue() {
+	for _, err := range cleanup_tasks.DeleteMissingMedia(queue.db) {
+		scanner_utils.ScannerError(nil, "delete missing media: %s", err)
+	}
+
+	if err := models.DeleteOrphanKeywords(queue.db); err != nil {
+		scanner_utils.ScannerError(nil, "clean up keywords: %s", err)
+	}
+
+	if err := queue.pruneJobHistory(); err != nil {
+		log.Printf("Failed to prune scanner job history: %v\n", err)
+	}
+}
+
+// resumePendingJobs adds the jobs that were queued or running when the server last stopped back on the queue,
+// and removes finished jobs older than scannerJobHistoryRetention
+func (queue *ScannerQueue) resumePendingJobs() error {
+	if err := queue.pruneJobHistory(); err != nil {
+		return err
+	}
+
+	var pendingJobs []*models.ScannerJob
+	if err := queue.db.Preload("Album").
+		Where("status IN (?)", []models.ScannerJobStatus{models.ScannerJobStatusQueued, models.ScannerJobStatusRunning}).
+		Order("id ASC").
+		Find(&pendingJobs).Error; err != nil {
+		return errors.Wrap(err, "get pending scanner jobs from database")
+	}
+
+	if len(pendingJobs) > 0 {
+		log.Printf("Resuming %d pending scanner jobs", len(pendingJobs))
+	}
+
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	for _, pendingJob := range pendingJobs {
+		job := ScannerJob{id: pendingJob.ID, priority: pendingJob.Priority}
+
+		if pendingJob.Album == nil {
+			job.updateRecord(queue.db, map[string]any{
+				"status":      models.ScannerJobStatusFailed,
+				"finished_at": time.Now(),
+				"error":       "album no longer exists",
+			})
+			continue
+		}
+
+		albumCache := scanner_cache.MakeAlbumCache()
+		if err := scanner.LoadAlbumIgnore(queue.db, pendingJob.Album, albumCache); err != nil {
+			job.updateRecord(queue.db, map[string]any{
+				"status":      models.ScannerJobStatusFailed,
+				"finished_at": time.Now(),
+				"error":       errors.Wrap(err, "load ignore rules for album").Error(),
+			})
+			continue
+		}
+
+		job.ctx = scanner_task.NewTaskContext(context.Background(), queue.db, pendingJob.Album, albumCache)
+
+		if onQueue, _ := queue.jobOnQueue(&job); onQueue {
+			job.updateRecord(queue.db, map[string]any{
+				"status":      models.ScannerJobStatusFailed,
+				"finished_at": time.Now(),
+				"error":       "album was already queued by another job",
+			})
+			continue
+		}
+
+		job.updateRecord(queue.db, map[string]any{
+			"status":     models.ScannerJobStatusQueued,
+			"started_at": nil,
+		})
+		queue.up_next = append(queue.up_next, job)
+	}
+
+	queue.notify()
+
+	return nil
+}
+
 func CloseScannerQueue() {
 	global_scanner_queue.CloseBackgroundWorker()
 }
@@ -141,8 +309,15 @@ func (queue *ScannerQueue) processQueue(notifyThrottle *utils.Throttle) {
 
 	for len(queue.in_progress) < maxJobs && len(queue.up_next) > 0 {
 		log.Println("Queue starting job")
-		nextJob := queue.up_next[0]
-		queue.up_next = queue.up_next[1:]
+		nextJob := queue.popNextJob()
+
+		queue.last_seq++
+		nextJob.seq = queue.last_seq
+
+		runCtx, cancel := nextJob.ctx.WithCancel()
+		nextJob.ctx = runCtx
+		queue.cancel_funcs[nextJob.seq] = cancel
+
 		queue.in_progress = append(queue.in_progress, nextJob)
 		jobNum := len(queue.in_progress)
 
@@ -153,6 +328,8 @@ func (queue *ScannerQueue) processQueue(notifyThrottle *utils.Throttle) {
 
 			// Delete finished job from queue
 			queue.mutex.Lock()
+			delete(queue.cancel_funcs, nextJob.seq)
+			cancel()
 			for i, x := range queue.in_progress {
 				if x == nextJob {
 					queue.in_progress[i] = queue.in_progress[len(queue.in_progress)-1]
@@ -160,8 +337,15 @@ func (queue *ScannerQueue) processQueue(notifyThrottle *utils.Throttle) {
 					break
 				}
 			}
+
+			idle := len(queue.in_progress) == 0 && len(queue.up_next) == 0
 			queue.mutex.Unlock()
 
+			// The clean up scans whole tables, so it runs without the lock to keep the queue usable meanwhile
+			if idle && queue.db != nil {
+				queue.cleanUpIdleQueue()
+			}
+
 			queue.notify()
 		}()
 	}
@@ -191,6 +375,22 @@ func (queue *ScannerQueue) processQueue(notifyThrottle *utils.Throttle) {
 	}
 }
 
+// popNextJob removes and returns the first job with the highest priority from up_next.
+// Queue should be locked prior to calling this function
+func (queue *ScannerQueue) popNextJob() ScannerJob {
+	nextIndex := 0
+	for i, job := range queue.up_next {
+		if priorityRank[job.priority] > priorityRank[queue.up_next[nextIndex].priority] {
+			nextIndex = i
+		}
+	}
+
+	nextJob := queue.up_next[nextIndex]
+	queue.up_next = append(queue.up_next[:nextIndex], queue.up_next[nextIndex+1:]...)
+
+	return nextJob
+}
+
 // Notifies the queue that the jobs has changed
 func (queue *ScannerQueue) notify() bool {
 	select {
@@ -201,7 +401,9 @@ func (queue *ScannerQueue) notify() bool {
 	}
 }
 
-func AddAllToQueue() error {
+// AddAllToQueue finds the albums of all users and adds them to the scanner queue with the given priority.
+// Function does not block.
+func AddAllToQueue(priority models.ScannerJobPriority) error {
 
 	var users []*models.User
 	result := global_scanner_queue.db.Find(&users)
@@ -209,40 +411,114 @@ func AddAllToQueue() error {
 		return errors.Wrap(result.Error, "get all users from database")
 	}
 
+	// The albums of the other users are still queued when one of the users fails
+	queueErrors := make([]error, 0)
 	for _, user := range users {
-		if err := AddUserToQueue(user); err != nil {
-			return errors.Wrapf(err, "failed to add user for scanning (%d)", user.ID)
+		if err := AddUserToQueue(user, priority); err != nil {
+			queueErrors = append(queueErrors, errors.Wrapf(err, "failed to add user for scanning (%d)", user.ID))
 		}
 	}
 
-	return nil
+	return goerrors.Join(queueErrors...)
 }
 
-// AddUserToQueue finds all root albums owned by the given user and adds them to the scanner queue.
+// AddUserToQueue finds all root albums owned by the given user and adds them to the scanner queue with the given priority.
 // Function does not block.
-func AddUserToQueue(user *models.User) error {
+func AddUserToQueue(user *models.User, priority models.ScannerJobPriority) error {
 	albumCache := scanner_cache.MakeAlbumCache()
 	albums, album_errors := scanner.FindAlbumsForUser(global_scanner_queue.db, user, albumCache)
+
+	// The albums that were found are still queued when some of the albums couldn't be read
+	queueErrors := make([]error, 0, len(album_errors))
 	for _, err := range album_errors {
-		return errors.Wrapf(err, "find albums for user (user_id: %d)", user.ID)
+		queueErrors = append(queueErrors, errors.Wrapf(err, "find albums for user (user_id: %d)", user.ID))
 	}
 
 	global_scanner_queue.mutex.Lock()
 	for _, album := range albums {
-		global_scanner_queue.addJob(&ScannerJob{
-			ctx: scanner_task.NewTaskContext(context.Background(), global_scanner_queue.db, album, albumCache),
-		})
+		err := global_scanner_queue.addJob(newPriorityScannerJob(
+			scanner_task.NewTaskContext(context.Background(), global_scanner_queue.db, album, albumCache),
+			priority,
+		))
+		if err != nil {
+			queueErrors = append(queueErrors, err)
+		}
 	}
 	global_scanner_queue.mutex.Unlock()
 
-	return nil
+	return goerrors.Join(queueErrors...)
+}
+
+// AddAlbumToQueue adds a single album to the scanner queue with the given priority, without looking for new sub-albums.
+// Function does not block.
+func AddAlbumToQueue(album *models.Album, priority models.ScannerJobPriority) error {
+	albumCache := scanner_cache.MakeAlbumCache()
+	if err := scanner.LoadAlbumIgnore(global_scanner_queue.db, album, albumCache); err != nil {
+		return errors.Wrapf(err, "load ignore rules for album (album_id: %d)", album.ID)
+	}
+
+	global_scanner_queue.mutex.Lock()
+	defer global_scanner_queue.mutex.Unlock()
+
+	return global_scanner_queue.addJob(newPriorityScannerJob(
+		scanner_task.NewTaskContext(context.Background(), global_scanner_queue.db, album, albumCache),
+		priority,
+	))
+}
+
+// AddAlbumTreeToQueue finds the album and all of its sub-albums on the filesystem
+// and adds them to the scanner queue with the given priority.
+// Sub-albums that no longer exist on the filesystem are deleted.
+// Function does not block.
+func AddAlbumTreeToQueue(album *models.Album, priority models.ScannerJobPriority) error {
+	albumCache := scanner_cache.MakeAlbumCache()
+	albums, album_errors := scanner.FindAlbumsForAlbum(global_scanner_queue.db, album, albumCache)
+
+	// The albums that were found are still queued when parts of the tree couldn't be read
+	queueErrors := make([]error, 0, len(album_errors))
+	for _, err := range album_errors {
+		queueErrors = append(queueErrors, errors.Wrapf(err, "find sub-albums for album (album_id: %d)", album.ID))
+	}
+
+	global_scanner_queue.mutex.Lock()
+	for _, album := range albums {
+		err := global_scanner_queue.addJob(newPriorityScannerJob(
+			scanner_task.NewTaskContext(context.Background(), global_scanner_queue.db, album, albumCache),
+			priority,
+		))
+		if err != nil {
+			queueErrors = append(queueErrors, err)
+		}
+	}
+	global_scanner_queue.mutex.Unlock()
+
+	return goerrors.Join(queueErrors...)
 }
 
 // Queue should be locked prior to calling this function
 func (queue *ScannerQueue) addJob(job *ScannerJob) error {
 	if exists, err := queue.jobOnQueue(job); exists || err != nil {
+		if err == nil {
+			queue.raisePriority(job)
+		}
 		return err
 	}
+
+	if queue.db != nil {
+		album := job.ctx.GetAlbum()
+		record := models.ScannerJob{
+			AlbumID:   &album.ID,
+			AlbumPath: album.Path,
+			Status:    models.ScannerJobStatusQueued,
+			Priority:  job.priority,
+		}
+
+		if err := queue.db.Create(&record).Error; err != nil {
+			return errors.Wrapf(err, "save scanner job for album (album_id: %d)", album.ID)
+		}
+		job.id = record.ID
+	}
+
 	queue.up_next = append(queue.up_next, *job)
 	queue.notify()
 
@@ -262,3 +538,92 @@ func (queue *ScannerQueue) jobOnQueue(job *ScannerJob) (bool, error) {
 
 	return false, nil
 }
+
+// raisePriority raises the priority of the queued job for the same album as the given job,
+// if the given job has a higher priority.
+// Queue should be locked prior to calling this function
+func (queue *ScannerQueue) raisePriority(job *ScannerJob) {
+	for i, queuedJob := range queue.up_next {
+		if queuedJob.ctx.GetAlbum().ID != job.ctx.GetAlbum().ID {
+			continue
+		}
+
+		if priorityRank[job.priority] > priorityRank[queuedJob.priority] {
+			queue.up_next[i].priority = job.priority
+			queuedJob.updateRecord(queue.db, map[string]any{"priority": job.priority})
+		}
+
+		return
+	}
+}
+
+// CancelJob cancels the queued or running scanner job with the given id.
+// A queued job is removed from the queue, a running job is stopped through its task context.
+func CancelJob(jobID int) error {
+	global_scanner_queue.mutex.Lock()
+	defer global_scanner_queue.mutex.Unlock()
+
+	if !global_scanner_queue.cancelJob(jobID) {
+		return ErrJobNotFound
+	}
+
+	return nil
+}
+
+// CancelAllJobs cancels all queued and running scanner jobs, and returns the number of cancelled jobs.
+func CancelAllJobs() int {
+	global_scanner_queue.mutex.Lock()
+	defer global_scanner_queue.mutex.Unlock()
+
+	return global_scanner_queue.cancelMatchingJobs(func(job ScannerJob) bool { return true })
+}
+
+// cancelJob cancels the job with the given id, jobs that are not persisted have no id and can't be cancelled by it.
+// Queue should be locked prior to calling this function
+func (queue *ScannerQueue) cancelJob(jobID int) bool {
+	if jobID == 0 {
+		return false
+	}
+
+	return queue.cancelMatchingJobs(func(job ScannerJob) bool { return job.id == jobID }) > 0
+}
+
+// cancelMatchingJobs cancels the queued and running jobs matching the function, and returns the number of cancelled jobs.
+// Queue should be locked prior to calling this function
+func (queue *ScannerQueue) cancelMatchingJobs(match func(job ScannerJob) bool) int {
+	cancelled := 0
+
+	upNext := make([]ScannerJob, 0, len(queue.up_next))
+	for _, job := range queue.up_next {
+		if !match(job) {
+			upNext = append(upNext, job)
+			continue
+		}
+
+		job.updateRecord(queue.db, map[string]any{
+			"status":      models.ScannerJobStatusCancelled,
+			"finished_at": time.Now(),
+		})
+		cancelled++
+	}
+	queue.up_next = upNext
+
+	// Running jobs stop asynchronously, their record is updated again with the finish time when the job returns
+	for _, job := range queue.in_progress {
+		if !match(job) {
+			continue
+		}
+
+		if cancel, found := queue.cancel_funcs[job.seq]; found {
+			cancel()
+			job.updateRecord(queue.db, map[string]any{"status": models.ScannerJobStatusCancelled})
+			cancelled++
+		}
+	}
+
+	if cancelled > 0 {
+		queue.notify()
+	}
+
+	return cancelled
+}
@@ -2,16 +2,20 @@ package scanner_queue
 
 import (
 	"context"
-	"flag"
 	"testing"
+	"time"
 
 	"github.com/photoview/photoview/api/graphql/models"
 	"github.com/photoview/photoview/api/scanner/scanner_cache"
 	"github.com/photoview/photoview/api/scanner/scanner_task"
+	"github.com/photoview/photoview/api/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-var _ = flag.Bool("database", false, "run database integration tests")
-var _ = flag.Bool("filesystem", false, "run filesystem integration tests")
+func TestMain(m *testing.M) {
+	test_utils.IntegrationTestRun(m)
+}
 
 func makeAlbumWithID(id int) *models.Album {
 	var album models.Album
@@ -32,10 +36,11 @@ func TestScannerQueueAddJob(t *testing.T) {
 	}
 
 	mockScannerQueue := ScannerQueue{
-		idle_chan:   make(chan bool, 1),
-		in_progress: make([]ScannerJob, 0),
-		up_next:     scannerJobs,
-		db:          nil,
+		idle_chan:    make(chan bool, 1),
+		in_progress:  make([]ScannerJob, 0),
+		up_next:      scannerJobs,
+		db:           nil,
+		cancel_funcs: make(map[int]context.CancelFunc),
 	}
 
 	t.Run("add new job to scanner queue", func(t *testing.T) {
@@ -80,10 +85,11 @@ func TestScannerQueueJobOnQueue(t *testing.T) {
 	}
 
 	mockScannerQueue := ScannerQueue{
-		idle_chan:   make(chan bool, 1),
-		in_progress: make([]ScannerJob, 0),
-		up_next:     scannerJobs,
-		db:          nil,
+		idle_chan:    make(chan bool, 1),
+		in_progress:  make([]ScannerJob, 0),
+		up_next:      scannerJobs,
+		db:           nil,
+		cancel_funcs: make(map[int]context.CancelFunc),
 	}
 
 	onQueueTests := []struct {
@@ -107,3 +113,169 @@ func TestScannerQueueJobOnQueue(t *testing.T) {
 	}
 
 }
+
+func TestScannerQueuePersistJobs(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	album := models.Album{
+		Title: "album",
+		Path:  t.TempDir(),
+	}
+	require.NoError(t, db.Create(&album).Error)
+
+	mockScannerQueue := ScannerQueue{
+		idle_chan:    make(chan bool, 1),
+		in_progress:  make([]ScannerJob, 0),
+		up_next:      make([]ScannerJob, 0),
+		db:           db,
+		cancel_funcs: make(map[int]context.CancelFunc),
+	}
+
+	job := NewScannerJob(scanner_task.NewTaskContext(context.Background(), db, &album, scanner_cache.MakeAlbumCache()))
+	require.NoError(t, mockScannerQueue.addJob(&job))
+	require.Len(t, mockScannerQueue.up_next, 1)
+
+	var record models.ScannerJob
+	require.NoError(t, db.First(&record, mockScannerQueue.up_next[0].id).Error)
+	assert.Equal(t, models.ScannerJobStatusQueued, record.Status)
+	assert.Equal(t, album.Path, record.AlbumPath)
+	assert.Equal(t, album.ID, *record.AlbumID)
+
+	t.Run("resume pending jobs", func(t *testing.T) {
+		runningJob := models.ScannerJob{AlbumID: &album.ID, AlbumPath: album.Path, Status: models.ScannerJobStatusRunning}
+		deletedAlbumJob := models.ScannerJob{AlbumPath: "/deleted", Status: models.ScannerJobStatusQueued}
+		doneJob := models.ScannerJob{AlbumID: &album.ID, AlbumPath: album.Path, Status: models.ScannerJobStatusDone}
+		require.NoError(t, db.Create(&runningJob).Error)
+		require.NoError(t, db.Create(&deletedAlbumJob).Error)
+		require.NoError(t, db.Create(&doneJob).Error)
+
+		restartedQueue := ScannerQueue{
+			idle_chan:    make(chan bool, 1),
+			in_progress:  make([]ScannerJob, 0),
+			up_next:      make([]ScannerJob, 0),
+			db:           db,
+			cancel_funcs: make(map[int]context.CancelFunc),
+		}
+		require.NoError(t, restartedQueue.resumePendingJobs())
+
+		require.Len(t, restartedQueue.up_next, 1, "album should only be queued once")
+		assert.Equal(t, record.ID, restartedQueue.up_next[0].id)
+		assert.Equal(t, album.ID, restartedQueue.up_next[0].ctx.GetAlbum().ID)
+
+		statuses := map[int]models.ScannerJobStatus{
+			record.ID:          models.ScannerJobStatusQueued,
+			runningJob.ID:      models.ScannerJobStatusFailed,
+			deletedAlbumJob.ID: models.ScannerJobStatusFailed,
+			doneJob.ID:         models.ScannerJobStatusDone,
+		}
+		for id, status := range statuses {
+			var job models.ScannerJob
+			require.NoError(t, db.First(&job, id).Error)
+			assert.Equal(t, status, job.Status, "status of job %d", id)
+		}
+	})
+
+	t.Run("old finished jobs are pruned", func(t *testing.T) {
+		longAgo := time.Now().Add(-scannerJobHistoryRetention - time.Hour)
+		oldJob := models.ScannerJob{AlbumID: &album.ID, AlbumPath: album.Path, Status: models.ScannerJobStatusDone}
+		oldJob.CreatedAt = longAgo
+		oldQueuedJob := models.ScannerJob{AlbumID: &album.ID, AlbumPath: album.Path, Status: models.ScannerJobStatusQueued}
+		oldQueuedJob.CreatedAt = longAgo
+		require.NoError(t, db.Create(&oldJob).Error)
+		require.NoError(t, db.Create(&oldQueuedJob).Error)
+
+		require.NoError(t, mockScannerQueue.pruneJobHistory())
+
+		var count int64
+		require.NoError(t, db.Model(&models.ScannerJob{}).Where("id = ?", oldJob.ID).Count(&count).Error)
+		assert.Zero(t, count, "old finished job should be deleted")
+		require.NoError(t, db.Model(&models.ScannerJob{}).Where("id = ?", oldQueuedJob.ID).Count(&count).Error)
+		assert.Equal(t, int64(1), count, "unfinished jobs should be kept")
+	})
+}
+
+func TestScannerQueuePriority(t *testing.T) {
+	lowJob := makeScannerJob(1)
+	lowJob.priority = models.ScannerJobPriorityLow
+	normalJob := makeScannerJob(2)
+	highJob := makeScannerJob(3)
+	highJob.priority = models.ScannerJobPriorityHigh
+
+	mockScannerQueue := ScannerQueue{
+		idle_chan:    make(chan bool, 1),
+		in_progress:  make([]ScannerJob, 0),
+		up_next:      []ScannerJob{lowJob, normalJob, highJob},
+		db:           nil,
+		cancel_funcs: make(map[int]context.CancelFunc),
+	}
+
+	t.Run("jobs are run by priority", func(t *testing.T) {
+		assert.Equal(t, highJob, mockScannerQueue.popNextJob())
+		assert.Equal(t, normalJob, mockScannerQueue.popNextJob())
+		assert.Equal(t, lowJob, mockScannerQueue.popNextJob())
+		assert.Empty(t, mockScannerQueue.up_next)
+	})
+
+	t.Run("jobs with the same priority are run in order", func(t *testing.T) {
+		mockScannerQueue.up_next = []ScannerJob{makeScannerJob(4), makeScannerJob(5)}
+
+		assert.Equal(t, 4, mockScannerQueue.popNextJob().ctx.GetAlbum().ID)
+		assert.Equal(t, 5, mockScannerQueue.popNextJob().ctx.GetAlbum().ID)
+	})
+
+	t.Run("adding a queued album with a higher priority raises its priority", func(t *testing.T) {
+		mockScannerQueue.up_next = []ScannerJob{lowJob}
+
+		job := makeScannerJob(1)
+		job.priority = models.ScannerJobPriorityHigh
+		require.NoError(t, mockScannerQueue.addJob(&job))
+
+		require.Len(t, mockScannerQueue.up_next, 1)
+		assert.Equal(t, models.ScannerJobPriorityHigh, mockScannerQueue.up_next[0].priority)
+
+		job = makeScannerJob(1)
+		job.priority = models.ScannerJobPriorityLow
+		require.NoError(t, mockScannerQueue.addJob(&job))
+		assert.Equal(t, models.ScannerJobPriorityHigh, mockScannerQueue.up_next[0].priority, "priority should never be lowered")
+	})
+}
+
+func TestScannerQueueCancelJob(t *testing.T) {
+	queuedJob := makeScannerJob(1)
+	queuedJob.id = 10
+	runningJob := makeScannerJob(2)
+	runningJob.id = 20
+	runningJob.seq = 1
+	// Jobs that are not persisted all have the id 0
+	unsavedJob := makeScannerJob(3)
+	unsavedJob.seq = 2
+
+	runningCtx, cancel := runningJob.ctx.WithCancel()
+	runningJob.ctx = runningCtx
+	unsavedCtx, unsavedCancel := unsavedJob.ctx.WithCancel()
+	unsavedJob.ctx = unsavedCtx
+
+	mockScannerQueue := ScannerQueue{
+		idle_chan:   make(chan bool, 1),
+		in_progress: []ScannerJob{runningJob, unsavedJob},
+		up_next:     []ScannerJob{queuedJob},
+		db:          nil,
+		cancel_funcs: map[int]context.CancelFunc{
+			runningJob.seq: cancel,
+			unsavedJob.seq: unsavedCancel,
+		},
+	}
+
+	assert.True(t, mockScannerQueue.cancelJob(queuedJob.id))
+	assert.Empty(t, mockScannerQueue.up_next, "cancelled job should be removed from the queue")
+
+	assert.True(t, mockScannerQueue.cancelJob(runningJob.id))
+	assert.ErrorIs(t, runningJob.ctx.Err(), context.Canceled, "context of the running job should be cancelled")
+	assert.NoError(t, unsavedJob.ctx.Err(), "other running jobs should not be cancelled")
+
+	assert.False(t, mockScannerQueue.cancelJob(0), "jobs that are not persisted cannot be cancelled by id")
+	assert.False(t, mockScannerQueue.cancelJob(30), "unknown jobs cannot be cancelled")
+
+	assert.Equal(t, 2, mockScannerQueue.cancelMatchingJobs(func(job ScannerJob) bool { return true }))
+	assert.ErrorIs(t, unsavedJob.ctx.Err(), context.Canceled)
+}
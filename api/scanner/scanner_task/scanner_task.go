@@ -54,6 +54,7 @@ const (
 	taskCtxKeyAlbum      taskCtxKeyType = "task_album"
 	taskCtxKeyAlbumCache taskCtxKeyType = "task_album_cache"
 	taskCtxKeyDatabase   taskCtxKeyType = "task_database"
+	taskCtxKeyScanErrors taskCtxKeyType = "task_scan_errors"
 )
 
 func (c TaskContext) GetAlbum() *models.Album {
@@ -68,6 +69,18 @@ func (c TaskContext) GetDB() *gorm.DB {
 	return c.Context.Value(taskCtxKeyDatabase).(*gorm.DB)
 }
 
+// WithMediaScanErrors returns a copy of the context with the recorded errors of the media in the album
+func (c TaskContext) WithMediaScanErrors(scanErrors models.MediaScanErrors) TaskContext {
+	return c.WithValue(taskCtxKeyScanErrors, scanErrors)
+}
+
+// GetMediaScanError returns the recorded error of the task for the given media,
+// or nil if the task has not failed or the errors of the album were not loaded
+func (c TaskContext) GetMediaScanError(mediaID int, task models.MediaScanTask) *models.MediaScanError {
+	scanErrors, _ := c.Context.Value(taskCtxKeyScanErrors).(models.MediaScanErrors)
+	return scanErrors.Get(mediaID, task)
+}
+
 func (c TaskContext) DatabaseTransaction(transFunc func(ctx TaskContext) error, opts ...*sql.TxOptions) error {
 	return c.GetDB().Transaction(func(tx *gorm.DB) error {
 		return transFunc(c.WithDB(tx))
@@ -80,6 +93,19 @@ func (c TaskContext) WithValue(key, val interface{}) TaskContext {
 	}
 }
 
+// WithCancel returns a copy of the context that is cancelled when the returned cancel function is called,
+// database queries of the returned context are cancelled as well
+func (c TaskContext) WithCancel() (TaskContext, context.CancelFunc) {
+	cancelCtx, cancel := context.WithCancel(c.Context)
+	ctx := TaskContext{Context: cancelCtx}
+
+	if db, ok := c.Context.Value(taskCtxKeyDatabase).(*gorm.DB); ok {
+		ctx = ctx.WithDB(db)
+	}
+
+	return ctx, cancel
+}
+
 func (c TaskContext) WithDB(db *gorm.DB) TaskContext {
 	// Allow db to be nil in tests
 	if db == nil && flag.Lookup("test.v") != nil {
@@ -88,3 +114,25 @@ func (c TaskContext) WithDB(db *gorm.DB) TaskContext {
 
 	return c.WithValue(taskCtxKeyDatabase, db.WithContext(c.Context))
 }
+
+// MediaTaskError is returned by tasks that failed to process a single media file.
+// The scanner records these errors, so the task is not retried for the file on every scan.
+type MediaTaskError struct {
+	Task models.MediaScanTask
+	Err  error
+}
+
+func NewMediaTaskError(task models.MediaScanTask, err error) *MediaTaskError {
+	return &MediaTaskError{
+		Task: task,
+		Err:  err,
+	}
+}
+
+func (e *MediaTaskError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *MediaTaskError) Unwrap() error {
+	return e.Err
+}
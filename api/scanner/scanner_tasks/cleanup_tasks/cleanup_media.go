@@ -4,6 +4,7 @@ import (
 	"os"
 	"path"
 	"strconv"
+	"sync"
 
 	"github.com/photoview/photoview/api/graphql/models"
 	"github.com/photoview/photoview/api/scanner/face_detection"
@@ -13,7 +14,16 @@ import (
 	"gorm.io/gorm"
 )
 
-// CleanupMedia removes media entries from the database that are no longer present on the filesystem
+// missingMedia holds the ids of fingerprinted media, whose files were missing when their album was scanned.
+// They are deleted by DeleteMissingMedia once the scanner is idle, instead of right away,
+// so a file moved to an album that is scanned later is found by its fingerprint and keeps its media.
+var missingMedia = struct {
+	sync.Mutex
+	ids map[int]bool
+}{ids: make(map[int]bool)}
+
+// CleanupMedia removes media entries from the database that are no longer present on the filesystem.
+// Media that might have been moved to another album are only marked, to be deleted by DeleteMissingMedia.
 func CleanupMedia(db *gorm.DB, albumId int, albumMedia []*models.Media) []error {
 	albumMediaIds := make([]int, len(albumMedia))
 	for i, media := range albumMedia {
@@ -21,7 +31,7 @@ func CleanupMedia(db *gorm.DB, albumId int, albumMedia []*models.Media) []error
 	}
 
 	// Will get from database
-	var mediaList []models.Media
+	var mediaList []*models.Media
 
 	query := db.Where("album_id = ?", albumId)
 
@@ -34,13 +44,61 @@ func CleanupMedia(db *gorm.DB, albumId int, albumMedia []*models.Media) []error
 		return []error{errors.Wrap(err, "get media files to be deleted from database")}
 	}
 
+	deleteMedia := make([]*models.Media, 0)
+
+	missingMedia.Lock()
+	for _, media := range mediaList {
+		if media.Fingerprint != nil && *media.Fingerprint != "" {
+			missingMedia.ids[media.ID] = true
+			continue
+		}
+
+		deleteMedia = append(deleteMedia, media)
+	}
+	missingMedia.Unlock()
+
+	return deleteMediaList(db, deleteMedia)
+}
+
+// DeleteMissingMedia deletes the media marked by CleanupMedia, whose files still don't exist.
+// Media that were found at a new path in the meantime are kept.
+func DeleteMissingMedia(db *gorm.DB) []error {
+	missingMedia.Lock()
+	mediaIDs := make([]int, 0, len(missingMedia.ids))
+	for id := range missingMedia.ids {
+		mediaIDs = append(mediaIDs, id)
+	}
+	missingMedia.ids = make(map[int]bool)
+	missingMedia.Unlock()
+
+	if len(mediaIDs) == 0 {
+		return nil
+	}
+
+	var mediaList []*models.Media
+	if err := db.Where("id IN (?)", mediaIDs).Find(&mediaList).Error; err != nil {
+		return []error{errors.Wrap(err, "get missing media from database")}
+	}
+
+	deleteMedia := make([]*models.Media, 0, len(mediaList))
+	for _, media := range mediaList {
+		if !scanner_utils.FileExists(media.Path) {
+			deleteMedia = append(deleteMedia, media)
+		}
+	}
+
+	return deleteMediaList(db, deleteMedia)
+}
+
+// deleteMediaList deletes the media from the cache and the database, and reloads the faces
+func deleteMediaList(db *gorm.DB, mediaList []*models.Media) []error {
 	deleteErrors := make([]error, 0)
 
 	mediaIDs := make([]int, 0)
 	for _, media := range mediaList {
 
 		mediaIDs = append(mediaIDs, media.ID)
-		cachePath := path.Join(utils.MediaCachePath(), strconv.Itoa(int(albumId)), strconv.Itoa(int(media.ID)))
+		cachePath := path.Join(utils.MediaCachePath(), strconv.Itoa(int(media.AlbumID)), strconv.Itoa(int(media.ID)))
 		err := os.RemoveAll(cachePath)
 		if err != nil {
 			deleteErrors = append(deleteErrors, errors.Wrapf(err, "delete unused cache folder (%s)", cachePath))
@@ -76,7 +134,7 @@ func DeleteOldUserAlbums(db *gorm.DB, scannedAlbums []*models.Album, user *model
 	}
 
 	// Old albums to be deleted
-	var deleteAlbums []models.Album
+	var oldAlbums []models.Album
 
 	// Find old albums in database
 	query := db.
@@ -86,34 +144,68 @@ func DeleteOldUserAlbums(db *gorm.DB, scannedAlbums []*models.Album, user *model
 		Where("user_id = ?", user.ID).
 		Where("album_id NOT IN (?)", scannedAlbumIDs)
 
-	if err := query.Find(&deleteAlbums).Error; err != nil {
+	if err := query.Find(&oldAlbums).Error; err != nil {
 		return []error{errors.Wrap(err, "get albums to be deleted from database")}
 	}
 
-	if len(deleteAlbums) == 0 {
+	if len(oldAlbums) == 0 {
 		return []error{}
 	}
 
+	deleteAlbumIDs := make([]int, len(oldAlbums))
+	for i, album := range oldAlbums {
+		deleteAlbumIDs[i] = album.ID
+	}
+
+	return deleteAlbums(db, deleteAlbumIDs)
+}
+
+// DeleteOldSubAlbums deletes the sub-albums of the given album, from the database and cache,
+// that were not found when scanning the directory tree of the album.
+func DeleteOldSubAlbums(db *gorm.DB, album *models.Album, scannedAlbums []*models.Album) []error {
+	scannedAlbumIDs := make(map[int]bool, len(scannedAlbums))
+	for _, scanned := range scannedAlbums {
+		scannedAlbumIDs[scanned.ID] = true
+	}
+
+	children, err := album.GetChildren(db, nil)
+	if err != nil {
+		return []error{errors.Wrap(err, "get sub-albums to be deleted from database")}
+	}
+
+	deleteAlbumIDs := make([]int, 0)
+	for _, child := range children {
+		if child.ID != album.ID && !scannedAlbumIDs[child.ID] {
+			deleteAlbumIDs = append(deleteAlbumIDs, child.ID)
+		}
+	}
+
+	if len(deleteAlbumIDs) == 0 {
+		return []error{}
+	}
+
+	return deleteAlbums(db, deleteAlbumIDs)
+}
+
+// deleteAlbums deletes the albums from the cache and the database, and reloads the faces of the remaining media
+func deleteAlbums(db *gorm.DB, albumIDs []int) []error {
 	deleteErrors := make([]error, 0)
 
 	// Delete old albums from cache
-	deleteAlbumIDs := make([]int, len(deleteAlbums))
-	for i, album := range deleteAlbums {
-		deleteAlbumIDs[i] = album.ID
-		cachePath := path.Join(utils.MediaCachePath(), strconv.Itoa(int(album.ID)))
-		err := os.RemoveAll(cachePath)
-		if err != nil {
+	for _, albumID := range albumIDs {
+		cachePath := path.Join(utils.MediaCachePath(), strconv.Itoa(albumID))
+		if err := os.RemoveAll(cachePath); err != nil {
 			deleteErrors = append(deleteErrors, errors.Wrapf(err, "delete unused cache folder (%s)", cachePath))
 		}
 	}
 
 	// Delete old albums from database
 	err := db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("album_id IN (?)", deleteAlbumIDs).Delete(&models.UserAlbums{}).Error; err != nil {
+		if err := tx.Where("album_id IN (?)", albumIDs).Delete(&models.UserAlbums{}).Error; err != nil {
 			return err
 		}
 
-		if err := tx.Where("id IN (?)", deleteAlbumIDs).Delete(models.Album{}).Error; err != nil {
+		if err := tx.Where("id IN (?)", albumIDs).Delete(models.Album{}).Error; err != nil {
 			return err
 		}
 
@@ -9,6 +9,7 @@ import (
 	"github.com/photoview/photoview/api/graphql/models"
 	"github.com/photoview/photoview/api/log"
 	"github.com/photoview/photoview/api/scanner/externaltools/exif"
+	"github.com/photoview/photoview/api/scanner/filename_date"
 	"github.com/photoview/photoview/api/scanner/scanner_task"
 )
 
@@ -16,20 +17,54 @@ type ExifTask struct {
 	scanner_task.ScannerTaskBase
 }
 
+type exifTaskKey string
+
+const rootAlbumPathKey exifTaskKey = "root_album_path_key"
+
+func getRootAlbumPath(ctx scanner_task.TaskContext) string {
+	return ctx.Value(rootAlbumPathKey).(string)
+}
+
+// BeforeScanAlbum looks up the root album, dates are only looked for in the names of the folders inside it
+func (t ExifTask) BeforeScanAlbum(ctx scanner_task.TaskContext) (scanner_task.TaskContext, error) {
+	rootPath, err := models.RootAlbumPath(ctx.GetDB(), ctx.GetAlbum().ID)
+	if err != nil {
+		return ctx, fmt.Errorf("get root album of %q: %w", ctx.GetAlbum().Path, err)
+	}
+
+	return ctx.WithValue(rootAlbumPathKey, rootPath), nil
+}
+
 func (t ExifTask) AfterMediaFound(ctx scanner_task.TaskContext, media *models.Media, newMedia bool) error {
-	if !newMedia {
+	scanError := ctx.GetMediaScanError(media.ID, models.MediaScanTaskExif)
+
+	// Existing media is only parsed again if a previous attempt failed, and the backoff has passed
+	if (!newMedia && scanError == nil) || (scanError != nil && scanError.InBackoff()) {
 		return nil
 	}
 
-	if err := SaveEXIF(ctx.GetDB(), media); err != nil {
+	if err := SaveEXIF(ctx.GetDB(), media, getRootAlbumPath(ctx)); err != nil {
 		log.Warn(ctx, "SaveEXIF failed", "title", media.Title, "error", err, "path", media.Path)
+
+		if err := models.RecordMediaScanError(ctx.GetDB(), media, models.MediaScanTaskExif, err); err != nil {
+			log.Warn(ctx, "Failed to record exif scan error", "path", media.Path, "error", err)
+		}
+
+		return nil
+	}
+
+	if scanError != nil {
+		if err := models.ClearMediaScanError(ctx.GetDB(), media.ID, models.MediaScanTaskExif); err != nil {
+			return fmt.Errorf("clear exif scan error for %q: %w", media.Path, err)
+		}
 	}
 
 	return nil
 }
 
-// SaveEXIF scans the media file for exif metadata and saves it in the database if found
-func SaveEXIF(tx *gorm.DB, media *models.Media) error {
+// SaveEXIF scans the media file for exif metadata and saves it in the database if found,
+// dates in the names of folders are looked for up to `rootPath` of the root album
+func SaveEXIF(tx *gorm.DB, media *models.Media, rootPath string) error {
 	// Check if EXIF data already exists
 	if media.ExifID != nil {
 		var e models.MediaEXIF
@@ -63,11 +98,35 @@ func SaveEXIF(tx *gorm.DB, media *models.Media) error {
 		return fmt.Errorf("failed to save media exif to database: %w", err)
 	}
 
-	if exifData.DateShot != nil && !exifData.DateShot.Equal(media.DateShot) {
+	if err := models.SetMediaKeywords(tx, media, exifData.Keywords); err != nil {
+		return fmt.Errorf("failed to save keywords of %q: %w", media.Path, err)
+	}
+
+	dateShot := exifData.DateShot
+	if dateShot == nil {
+		// The modification time of the file is often the time it was copied,
+		// so the date is looked for in the names of the file and its folders first
+		matcher, err := filename_date.SiteMatcher(tx)
+		if err != nil {
+			return fmt.Errorf("failed to get filename date patterns: %w", err)
+		}
+		dateShot = matcher.Match(media.Path, rootPath)
+	}
+
+	if dateShot != nil && !dateShot.Equal(media.DateShot) {
+		// A date corrected by users takes precedence over the date of the file
+		overridden, err := models.DateShotOverridden(tx, media.ID)
+		if err != nil {
+			return fmt.Errorf("failed to check date override of %q: %w", media.Path, err)
+		}
+		if overridden {
+			return nil
+		}
+
+		media.DateShot = *dateShot
 		if err := tx.Save(media).Error; err != nil {
 			return fmt.Errorf("failed to update EXIF metadata for the media %s: %w", media.Path, err)
 		}
-		media.DateShot = *exifData.DateShot
 	}
 
 	return nil
@@ -0,0 +1,69 @@
+package scanner_tasks
+
+import (
+	"fmt"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/scanner/geocoding"
+	"github.com/photoview/photoview/api/scanner/scanner_task"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// GeocodeTask resolves the GPS coordinates of the media of an album to places, after the album has been scanned
+type GeocodeTask struct {
+	scanner_task.ScannerTaskBase
+}
+
+func (t GeocodeTask) AfterScanAlbum(ctx scanner_task.TaskContext, changedMedia []*models.Media, albumMedia []*models.Media) error {
+	if geocoding.GlobalGeocoder == nil {
+		return nil
+	}
+
+	if err := GeocodeAlbum(ctx.GetDB(), geocoding.GlobalGeocoder, ctx.GetAlbum().ID); err != nil {
+		return fmt.Errorf("resolve places of album %q: %w", ctx.GetAlbum().Path, err)
+	}
+
+	return nil
+}
+
+// GeocodeAlbum resolves the places of the media in the album that have GPS coordinates but no place yet.
+// This includes media scanned before the GeoNames dataset was available.
+// The search documents of the media are updated with the names of the places.
+func GeocodeAlbum(db *gorm.DB, geocoder *geocoding.Geocoder, albumID int) error {
+	var exifs []*models.MediaEXIF
+	if err := db.
+		Where("id IN (?)", db.Model(&models.Media{}).Select("exif_id").Where("album_id = ?", albumID)).
+		Where("gps_latitude IS NOT NULL AND gps_longitude IS NOT NULL AND country_code IS NULL").
+		Find(&exifs).Error; err != nil {
+		return errors.Wrap(err, "get exif data without place")
+	}
+
+	placedExifIDs := make([]int, 0, len(exifs))
+	for _, exif := range exifs {
+		place := geocoder.Lookup(*exif.GPSLatitude, *exif.GPSLongitude)
+		if place == nil {
+			continue
+		}
+
+		exif.SetPlace(place)
+		if err := db.Model(exif).
+			Select("country_code", "country", "region", "city").
+			Updates(exif).Error; err != nil {
+			return errors.Wrapf(err, "save place of exif data (%d)", exif.ID)
+		}
+		placedExifIDs = append(placedExifIDs, exif.ID)
+	}
+
+	if len(placedExifIDs) == 0 {
+		return nil
+	}
+
+	// The places are searched by name
+	var mediaIDs []int
+	if err := db.Model(&models.Media{}).Where("exif_id IN ?", placedExifIDs).Pluck("id", &mediaIDs).Error; err != nil {
+		return errors.Wrap(err, "get media of placed exif data")
+	}
+
+	return models.UpdateMediaSearchDocuments(db, mediaIDs)
+}
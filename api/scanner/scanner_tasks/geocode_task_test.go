@@ -0,0 +1,53 @@
+package scanner_tasks_test
+
+import (
+	"testing"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/scanner/geocoding"
+	"github.com/photoview/photoview/api/scanner/scanner_tasks"
+	"github.com/photoview/photoview/api/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeocodeAlbum(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	geocoder, err := geocoding.Load(test_utils.PathFromAPIRoot("scanner", "geocoding", "test_data"))
+	require.NoError(t, err)
+
+	album := models.Album{
+		Title: "album",
+		Path:  "/photos",
+	}
+	require.NoError(t, db.Save(&album).Error)
+
+	coordinates := func(latitude, longitude float64) *models.MediaEXIF {
+		return &models.MediaEXIF{GPSLatitude: &latitude, GPSLongitude: &longitude}
+	}
+
+	media := []models.Media{
+		{Title: "lisbon", Path: "/photos/lisbon", AlbumID: album.ID, Exif: coordinates(38.7223, -9.1393)},
+		{Title: "atlantic", Path: "/photos/atlantic", AlbumID: album.ID, Exif: coordinates(30, -40)},
+		{Title: "no_gps", Path: "/photos/no_gps", AlbumID: album.ID, Exif: &models.MediaEXIF{}},
+	}
+	require.NoError(t, db.Save(&media).Error)
+
+	require.NoError(t, scanner_tasks.GeocodeAlbum(db, geocoder, album.ID))
+
+	placeOf := func(media models.Media) *models.Place {
+		var exif models.MediaEXIF
+		require.NoError(t, db.First(&exif, *media.ExifID).Error)
+		return exif.Place()
+	}
+
+	place := placeOf(media[0])
+	require.NotNil(t, place)
+	assert.Equal(t, "PT", place.CountryCode)
+	assert.Equal(t, "Portugal", place.Country)
+	assert.Equal(t, "Lisbon", *place.City)
+
+	assert.Nil(t, placeOf(media[1]))
+	assert.Nil(t, placeOf(media[2]))
+}
@@ -0,0 +1,139 @@
+package scanner_tasks
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"os"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/log"
+	"github.com/photoview/photoview/api/scanner/media_encoding"
+	"github.com/photoview/photoview/api/scanner/scanner_task"
+)
+
+// PerceptualHashTask computes a perceptual hash of the thumbnail of photos, used to find duplicates
+type PerceptualHashTask struct {
+	scanner_task.ScannerTaskBase
+}
+
+func (t PerceptualHashTask) AfterProcessMedia(ctx scanner_task.TaskContext, mediaData *media_encoding.EncodeMediaData, updatedURLs []*models.MediaURL, mediaIndex int, mediaTotal int) error {
+	if mediaData.Media.Type != models.MediaTypePhoto {
+		return nil
+	}
+
+	hasThumbnailUpdated := false
+	for _, url := range updatedURLs {
+		if url.Purpose == models.PhotoThumbnail {
+			hasThumbnailUpdated = true
+			break
+		}
+	}
+
+	var media *models.Media
+	if err := ctx.GetDB().Preload("MediaURL").Where("id = ?", mediaData.Media.ID).First(&media).Error; err != nil {
+		return fmt.Errorf("failed to get media(id:%d): %w", mediaData.Media.ID, err)
+	}
+
+	if media.PerceptualHash != nil && !hasThumbnailUpdated {
+		return nil
+	}
+
+	thumbnail, err := media.GetThumbnail()
+	if err != nil {
+		return fmt.Errorf("failed to get thumbnail of image %q: %w", mediaData.Media.Path, err)
+	}
+	if thumbnail == nil {
+		return nil
+	}
+
+	hash, err := generatePerceptualHashFromThumbnail(thumbnail)
+	if errors.Is(err, ErrImageTooSmall) {
+		// Tiny images would all get the same hash and be grouped as duplicates, so they are left without one
+		log.Info(ctx, "Image too small for a perceptual hash", "media", mediaData.Media.Path)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to generate perceptual hash of image %q: %w", mediaData.Media.Path, err)
+	}
+
+	media.PerceptualHash = &hash
+	if err := ctx.GetDB().Select("perceptual_hash").Save(media).Error; err != nil {
+		return fmt.Errorf("failed to store perceptual hash of image %q: %w", mediaData.Media.Path, err)
+	}
+
+	log.Info(ctx, "Generated perceptual hash of image", "media", mediaData.Media.Path)
+
+	return nil
+}
+
+func generatePerceptualHashFromThumbnail(thumbnail *models.MediaURL) (int64, error) {
+	path, err := thumbnail.CachedPath()
+	if err != nil {
+		return 0, fmt.Errorf("get path of media(id:%d) error: %w", thumbnail.MediaID, err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("open %q error: %w", path, err)
+	}
+	defer f.Close()
+
+	imageData, _, err := image.Decode(f)
+	if err != nil {
+		return 0, fmt.Errorf("decode %q error: %w", path, err)
+	}
+
+	return DifferenceHash(imageData)
+}
+
+// ErrImageTooSmall is returned by DifferenceHash for images smaller than the 9x8 pixels it is computed from
+var ErrImageTooSmall = errors.New("image is too small for a perceptual hash")
+
+// DifferenceHash computes the 64 bit dHash of an image.
+// The image is shrunk to 9x8 grayscale pixels, and every bit tells if a pixel is brighter than its right neighbour,
+// so the hash stays almost the same when the image is resized, recompressed or slightly edited.
+func DifferenceHash(img image.Image) (int64, error) {
+	const (
+		width  = 9
+		height = 8
+	)
+
+	bounds := img.Bounds()
+	if bounds.Dx() < width || bounds.Dy() < height {
+		return 0, ErrImageTooSmall
+	}
+
+	var pixels [height][width]float64
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := bounds.Min.Y + (y+1)*bounds.Dy()/height
+
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := bounds.Min.X + (x+1)*bounds.Dx()/width
+
+			var sum float64
+			for py := y0; py < y1; py++ {
+				for px := x0; px < x1; px++ {
+					r, g, b, _ := img.At(px, py).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+				}
+			}
+			pixels[y][x] = sum / float64((x1-x0)*(y1-y0))
+		}
+	}
+
+	var hash uint64
+	for y := 0; y < height; y++ {
+		for x := 0; x < width-1; x++ {
+			hash <<= 1
+			if pixels[y][x] > pixels[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+
+	return int64(hash), nil
+}
@@ -0,0 +1,57 @@
+package scanner_tasks_test
+
+import (
+	"image"
+	"image/color"
+	"math/bits"
+	"testing"
+
+	"github.com/photoview/photoview/api/scanner/scanner_tasks"
+	"github.com/photoview/photoview/api/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	test_utils.UnitTestRun(m)
+}
+
+func gradientImage(width, height int, brightness uint8, reversed bool) image.Image {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			value := (x*x + y*3) * 200 / (width*width + height*3)
+			if reversed {
+				value = 200 - value
+			}
+			img.SetGray(x, y, color.Gray{Y: uint8(value) + brightness})
+		}
+	}
+	return img
+}
+
+func TestDifferenceHash(t *testing.T) {
+	distance := func(a, b int64) int {
+		return bits.OnesCount64(uint64(a ^ b))
+	}
+
+	hash := func(img image.Image) int64 {
+		result, err := scanner_tasks.DifferenceHash(img)
+		require.NoError(t, err)
+		return result
+	}
+
+	original := hash(gradientImage(640, 480, 0, false))
+
+	resized := hash(gradientImage(320, 240, 0, false))
+	assert.LessOrEqual(t, distance(original, resized), 4, "resized image should have a similar hash")
+
+	brighter := hash(gradientImage(640, 480, 40, false))
+	assert.LessOrEqual(t, distance(original, brighter), 4, "brighter image should have a similar hash")
+
+	reversed := hash(gradientImage(640, 480, 0, true))
+	assert.Greater(t, distance(original, reversed), 16, "different image should have a different hash")
+
+	_, err := scanner_tasks.DifferenceHash(gradientImage(8, 8, 0, false))
+	assert.ErrorIs(t, err, scanner_tasks.ErrImageTooSmall, "tiny images should have no hash")
+}
@@ -21,6 +21,13 @@ func (t CounterpartFilesTask) MediaFound(ctx scanner_task.TaskContext, fileInfo
 		return true, nil
 	}
 
+	if fileType.IsVideo() && !utils.EnvDisableMotionPhotos.GetBool() {
+		// The clip of a Live Photo is shown as the motion video of its still, instead of a separate video
+		if _, isLivePhoto := media_type.FindLivePhotoStill(mediaPath); isLivePhoto {
+			return true, nil
+		}
+	}
+
 	if utils.EnvDisableRawProcessing.GetBool() {
 		if !fileType.IsWebCompatible() {
 			return true, nil
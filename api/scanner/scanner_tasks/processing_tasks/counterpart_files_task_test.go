@@ -23,6 +23,7 @@ func TestCounterpartFilesTaskMediaFound(t *testing.T) {
 		name                 string
 		file                 string
 		disableRawProcessing bool
+		disableMotionPhotos  bool
 		wantSkip             bool
 	}{
 		{
@@ -73,11 +74,33 @@ func TestCounterpartFilesTaskMediaFound(t *testing.T) {
 			disableRawProcessing: true,
 			wantSkip:             true,
 		},
+		{
+			name:     "LivePhotoStill",
+			file:     "live_photo.jpg",
+			wantSkip: false,
+		},
+		{
+			name:     "LivePhotoVideo",
+			file:     "live_photo.mov",
+			wantSkip: true,
+		},
+		{
+			name:                "LivePhotoVideoMotionPhotosDisabled",
+			file:                "live_photo.mov",
+			disableMotionPhotos: true,
+			wantSkip:            false,
+		},
+		{
+			name:     "StandaloneVideo",
+			file:     "quicktime.mov",
+			wantSkip: false,
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Setenv(string(utils.EnvDisableRawProcessing), fmt.Sprintf("%v", tc.disableRawProcessing))
+			t.Setenv(string(utils.EnvDisableMotionPhotos), fmt.Sprintf("%v", tc.disableMotionPhotos))
 
 			ctx := scanner_task.NewTaskContext(context.Background(), nil, nil, nil)
 
@@ -6,8 +6,12 @@ import (
 
 	"github.com/photoview/photoview/api/graphql/models"
 	"github.com/photoview/photoview/api/log"
+	"github.com/photoview/photoview/api/scanner/externaltools/exif"
 	"github.com/photoview/photoview/api/scanner/media_encoding"
+	"github.com/photoview/photoview/api/scanner/media_encoding/executable_worker"
+	"github.com/photoview/photoview/api/scanner/media_type"
 	"github.com/photoview/photoview/api/scanner/scanner_task"
+	"github.com/photoview/photoview/api/utils"
 	"github.com/pkg/errors"
 )
 
@@ -20,6 +24,21 @@ func (t ProcessPhotoTask) ProcessMedia(ctx scanner_task.TaskContext, mediaData *
 		return []*models.MediaURL{}, nil
 	}
 
+	updatedURLs, err := processPhoto(ctx, mediaData, mediaCachePath)
+	if err != nil {
+		return []*models.MediaURL{}, scanner_task.NewMediaTaskError(models.MediaScanTaskProcessPhoto, err)
+	}
+
+	if ctx.GetMediaScanError(mediaData.Media.ID, models.MediaScanTaskProcessPhoto) != nil {
+		if err := models.ClearMediaScanError(ctx.GetDB(), mediaData.Media.ID, models.MediaScanTaskProcessPhoto); err != nil {
+			return []*models.MediaURL{}, errors.Wrap(err, "clear media scan error")
+		}
+	}
+
+	return updatedURLs, nil
+}
+
+func processPhoto(ctx scanner_task.TaskContext, mediaData *media_encoding.EncodeMediaData, mediaCachePath string) ([]*models.MediaURL, error) {
 	updatedURLs := make([]*models.MediaURL, 0)
 	photo := mediaData.Media
 
@@ -122,5 +141,120 @@ func (t ProcessPhotoTask) ProcessMedia(ctx scanner_task.TaskContext, mediaData *
 		}
 	}
 
+	// Additional configured renditions
+	for _, rendition := range media_encoding.ExtraRenditions() {
+		renditionURL, err := photoURLFromDB(models.RenditionPurpose(rendition.Name))
+		if err != nil {
+			return []*models.MediaURL{}, errors.Wrapf(err, "error processing photo %s rendition", rendition.Name)
+		}
+
+		if renditionURL == nil {
+			renditionName := generateUniqueMediaNamePrefixed(rendition.Name, photo.Path, ".jpg")
+			renditionURL, err := generateSaveRenditionJPEG(ctx.GetDB(), photo, rendition, renditionName, mediaCachePath, baseImagePath, nil)
+			if err != nil {
+				return []*models.MediaURL{}, err
+			}
+
+			updatedURLs = append(updatedURLs, renditionURL)
+		} else {
+			// Verify that the rendition still exists in cache
+			renditionPath := path.Join(mediaCachePath, renditionURL.MediaName)
+
+			if _, err := os.Stat(renditionPath); os.IsNotExist(err) {
+				log.Info(ctx, "Rendition found in database but not in cache, re-encoding photo to cache", "media_name", renditionURL.MediaName)
+
+				renditionURL, err := generateSaveRenditionJPEG(ctx.GetDB(), photo, rendition, renditionURL.MediaName, mediaCachePath, baseImagePath, renditionURL)
+				if err != nil {
+					return []*models.MediaURL{}, err
+				}
+
+				updatedURLs = append(updatedURLs, renditionURL)
+			}
+		}
+	}
+
+	// Video clip of Live Photos and Motion Photos
+	if !utils.EnvDisableMotionPhotos.GetBool() {
+		motionURL, err := photoURLFromDB(models.MotionVideo)
+		if err != nil {
+			return []*models.MediaURL{}, errors.Wrap(err, "error processing photo motion video")
+		}
+
+		motionURL, err = processMotionVideo(ctx, photo, motionURL, mediaCachePath)
+		if err != nil {
+			return []*models.MediaURL{}, err
+		}
+
+		if motionURL != nil {
+			updatedURLs = append(updatedURLs, motionURL)
+		}
+	}
+
 	return updatedURLs, nil
 }
+
+// processMotionVideo saves the video clip of a Live Photo or Motion Photo to the cache if it is missing from the database or the cache,
+// it returns the media url if it was saved. Photos without a motion video are searched for an embedded video on every scan,
+// so photos processed before their clip was added are found too.
+func processMotionVideo(ctx scanner_task.TaskContext, photo *models.Media, motionURL *models.MediaURL, mediaCachePath string) (*models.MediaURL, error) {
+	if motionURL != nil {
+		// Verify that the motion video still exists in cache
+		if _, err := os.Stat(path.Join(mediaCachePath, motionURL.MediaName)); !os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		log.Info(ctx, "Motion video found in database but not in cache, saving motion video to cache", "media_name", motionURL.MediaName)
+	}
+
+	motionName := generateUniqueMediaNamePrefixed("motion_video", photo.Path, ".mp4")
+	if motionURL != nil {
+		motionName = motionURL.MediaName
+	}
+	motionPath := path.Join(mediaCachePath, motionName)
+
+	if livePhotoVideo, isLivePhoto := media_type.FindLivePhotoVideo(photo.Path); isLivePhoto {
+		// Live Photo clips are QuickTime videos, mostly in HEVC that many browsers can't play
+		err := executable_worker.Ffmpeg.EncodeMp4(livePhotoVideo, motionPath, media_encoding.VideoRendition().Size)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not encode live photo video (%s)", livePhotoVideo)
+		}
+	} else {
+		saved, err := exif.SaveMotionPhotoVideo(photo.Path, motionPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not extract motion photo video (%s)", photo.Path)
+		}
+
+		if !saved {
+			return nil, nil
+		}
+	}
+
+	motionMetadata, err := ReadVideoStreamMetadata(motionPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read metadata for motion video (%s)", photo.Title)
+	}
+
+	fileStats, err := os.Stat(motionPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading file stats of motion video")
+	}
+
+	if motionURL == nil {
+		motionURL = &models.MediaURL{
+			MediaID:     photo.ID,
+			MediaName:   motionName,
+			Purpose:     models.MotionVideo,
+			ContentType: "video/mp4",
+		}
+	}
+
+	motionURL.Width = motionMetadata.Width
+	motionURL.Height = motionMetadata.Height
+	motionURL.FileSize = fileStats.Size()
+
+	if err := ctx.GetDB().Save(motionURL).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to save motion video into database (%s)", photo.Title)
+	}
+
+	return motionURL, nil
+}
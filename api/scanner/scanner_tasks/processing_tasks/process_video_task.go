@@ -26,6 +26,21 @@ func (t ProcessVideoTask) ProcessMedia(ctx scanner_task.TaskContext, mediaData *
 		return []*models.MediaURL{}, nil
 	}
 
+	updatedURLs, err := processVideo(ctx, mediaData, mediaCachePath)
+	if err != nil {
+		return []*models.MediaURL{}, scanner_task.NewMediaTaskError(models.MediaScanTaskProcessVideo, err)
+	}
+
+	if ctx.GetMediaScanError(mediaData.Media.ID, models.MediaScanTaskProcessVideo) != nil {
+		if err := models.ClearMediaScanError(ctx.GetDB(), mediaData.Media.ID, models.MediaScanTaskProcessVideo); err != nil {
+			return []*models.MediaURL{}, errors.Wrap(err, "clear media scan error")
+		}
+	}
+
+	return updatedURLs, nil
+}
+
+func processVideo(ctx scanner_task.TaskContext, mediaData *media_encoding.EncodeMediaData, mediaCachePath string) ([]*models.MediaURL, error) {
 	updatedURLs := make([]*models.MediaURL, 0)
 	video := mediaData.Media
 
@@ -48,6 +63,16 @@ func (t ProcessVideoTask) ProcessMedia(ctx scanner_task.TaskContext, mediaData *
 		return []*models.MediaURL{}, errors.Wrap(err, "error processing video thumbnail")
 	}
 
+	videoPreviewURL, err := mediaURLFromDB(models.VideoPreview)
+	if err != nil {
+		return []*models.MediaURL{}, errors.Wrap(err, "error processing video preview")
+	}
+
+	videoHLSURL, err := mediaURLFromDB(models.VideoHLS)
+	if err != nil {
+		return []*models.MediaURL{}, errors.Wrap(err, "error processing video HLS stream")
+	}
+
 	videoType, err := mediaData.ContentType()
 	if err != nil {
 		return []*models.MediaURL{}, fmt.Errorf("getting video content type error: %w", err)
@@ -92,7 +117,7 @@ func (t ProcessVideoTask) ProcessMedia(ctx scanner_task.TaskContext, mediaData *
 
 		webVideoPath := path.Join(mediaCachePath, webVideoName)
 
-		err = executable_worker.Ffmpeg.EncodeMp4(video.Path, webVideoPath)
+		err = executable_worker.Ffmpeg.EncodeMp4(video.Path, webVideoPath, media_encoding.VideoRendition().Size)
 		if err != nil {
 			return []*models.MediaURL{}, errors.Wrapf(err, "could not encode mp4 video (%s)", video.Path)
 		}
@@ -124,6 +149,17 @@ func (t ProcessVideoTask) ProcessMedia(ctx scanner_task.TaskContext, mediaData *
 		updatedURLs = append(updatedURLs, &mediaURL)
 	}
 
+	if utils.EnvVideoHLS.GetBool() {
+		hlsURL, err := processVideoHLS(ctx, video, videoHLSURL, mediaCachePath)
+		if err != nil {
+			return []*models.MediaURL{}, err
+		}
+
+		if hlsURL != nil {
+			updatedURLs = append(updatedURLs, hlsURL)
+		}
+	}
+
 	probeData, err := mediaData.VideoMetadata()
 	if err != nil {
 		return []*models.MediaURL{}, err
@@ -137,10 +173,11 @@ func (t ProcessVideoTask) ProcessMedia(ctx scanner_task.TaskContext, mediaData *
 
 		thumbImagePath := path.Join(mediaCachePath, videoThumbName)
 
-		err = executable_worker.Ffmpeg.EncodeVideoThumbnail(video.Path, thumbImagePath, probeData)
+		err = executable_worker.Ffmpeg.EncodeVideoThumbnail(video.Path, thumbImagePath, probeData, media_encoding.ThumbnailRendition().Size)
 		if err != nil {
 			return []*models.MediaURL{}, errors.Wrapf(err, "failed to generate thumbnail for video (%s)", video.Title)
 		}
+		media_encoding.EncodeAlternativeFormats(thumbImagePath, media_encoding.ThumbnailRendition().Quality)
 
 		thumbDimensions, err := media_encoding.GetPhotoDimensions(thumbImagePath)
 		if err != nil {
@@ -175,10 +212,11 @@ func (t ProcessVideoTask) ProcessMedia(ctx scanner_task.TaskContext, mediaData *
 			log.Info(ctx, "Video thumbnail found in database but not in cache, re-encoding video thumbnail to cache", "video", videoThumbnailURL.MediaName)
 			updatedURLs = append(updatedURLs, videoThumbnailURL)
 
-			err = executable_worker.Ffmpeg.EncodeVideoThumbnail(video.Path, thumbImagePath, probeData)
+			err = executable_worker.Ffmpeg.EncodeVideoThumbnail(video.Path, thumbImagePath, probeData, media_encoding.ThumbnailRendition().Size)
 			if err != nil {
 				return []*models.MediaURL{}, errors.Wrapf(err, "failed to generate thumbnail for video (%s)", video.Title)
 			}
+			media_encoding.EncodeAlternativeFormats(thumbImagePath, media_encoding.ThumbnailRendition().Quality)
 
 			thumbDimensions, err := media_encoding.GetPhotoDimensions(thumbImagePath)
 			if err != nil {
@@ -200,9 +238,144 @@ func (t ProcessVideoTask) ProcessMedia(ctx scanner_task.TaskContext, mediaData *
 		}
 	}
 
+	if utils.EnvVideoPreviews.GetBool() {
+		previewURL, err := processVideoPreview(ctx, video, videoPreviewURL, probeData, mediaCachePath)
+		if err != nil {
+			return []*models.MediaURL{}, err
+		}
+
+		if previewURL != nil {
+			updatedURLs = append(updatedURLs, previewURL)
+		}
+	}
+
 	return updatedURLs, nil
 }
 
+const (
+	// videoPreviewSize is the max width and height of video previews in pixels
+	videoPreviewSize = 320
+	// videoPreviewClips is the number of parts sampled across the video for the preview
+	videoPreviewClips = 4
+	// videoPreviewClipSeconds is the length of every sampled part of the preview
+	videoPreviewClipSeconds = 1.5
+)
+
+// processVideoPreview encodes the preview clip of the video if it is missing from the database or the cache,
+// it returns the media url if it was encoded
+func processVideoPreview(ctx scanner_task.TaskContext, video *models.Media, previewURL *models.MediaURL, probeData *ffprobe.ProbeData, mediaCachePath string) (*models.MediaURL, error) {
+	if previewURL != nil {
+		// Verify that the video preview still exists in cache
+		if _, err := os.Stat(path.Join(mediaCachePath, previewURL.MediaName)); !os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		log.Info(ctx, "Video preview found in database but not in cache, re-encoding video preview to cache", "video", previewURL.MediaName)
+	}
+
+	previewName := generateUniqueMediaNamePrefixed("video_preview", video.Path, ".mp4")
+	if previewURL != nil {
+		previewName = previewURL.MediaName
+	}
+	previewPath := path.Join(mediaCachePath, previewName)
+
+	err := executable_worker.Ffmpeg.EncodeVideoPreview(video.Path, previewPath, probeData, videoPreviewSize, videoPreviewClips, videoPreviewClipSeconds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to generate preview for video (%s)", video.Title)
+	}
+
+	previewMetadata, err := ReadVideoStreamMetadata(previewPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read metadata for video preview (%s)", video.Title)
+	}
+
+	fileStats, err := os.Stat(previewPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading file stats of video preview")
+	}
+
+	if previewURL == nil {
+		previewURL = &models.MediaURL{
+			MediaID:     video.ID,
+			MediaName:   previewName,
+			Purpose:     models.VideoPreview,
+			ContentType: "video/mp4",
+		}
+	}
+
+	previewURL.Width = previewMetadata.Width
+	previewURL.Height = previewMetadata.Height
+	previewURL.FileSize = fileStats.Size()
+
+	if err := ctx.GetDB().Save(previewURL).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to save video preview into database (%s)", video.Title)
+	}
+
+	return previewURL, nil
+}
+
+// processVideoHLS encodes the HLS stream of the video if it is missing from the database or the cache,
+// it returns the media url if it was encoded
+func processVideoHLS(ctx scanner_task.TaskContext, video *models.Media, hlsURL *models.MediaURL, mediaCachePath string) (*models.MediaURL, error) {
+	if hlsURL != nil {
+		// Verify that the HLS stream still exists in cache
+		masterPath := path.Join(mediaCachePath, hlsURL.MediaName, models.HLSMasterPlaylist)
+		if _, err := os.Stat(masterPath); !os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		log.Info(ctx, "HLS stream found in database but not in cache, re-encoding video to cache", "video", hlsURL.MediaName)
+	}
+
+	sourceMetadata, err := ReadVideoStreamMetadata(video.Path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read metadata for HLS stream of video (%s)", video.Title)
+	}
+
+	hlsName := generateUniqueMediaNamePrefixed("hls", video.Path, "")
+	if hlsURL != nil {
+		hlsName = hlsURL.MediaName
+	}
+	hlsPath := path.Join(mediaCachePath, hlsName)
+
+	// Remove segments of a partially encoded stream
+	if err := os.RemoveAll(hlsPath); err != nil {
+		return nil, errors.Wrap(err, "remove old HLS stream of video")
+	}
+
+	hlsDimension, err := media_encoding.EncodeHLS(video.Path, hlsPath, media_encoding.Dimension{
+		Width:  sourceMetadata.Width,
+		Height: sourceMetadata.Height,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not encode HLS stream (%s)", video.Path)
+	}
+
+	hlsSize, err := directorySize(hlsPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading size of HLS stream")
+	}
+
+	if hlsURL == nil {
+		hlsURL = &models.MediaURL{
+			MediaID:     video.ID,
+			MediaName:   hlsName,
+			Purpose:     models.VideoHLS,
+			ContentType: "application/vnd.apple.mpegurl",
+		}
+	}
+
+	hlsURL.Width = hlsDimension.Width
+	hlsURL.Height = hlsDimension.Height
+	hlsURL.FileSize = hlsSize
+
+	if err := ctx.GetDB().Save(hlsURL).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to save HLS stream into database (%s)", video.Title)
+	}
+
+	return hlsURL, nil
+}
+
 func ReadVideoMetadata(videoPath string) (*ffprobe.ProbeData, error) {
 	ctx, cancelFn := context.WithTimeout(context.Background(), utils.MediaProbeTimeout())
 	defer cancelFn()
@@ -95,3 +95,44 @@ func generateSaveThumbnailJPEG(tx *gorm.DB, media *models.Media, thumbnailName s
 
 	return mediaURL, nil
 }
+
+func generateSaveRenditionJPEG(tx *gorm.DB, media *models.Media, rendition media_encoding.Rendition, renditionName string, photoCachePath string, baseImagePath string, mediaURL *models.MediaURL) (*models.MediaURL, error) {
+	renditionOutputPath := path.Join(photoCachePath, renditionName)
+
+	renditionSize, err := media_encoding.EncodeRendition(baseImagePath, renditionOutputPath, rendition)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not create %s rendition cached image", rendition.Name)
+	}
+
+	fileStats, err := os.Stat(renditionOutputPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading file stats of %s rendition photo", rendition.Name)
+	}
+
+	if mediaURL == nil {
+
+		mediaURL = &models.MediaURL{
+			MediaID:     media.ID,
+			MediaName:   renditionName,
+			Width:       renditionSize.Width,
+			Height:      renditionSize.Height,
+			Purpose:     models.RenditionPurpose(rendition.Name),
+			ContentType: "image/jpeg",
+			FileSize:    fileStats.Size(),
+		}
+
+		if err := tx.Create(&mediaURL).Error; err != nil {
+			return nil, errors.Wrapf(err, "could not insert %s rendition media url (%d, %s)", rendition.Name, media.ID, renditionName)
+		}
+	} else {
+		mediaURL.Width = renditionSize.Width
+		mediaURL.Height = renditionSize.Height
+		mediaURL.FileSize = fileStats.Size()
+
+		if err := tx.Save(&mediaURL).Error; err != nil {
+			return nil, errors.Wrapf(err, "could not update %s rendition media url (%d, %s)", rendition.Name, media.ID, renditionName)
+		}
+	}
+
+	return mediaURL, nil
+}
@@ -50,6 +50,28 @@ func generateUniqueMediaName(mediaPath string) string {
 	return mediaName
 }
 
+// directorySize returns the total size of the files in a directory
+func directorySize(dirPath string) (int64, error) {
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return 0, err
+	}
+
+	var size int64
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return 0, err
+		}
+
+		if !info.IsDir() {
+			size += info.Size()
+		}
+	}
+
+	return size, nil
+}
+
 func saveOriginalPhotoToDB(tx *gorm.DB, photo *models.Media, imageData *media_encoding.EncodeMediaData, photoDimensions media_encoding.Dimension) (*models.MediaURL, error) {
 	originalImageName := generateUniqueMediaName(photo.Path)
 
@@ -1,19 +1,14 @@
 package processing_tasks
 
 import (
-	"crypto/md5"
-	"encoding/hex"
-	"fmt"
-	"io"
-	"log"
 	"os"
 	"path"
 
 	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/log"
 	"github.com/photoview/photoview/api/scanner/media_encoding"
-	"github.com/photoview/photoview/api/scanner/media_type"
 	"github.com/photoview/photoview/api/scanner/scanner_task"
-	"github.com/photoview/photoview/api/scanner/scanner_utils"
+	"github.com/photoview/photoview/api/scanner/xmp_sidecar"
 	"github.com/pkg/errors"
 )
 
@@ -22,34 +17,26 @@ type SidecarTask struct {
 }
 
 func (t SidecarTask) AfterMediaFound(ctx scanner_task.TaskContext, media *models.Media, newMedia bool) error {
-	if media.Type != models.MediaTypePhoto || !newMedia {
+	if !newMedia {
 		return nil
 	}
 
-	mediaType := ctx.GetCache().GetMediaType(media.Path)
-	if mediaType == media_type.TypeUnknown {
-		return fmt.Errorf("scan for sidecar file %s failed: media type is %s", media.Path, mediaType)
-	}
-
-	if mediaType.IsWebCompatible() {
+	sideCarPath := xmp_sidecar.Find(media.Path)
+	if sideCarPath == nil {
 		return nil
 	}
 
-	var sideCarPath *string = nil
-	var sideCarHash *string = nil
-
-	sideCarPath = scanForSideCarFile(media.Path)
-	if sideCarPath != nil {
-		sideCarHash = hashSideCarFile(sideCarPath)
-	}
-
 	// Add sidecar data to media
 	media.SideCarPath = sideCarPath
-	media.SideCarHash = sideCarHash
+	media.SideCarHash = xmp_sidecar.Hash(sideCarPath)
 	if err := ctx.GetDB().Save(media).Error; err != nil {
 		return errors.Wrapf(err, "update media sidecar info (%s)", *sideCarPath)
 	}
 
+	if err := xmp_sidecar.Import(ctx.GetDB(), media); err != nil {
+		log.Warn(ctx, "Failed to import XMP sidecar metadata", "media", media.Path, "sidecar", *sideCarPath, "error", err)
+	}
+
 	return nil
 }
 
@@ -59,18 +46,14 @@ func (t SidecarTask) ProcessMedia(ctx scanner_task.TaskContext, mediaData *media
 		return []*models.MediaURL{}, errors.Wrap(err, "sidecar task, process media")
 	}
 
-	if mediaType.IsWebCompatible() {
-		return []*models.MediaURL{}, nil
-	}
-
 	photo := mediaData.Media
 
 	sideCarFileHasChanged := false
 	var currentFileHash *string
-	currentSideCarPath := scanForSideCarFile(photo.Path)
+	currentSideCarPath := xmp_sidecar.Find(photo.Path)
 
 	if currentSideCarPath != nil {
-		currentFileHash = hashSideCarFile(currentSideCarPath)
+		currentFileHash = xmp_sidecar.Hash(currentSideCarPath)
 		if photo.SideCarHash == nil || *photo.SideCarHash != *currentFileHash {
 			sideCarFileHasChanged = true
 		}
@@ -82,7 +65,23 @@ func (t SidecarTask) ProcessMedia(ctx scanner_task.TaskContext, mediaData *media
 		return []*models.MediaURL{}, nil
 	}
 
-	fmt.Printf("Detected changed sidecar file for %s recreating JPG's to reflect changes\n", photo.Path)
+	photo.SideCarHash = currentFileHash
+	photo.SideCarPath = currentSideCarPath
+
+	if err := xmp_sidecar.Import(ctx.GetDB(), photo); err != nil {
+		log.Warn(ctx, "Failed to import XMP sidecar metadata", "media", photo.Path, "error", err)
+	}
+
+	// Only photos that are converted for the browser are rendered with the sidecar edits
+	if photo.Type != models.MediaTypePhoto || mediaType.IsWebCompatible() {
+		if err := ctx.GetDB().Save(&photo).Error; err != nil {
+			return []*models.MediaURL{}, errors.Wrapf(err, "could not update side car hash for media: %s", photo.Path)
+		}
+
+		return []*models.MediaURL{}, nil
+	}
+
+	log.Info(ctx, "Detected changed sidecar file, recreating JPGs to reflect changes", "media", photo.Path)
 
 	highResURL, err := photo.GetHighRes()
 	if err != nil {
@@ -116,9 +115,6 @@ func (t SidecarTask) ProcessMedia(ctx scanner_task.TaskContext, mediaData *media
 	}
 	os.Remove(tempThumbPath)
 
-	photo.SideCarHash = currentFileHash
-	photo.SideCarPath = currentSideCarPath
-
 	// save new side car hash
 	if err := ctx.GetDB().Save(&photo).Error; err != nil {
 		return []*models.MediaURL{}, errors.Wrapf(err, "could not update side car hash for media: %s", photo.Path)
@@ -129,32 +125,3 @@ func (t SidecarTask) ProcessMedia(ctx scanner_task.TaskContext, mediaData *media
 		updatedHighRes,
 	}, nil
 }
-
-func scanForSideCarFile(path string) *string {
-	testPath := path + ".xmp"
-
-	if scanner_utils.FileExists(testPath) {
-		return &testPath
-	}
-
-	return nil
-}
-
-func hashSideCarFile(path *string) *string {
-	if path == nil {
-		return nil
-	}
-
-	f, err := os.Open(*path)
-	if err != nil {
-		log.Printf("ERROR: %s", err)
-	}
-	defer f.Close()
-
-	h := md5.New()
-	if _, err := io.Copy(h, f); err != nil {
-		log.Printf("ERROR: %s", err)
-	}
-	hash := hex.EncodeToString(h.Sum(nil))
-	return &hash
-}
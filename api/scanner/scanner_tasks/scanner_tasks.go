@@ -21,9 +21,13 @@ var allTasks []scanner_task.ScannerTask = []scanner_task.ScannerTask{
 	processing_tasks.ProcessVideoTask{},
 	FaceDetectionTask{},
 	BlurhashTask{},
+	PerceptualHashTask{},
 	ExifTask{},
+	GeocodeTask{},
+	SearchIndexTask{},
 	VideoMetadataTask{},
 	cleanup_tasks.MediaCleanupTask{},
+	StackTask{},
 }
 
 type scannerTasks struct {
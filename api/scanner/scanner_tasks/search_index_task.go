@@ -0,0 +1,69 @@
+package scanner_tasks
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/scanner/media_encoding"
+	"github.com/photoview/photoview/api/scanner/scanner_task"
+)
+
+// SearchIndexTask updates the search documents of the media of an album that were found or processed while scanning,
+// after the album has been scanned, so the search index has the metadata and people found while scanning.
+// Media that didn't change keep their documents, changes made outside the scanner update the documents themselves.
+type SearchIndexTask struct {
+	scanner_task.ScannerTaskBase
+}
+
+type searchIndexTaskKey string
+
+const mediaToIndexKey searchIndexTaskKey = "media_to_index_key"
+
+// mediaToIndex holds the ids of the media of the album whose search documents must be updated
+type mediaToIndex struct {
+	mutex    sync.Mutex
+	mediaIDs []int
+}
+
+func (c *mediaToIndex) add(mediaID int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.mediaIDs = append(c.mediaIDs, mediaID)
+}
+
+func getMediaToIndex(ctx scanner_task.TaskContext) *mediaToIndex {
+	return ctx.Value(mediaToIndexKey).(*mediaToIndex)
+}
+
+func (t SearchIndexTask) BeforeScanAlbum(ctx scanner_task.TaskContext) (scanner_task.TaskContext, error) {
+	return ctx.WithValue(mediaToIndexKey, &mediaToIndex{}), nil
+}
+
+func (t SearchIndexTask) AfterMediaFound(ctx scanner_task.TaskContext, media *models.Media, newMedia bool) error {
+	if newMedia {
+		getMediaToIndex(ctx).add(media.ID)
+	}
+
+	return nil
+}
+
+func (t SearchIndexTask) AfterProcessMedia(ctx scanner_task.TaskContext, mediaData *media_encoding.EncodeMediaData,
+	updatedURLs []*models.MediaURL, mediaIndex int, mediaTotal int) error {
+
+	// Processed media may have new faces
+	if len(updatedURLs) > 0 {
+		getMediaToIndex(ctx).add(mediaData.Media.ID)
+	}
+
+	return nil
+}
+
+func (t SearchIndexTask) AfterScanAlbum(ctx scanner_task.TaskContext, changedMedia []*models.Media, albumMedia []*models.Media) error {
+	if err := models.UpdateMediaSearchDocuments(ctx.GetDB(), getMediaToIndex(ctx).mediaIDs); err != nil {
+		return fmt.Errorf("update search index of album %q: %w", ctx.GetAlbum().Path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,237 @@
+package scanner_tasks
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/scanner/scanner_task"
+	"github.com/photoview/photoview/api/utils"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// StackTask groups the photos of an album into stacks of burst and bracketing frames, after the album has been scanned
+type StackTask struct {
+	scanner_task.ScannerTaskBase
+}
+
+func (t StackTask) AfterScanAlbum(ctx scanner_task.TaskContext, changedMedia []*models.Media, albumMedia []*models.Media) error {
+	if utils.EnvDisableMediaStacks.GetBool() {
+		return nil
+	}
+
+	if err := UpdateAlbumStacks(ctx.GetDB(), ctx.GetAlbum().ID, utils.StackTimeWindow()); err != nil {
+		return fmt.Errorf("update media stacks of album %q: %w", ctx.GetAlbum().Path, err)
+	}
+
+	return nil
+}
+
+// UpdateAlbumStacks regroups the photos of the album into stacks, leaving the media of manual stacks as they are.
+// Existing stacks are reused where possible, so the covers picked by users are kept.
+func UpdateAlbumStacks(db *gorm.DB, albumID int, window time.Duration) error {
+	var media []*models.Media
+	if err := db.Preload("Exif").
+		Where("album_id = ? AND type = ?", albumID, models.MediaTypePhoto).
+		Where("stack_id IS NULL OR stack_id NOT IN (?)", db.Model(&models.MediaStack{}).Select("id").Where("manual")).
+		Order("date_shot, id").
+		Find(&media).Error; err != nil {
+		return errors.Wrap(err, "get media of album")
+	}
+
+	groups := groupStacks(media, window)
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var autoStacks []*models.MediaStack
+		if err := tx.Where("album_id = ? AND NOT manual", albumID).Find(&autoStacks).Error; err != nil {
+			return errors.Wrap(err, "get media stacks of album")
+		}
+
+		stacksByID := make(map[int]*models.MediaStack, len(autoStacks))
+		for _, stack := range autoStacks {
+			stacksByID[stack.ID] = stack
+		}
+
+		usedStacks := make(map[int]bool)
+		stackedMedia := make(map[int]bool)
+
+		for _, group := range groups {
+			var stack *models.MediaStack
+			for _, m := range group {
+				if m.StackID == nil {
+					continue
+				}
+
+				if existing, found := stacksByID[*m.StackID]; found && !usedStacks[existing.ID] {
+					stack = existing
+					break
+				}
+			}
+
+			ids := make([]int, 0, len(group))
+			inGroup := make(map[int]bool, len(group))
+			for _, m := range group {
+				ids = append(ids, m.ID)
+				inGroup[m.ID] = true
+				stackedMedia[m.ID] = true
+			}
+
+			if stack == nil {
+				stack = &models.MediaStack{AlbumID: albumID, CoverID: group[0].ID}
+				if err := tx.Create(stack).Error; err != nil {
+					return errors.Wrap(err, "create media stack")
+				}
+			} else if !inGroup[stack.CoverID] {
+				stack.CoverID = group[0].ID
+				if err := tx.Model(stack).Update("cover_id", stack.CoverID).Error; err != nil {
+					return errors.Wrap(err, "update cover of media stack")
+				}
+			}
+			usedStacks[stack.ID] = true
+
+			if err := tx.Model(&models.Media{}).
+				Where("id IN ?", ids).
+				Where("stack_id IS NULL OR stack_id <> ?", stack.ID).
+				UpdateColumn("stack_id", stack.ID).Error; err != nil {
+				return errors.Wrap(err, "add media to stack")
+			}
+		}
+
+		// Media that are no longer part of a burst
+		unstackIDs := make([]int, 0)
+		for _, m := range media {
+			if m.StackID != nil && !stackedMedia[m.ID] {
+				unstackIDs = append(unstackIDs, m.ID)
+			}
+		}
+
+		if len(unstackIDs) > 0 {
+			if err := tx.Model(&models.Media{}).Where("id IN ?", unstackIDs).UpdateColumn("stack_id", nil).Error; err != nil {
+				return errors.Wrap(err, "remove media from stack")
+			}
+		}
+
+		unusedStackIDs := make([]int, 0)
+		for _, stack := range autoStacks {
+			if !usedStacks[stack.ID] {
+				unusedStackIDs = append(unusedStackIDs, stack.ID)
+			}
+		}
+
+		if len(unusedStackIDs) > 0 {
+			if err := tx.Where("id IN ?", unusedStackIDs).Delete(&models.MediaStack{}).Error; err != nil {
+				return errors.Wrap(err, "delete unused media stacks")
+			}
+		}
+
+		return repairManualStacks(tx, albumID)
+	})
+}
+
+// repairManualStacks deletes manual stacks whose media have all been removed,
+// and picks a new cover for the ones that lost their cover
+func repairManualStacks(tx *gorm.DB, albumID int) error {
+	var manualStacks []*models.MediaStack
+	if err := tx.Where("album_id = ? AND manual", albumID).Find(&manualStacks).Error; err != nil {
+		return errors.Wrap(err, "get manual media stacks of album")
+	}
+
+	for _, stack := range manualStacks {
+		media, err := stack.GetMedia(tx)
+		if err != nil {
+			return errors.Wrap(err, "get media of stack")
+		}
+
+		if len(media) == 0 {
+			if err := tx.Delete(stack).Error; err != nil {
+				return errors.Wrap(err, "delete empty media stack")
+			}
+			continue
+		}
+
+		hasCover := false
+		for _, m := range media {
+			if m.ID == stack.CoverID {
+				hasCover = true
+				break
+			}
+		}
+
+		if !hasCover {
+			if err := tx.Model(stack).Update("cover_id", media[0].ID).Error; err != nil {
+				return errors.Wrap(err, "update cover of media stack")
+			}
+		}
+	}
+
+	return nil
+}
+
+// groupStacks groups photos, ordered by the time they were shot, into stacks of at least two frames.
+// Frames with the same burst id are stacked together. Other frames are stacked with the previous frame of the same camera,
+// if their sequence number continues it, or if they have no sequence number and were shot within the time window.
+func groupStacks(media []*models.Media, window time.Duration) [][]*models.Media {
+	candidates := make([][]*models.Media, 0)
+	bursts := make(map[string]int)
+
+	var sequence []*models.Media
+	endSequence := func() {
+		if len(sequence) > 0 {
+			candidates = append(candidates, sequence)
+		}
+		sequence = nil
+	}
+
+	for _, m := range media {
+		if m.Exif != nil && m.Exif.BurstID != nil && *m.Exif.BurstID != "" {
+			if index, found := bursts[*m.Exif.BurstID]; found {
+				candidates[index] = append(candidates[index], m)
+			} else {
+				bursts[*m.Exif.BurstID] = len(candidates)
+				candidates = append(candidates, []*models.Media{m})
+			}
+			continue
+		}
+
+		if len(sequence) > 0 && continuesStack(sequence[len(sequence)-1], m, window) {
+			sequence = append(sequence, m)
+			continue
+		}
+
+		endSequence()
+		sequence = []*models.Media{m}
+	}
+	endSequence()
+
+	groups := make([][]*models.Media, 0)
+	for _, group := range candidates {
+		if len(group) > 1 {
+			groups = append(groups, group)
+		}
+	}
+
+	return groups
+}
+
+// continuesStack returns true if `next` is the frame after `prev` in a burst or bracketing sequence
+func continuesStack(prev *models.Media, next *models.Media, window time.Duration) bool {
+	if prev.Exif == nil || next.Exif == nil {
+		return false
+	}
+
+	if !sameString(prev.Exif.Maker, next.Exif.Maker) || !sameString(prev.Exif.Camera, next.Exif.Camera) {
+		return false
+	}
+
+	if prev.Exif.SequenceNumber != nil && next.Exif.SequenceNumber != nil {
+		return *next.Exif.SequenceNumber > 1 && *next.Exif.SequenceNumber == *prev.Exif.SequenceNumber+1
+	}
+
+	return window > 0 && next.DateShot.Sub(prev.DateShot) <= window
+}
+
+// sameString returns true if both strings are set and equal
+func sameString(a *string, b *string) bool {
+	return a != nil && b != nil && *a == *b
+}
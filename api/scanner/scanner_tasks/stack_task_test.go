@@ -0,0 +1,130 @@
+package scanner_tasks_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/scanner/scanner_tasks"
+	"github.com/photoview/photoview/api/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateAlbumStacks(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	album := models.Album{
+		Title: "album",
+		Path:  "/photos",
+	}
+	require.NoError(t, db.Save(&album).Error)
+
+	str := func(value string) *string { return &value }
+	num := func(value int64) *int64 { return &value }
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	photo := func(title string, offset time.Duration, exif *models.MediaEXIF) models.Media {
+		return models.Media{
+			Title:    title,
+			Path:     "/photos/" + title,
+			AlbumID:  album.ID,
+			Type:     models.MediaTypePhoto,
+			DateShot: start.Add(offset),
+			Exif:     exif,
+		}
+	}
+
+	camera := func() *models.MediaEXIF {
+		return &models.MediaEXIF{Maker: str("Canon"), Camera: str("EOS R5")}
+	}
+	sequence := func(number int64) *models.MediaEXIF {
+		exif := camera()
+		exif.SequenceNumber = num(number)
+		return exif
+	}
+	burst := func(id string) *models.MediaEXIF {
+		return &models.MediaEXIF{Maker: str("Apple"), Camera: str("iPhone 15"), BurstID: str(id)}
+	}
+
+	media := []models.Media{
+		photo("burst_1", 0, burst("A")),
+		photo("burst_2", 10*time.Second, burst("A")),
+		photo("timed_1", time.Minute, camera()),
+		photo("timed_2", time.Minute+500*time.Millisecond, camera()),
+		photo("bracket_1", 2*time.Minute, sequence(1)),
+		photo("bracket_2", 2*time.Minute+3*time.Second, sequence(2)),
+		photo("bracket_3", 2*time.Minute+6*time.Second, sequence(3)),
+		photo("single", 2*time.Minute+6500*time.Millisecond, sequence(0)),
+		photo("alone", 3*time.Minute, camera()),
+		photo("no_exif", 3*time.Minute+100*time.Millisecond, nil),
+	}
+	require.NoError(t, db.Save(&media).Error)
+
+	stackTitles := func() map[string][]string {
+		var stacks []*models.MediaStack
+		require.NoError(t, db.Order("id").Find(&stacks).Error)
+
+		result := make(map[string][]string)
+		for _, stack := range stacks {
+			stackMedia, err := stack.GetMedia(db)
+			require.NoError(t, err)
+
+			var cover models.Media
+			require.NoError(t, db.First(&cover, stack.CoverID).Error)
+
+			titles := make([]string, 0, len(stackMedia))
+			for _, m := range stackMedia {
+				titles = append(titles, m.Title)
+			}
+			result[cover.Title] = titles
+		}
+
+		return result
+	}
+
+	require.NoError(t, scanner_tasks.UpdateAlbumStacks(db, album.ID, time.Second))
+
+	assert.Equal(t, map[string][]string{
+		"burst_1":   {"burst_1", "burst_2"},
+		"timed_1":   {"timed_1", "timed_2"},
+		"bracket_1": {"bracket_1", "bracket_2", "bracket_3"},
+	}, stackTitles())
+
+	t.Run("keep stacks and covers on rescan", func(t *testing.T) {
+		var stack models.MediaStack
+		require.NoError(t, db.Where("cover_id = ?", media[4].ID).First(&stack).Error)
+		require.NoError(t, db.Model(&stack).Update("cover_id", media[5].ID).Error)
+
+		require.NoError(t, scanner_tasks.UpdateAlbumStacks(db, album.ID, time.Second))
+
+		var rescanned models.MediaStack
+		require.NoError(t, db.First(&rescanned, stack.ID).Error)
+		assert.Equal(t, media[5].ID, rescanned.CoverID)
+		assert.Len(t, stackTitles(), 3)
+	})
+
+	t.Run("disable stacking by time", func(t *testing.T) {
+		require.NoError(t, scanner_tasks.UpdateAlbumStacks(db, album.ID, 0))
+
+		stacks := stackTitles()
+		assert.Len(t, stacks, 2)
+		assert.NotContains(t, stacks, "timed_1")
+
+		var timed models.Media
+		require.NoError(t, db.First(&timed, media[2].ID).Error)
+		assert.Nil(t, timed.StackID)
+	})
+
+	t.Run("keep manual stacks", func(t *testing.T) {
+		manual := models.MediaStack{AlbumID: album.ID, CoverID: media[3].ID, Manual: true}
+		require.NoError(t, db.Create(&manual).Error)
+		require.NoError(t, db.Model(&models.Media{}).Where("id = ?", media[3].ID).UpdateColumn("stack_id", manual.ID).Error)
+
+		require.NoError(t, scanner_tasks.UpdateAlbumStacks(db, album.ID, time.Second))
+
+		stacks := stackTitles()
+		assert.Equal(t, []string{"timed_2"}, stacks["timed_2"])
+		assert.NotContains(t, stacks, "timed_1")
+	})
+}
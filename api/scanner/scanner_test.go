@@ -41,6 +41,7 @@ func TestFullScan(t *testing.T) {
 		"webp.webp",
 
 		"jpg_with_file.jpg",
+		"live_photo.jpg",
 		"recoverable_bad_rst_marker.jpg",
 		"standalone_jpg.jpg",
 
@@ -57,6 +58,10 @@ func TestFullScan(t *testing.T) {
 		"left_arrow_normal_web.jpg",
 		"up_arrow_90cw_web.jpg",
 	}
+	// The QuickTime clips of Live Photos are saved as motion videos of their stills, instead of as videos
+	wantMotionPhotos := []string{
+		"live_photo.jpg",
+	}
 	wantNonWebPhotos := []string{
 		"heif.heif",
 		"jpegxl.jxl",
@@ -154,6 +159,10 @@ func TestFullScan(t *testing.T) {
 			want = append(want, "thumbnail_"+strings.ReplaceAll(name, ".", "_")+".jpg")
 		}
 
+		for _, name := range wantMotionPhotos {
+			want = append(want, "motion_video_"+strings.ReplaceAll(name, ".", "_")+".mp4")
+		}
+
 		want = append(want, wantNonWebPhotos...)
 		for _, name := range wantNonWebPhotos {
 			want = append(want, "thumbnail_"+strings.ReplaceAll(name, ".", "_")+".jpg")
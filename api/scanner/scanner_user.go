@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"path"
+	"sort"
 
 	"github.com/photoview/photoview/api/graphql/models"
 	"github.com/photoview/photoview/api/scanner/scanner_cache"
@@ -64,12 +65,6 @@ func FindAlbumsForUser(db *gorm.DB, user *models.User, albumCache *scanner_cache
 
 	scanErrors := make([]error, 0)
 
-	type scanInfo struct {
-		path   string
-		parent *models.Album
-		ignore []string
-	}
-
 	scanQueue := list.New()
 
 	for _, album := range userRootAlbums {
@@ -89,7 +84,101 @@ func FindAlbumsForUser(db *gorm.DB, user *models.User, albumCache *scanner_cache
 		}
 	}
 
-	userAlbums := make([]*models.Album, 0)
+	userAlbums, walkErrors := walkAlbumDirectories(db, user, scanQueue, albumCache)
+	scanErrors = append(scanErrors, walkErrors...)
+
+	deleteErrors := cleanup_tasks.DeleteOldUserAlbums(db, userAlbums, user)
+	scanErrors = append(scanErrors, deleteErrors...)
+
+	return userAlbums, scanErrors
+}
+
+// FindAlbumsForAlbum walks the directory tree of a single album, creating albums for new sub-directories
+// and deleting the sub-albums whose directories no longer exist.
+// Unlike FindAlbumsForUser it does not look at any other albums, which makes it cheap enough to run
+// whenever the content of a single directory changes.
+func FindAlbumsForAlbum(db *gorm.DB, album *models.Album, albumCache *scanner_cache.AlbumScannerCache) ([]*models.Album, []error) {
+	if _, err := os.Stat(album.Path); err != nil {
+		return nil, []error{errors.Wrapf(err, "read album directory (%s)", album.Path)}
+	}
+
+	parentIgnore, err := parentAlbumsIgnore(db, album)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	scanQueue := list.New()
+	scanQueue.PushBack(scanInfo{
+		path:   album.Path,
+		parent: nil,
+		ignore: parentIgnore,
+	})
+
+	albums, scanErrors := walkAlbumDirectories(db, nil, scanQueue, albumCache)
+
+	deleteErrors := cleanup_tasks.DeleteOldSubAlbums(db, album, albums)
+	scanErrors = append(scanErrors, deleteErrors...)
+
+	return albums, scanErrors
+}
+
+// LoadAlbumIgnore reads the ignore rules that apply to the given album, from its own directory
+// and all parent directories, and stores them in the album cache.
+// This must be done before the album can be scanned with a fresh cache.
+func LoadAlbumIgnore(db *gorm.DB, album *models.Album, albumCache *scanner_cache.AlbumScannerCache) error {
+	albumIgnore, err := parentAlbumsIgnore(db, album)
+	if err != nil {
+		return err
+	}
+
+	photoviewIgnore, err := getPhotoviewIgnore(album.Path)
+	if err != nil {
+		log.Printf("Failed to get ignore file, err = %s", err)
+	} else {
+		albumIgnore = append(albumIgnore, photoviewIgnore...)
+	}
+
+	albumCache.InsertAlbumIgnore(album.Path, albumIgnore)
+	return nil
+}
+
+// parentAlbumsIgnore collects the ignore rules of all the parent albums, starting from the root album.
+func parentAlbumsIgnore(db *gorm.DB, album *models.Album) ([]string, error) {
+	parents, err := album.GetParents(db, func(query *gorm.DB) *gorm.DB {
+		return query.Where("id != ?", album.ID)
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "get parent albums (%s)", album.Path)
+	}
+
+	sort.Slice(parents, func(i, j int) bool {
+		return len(parents[i].Path) < len(parents[j].Path)
+	})
+
+	var albumIgnore []string
+	for _, parent := range parents {
+		photoviewIgnore, err := getPhotoviewIgnore(parent.Path)
+		if err != nil {
+			log.Printf("Failed to get ignore file, err = %s", err)
+			continue
+		}
+		albumIgnore = append(albumIgnore, photoviewIgnore...)
+	}
+
+	return albumIgnore, nil
+}
+
+type scanInfo struct {
+	path   string
+	parent *models.Album
+	ignore []string
+}
+
+// walkAlbumDirectories walks the directories of the scan queue breadth first, and creates or updates the album of each.
+// If user is not nil, the user will be added as an owner of every album found.
+func walkAlbumDirectories(db *gorm.DB, user *models.User, scanQueue *list.List, albumCache *scanner_cache.AlbumScannerCache) ([]*models.Album, []error) {
+	scanErrors := make([]error, 0)
+	foundAlbums := make([]*models.Album, 0)
 
 	for scanQueue.Front() != nil {
 		albumInfo := scanQueue.Front().Value.(scanInfo)
@@ -148,17 +237,30 @@ func FindAlbumsForUser(db *gorm.DB, user *models.User, albumCache *scanner_cache
 					}
 				}
 
-				album = &models.Album{
-					Title:         albumTitle,
-					ParentAlbumID: albumParentID,
-					Path:          albumPath,
-				}
-
 				// Store album ignore
 				albumCache.InsertAlbumIgnore(albumPath, albumIgnore)
 
-				if err := tx.Create(&album).Error; err != nil {
-					return errors.Wrap(err, "insert album into database")
+				// Keep the existing album, if the directory was moved or renamed
+				movedAlbum, err := findMovedAlbum(tx, albumPath, albumCache)
+				if err != nil {
+					return err
+				}
+
+				if movedAlbum != nil {
+					album = movedAlbum
+					if err := moveAlbum(tx, album, albumPath, albumParentID); err != nil {
+						return err
+					}
+				} else {
+					album = &models.Album{
+						Title:         albumTitle,
+						ParentAlbumID: albumParentID,
+						Path:          albumPath,
+					}
+
+					if err := tx.Create(&album).Error; err != nil {
+						return errors.Wrap(err, "insert album into database")
+					}
 				}
 
 				if err := tx.Model(&album).Association("Owners").Append(parentOwners); err != nil {
@@ -168,23 +270,25 @@ func FindAlbumsForUser(db *gorm.DB, user *models.User, albumCache *scanner_cache
 				album = &albumResult[0]
 
 				// Add user as an owner of the album if not already
-				var userAlbumOwner []models.User
-				if err := tx.Model(&album).Association("Owners").Find(&userAlbumOwner, "user_albums.user_id = ?", user.ID); err != nil {
-					return err
-				}
-				if len(userAlbumOwner) == 0 {
-					newUser := models.User{}
-					newUser.ID = user.ID
-					if err := tx.Model(&album).Association("Owners").Append(&newUser); err != nil {
+				if user != nil {
+					var userAlbumOwner []models.User
+					if err := tx.Model(&album).Association("Owners").Find(&userAlbumOwner, "user_albums.user_id = ?", user.ID); err != nil {
 						return err
 					}
+					if len(userAlbumOwner) == 0 {
+						newUser := models.User{}
+						newUser.ID = user.ID
+						if err := tx.Model(&album).Association("Owners").Append(&newUser); err != nil {
+							return err
+						}
+					}
 				}
 
 				// Update album ignore
 				albumCache.InsertAlbumIgnore(albumPath, albumIgnore)
 			}
 
-			userAlbums = append(userAlbums, album)
+			foundAlbums = append(foundAlbums, album)
 
 			return nil
 		})
@@ -219,10 +323,7 @@ func FindAlbumsForUser(db *gorm.DB, user *models.User, albumCache *scanner_cache
 		}
 	}
 
-	deleteErrors := cleanup_tasks.DeleteOldUserAlbums(db, userAlbums, user)
-	scanErrors = append(scanErrors, deleteErrors...)
-
-	return userAlbums, scanErrors
+	return foundAlbums, scanErrors
 }
 
 func directoryContainsPhotos(rootPath string, cache *scanner_cache.AlbumScannerCache, albumIgnore []string) bool {
@@ -0,0 +1,69 @@
+package scanner_test
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/scanner"
+	"github.com/photoview/photoview/api/scanner/scanner_cache"
+	"github.com/photoview/photoview/api/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindAlbumsForAlbum(t *testing.T) {
+	test_utils.FilesystemTest(t)
+	db := test_utils.DatabaseTest(t)
+
+	photo, err := os.ReadFile("./test_media/real_media/jpeg.jpg")
+	require.NoError(t, err)
+
+	rootPath := t.TempDir()
+	for _, dir := range []string{"kept", "new", "ignored"} {
+		require.NoError(t, os.Mkdir(path.Join(rootPath, dir), 0755))
+		require.NoError(t, os.WriteFile(path.Join(rootPath, dir, "photo.jpg"), photo, 0644))
+	}
+	require.NoError(t, os.WriteFile(path.Join(rootPath, ".photoviewignore"), []byte("ignored/\n"), 0644))
+
+	user := models.User{Username: "user"}
+	require.NoError(t, db.Save(&user).Error)
+
+	rootAlbum := models.Album{
+		Title:  "root",
+		Path:   rootPath,
+		Owners: []models.User{user},
+	}
+	require.NoError(t, db.Create(&rootAlbum).Error)
+
+	keptAlbum := models.Album{
+		Title:         "kept",
+		Path:          path.Join(rootPath, "kept"),
+		ParentAlbumID: &rootAlbum.ID,
+	}
+	removedAlbum := models.Album{
+		Title:         "removed",
+		Path:          path.Join(rootPath, "removed"),
+		ParentAlbumID: &rootAlbum.ID,
+	}
+	require.NoError(t, db.Create(&keptAlbum).Error)
+	require.NoError(t, db.Create(&removedAlbum).Error)
+
+	albums, errs := scanner.FindAlbumsForAlbum(db, &rootAlbum, scanner_cache.MakeAlbumCache())
+	require.Empty(t, errs)
+
+	albumPaths := make([]string, len(albums))
+	for i, album := range albums {
+		albumPaths[i] = album.Path
+	}
+	assert.ElementsMatch(t, []string{rootPath, keptAlbum.Path, path.Join(rootPath, "new")}, albumPaths)
+
+	var removedCount int64
+	require.NoError(t, db.Model(&models.Album{}).Where("id = ?", removedAlbum.ID).Count(&removedCount).Error)
+	assert.EqualValues(t, 0, removedCount, "album of removed directory should be deleted")
+
+	var newAlbum models.Album
+	require.NoError(t, db.Where("path_hash = ?", models.MD5Hash(path.Join(rootPath, "new"))).First(&newAlbum).Error)
+	assert.EqualValues(t, 1, db.Model(&newAlbum).Association("Owners").Count(), "new album should inherit the owners of its parent")
+}
@@ -0,0 +1,210 @@
+// Package xmp_sidecar finds, imports and writes the XMP sidecar files of media,
+// that store the edits of tools like darktable and Lightroom next to the original files.
+package xmp_sidecar
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/log"
+	"github.com/photoview/photoview/api/scanner/externaltools/exif"
+	"github.com/photoview/photoview/api/scanner/externaltools/exiftool"
+	"github.com/photoview/photoview/api/scanner/scanner_utils"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// Find returns the path of the XMP sidecar of the media, named either `<file>.xmp` or `<basename>.xmp`
+func Find(mediaPath string) *string {
+	basePath := strings.TrimSuffix(mediaPath, path.Ext(mediaPath))
+
+	for _, testPath := range []string{
+		mediaPath + ".xmp",
+		mediaPath + ".XMP",
+		basePath + ".xmp",
+		basePath + ".XMP",
+	} {
+		if scanner_utils.FileExists(testPath) {
+			return &testPath
+		}
+	}
+
+	return nil
+}
+
+// Hash returns the MD5 hash of the content of the sidecar, used to detect changes of the sidecar
+func Hash(path *string) *string {
+	if path == nil {
+		return nil
+	}
+
+	f, err := os.Open(*path)
+	if err != nil {
+		log.Error(nil, "Failed to hash sidecar file", "path", *path, "error", err)
+	}
+	defer f.Close()
+
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		log.Error(nil, "Failed to hash sidecar file", "path", *path, "error", err)
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+	return &hash
+}
+
+// Import imports the metadata of the XMP sidecar of the media into the database,
+// or removes the imported metadata if the media no longer has a sidecar
+func Import(tx *gorm.DB, media *models.Media) error {
+	if media.SideCarPath == nil {
+		if media.XMPID == nil {
+			return nil
+		}
+
+		if err := tx.Delete(&models.MediaXMP{}, *media.XMPID).Error; err != nil {
+			return errors.Wrap(err, "delete XMP metadata of media")
+		}
+		media.XMPID = nil
+
+		return tx.Model(media).UpdateColumn("xmp_id", nil).Error
+	}
+
+	xmp, err := exif.ParseXMP(*media.SideCarPath)
+	if err != nil {
+		return errors.Wrapf(err, "parse XMP sidecar (%s)", *media.SideCarPath)
+	}
+
+	if media.XMPID != nil {
+		xmp.ID = *media.XMPID
+		return errors.Wrap(tx.Save(xmp).Error, "update XMP metadata of media")
+	}
+
+	if err := tx.Create(xmp).Error; err != nil {
+		return errors.Wrap(err, "save XMP metadata of media")
+	}
+	media.XMPID = &xmp.ID
+
+	return errors.Wrap(tx.Model(media).UpdateColumn("xmp_id", xmp.ID).Error, "link XMP metadata to media")
+}
+
+// WriteBackEnabled returns true if edits of the media in the album are written to XMP sidecars,
+// that is if writing sidecars is enabled for the album or one of its parents
+func WriteBackEnabled(db *gorm.DB, albumID int) (bool, error) {
+	enabledAlbums, err := models.GetParentsFromAlbums(db, func(query *gorm.DB) *gorm.DB {
+		return query.Where("xmp_writeback")
+	}, albumID)
+	if err != nil {
+		return false, errors.Wrap(err, "get XMP writeback setting of album")
+	}
+
+	return len(enabledAlbums) > 0, nil
+}
+
+// WriteBack writes the values of the update to the XMP sidecar of the media, if writing sidecars is enabled for its album.
+// A sidecar named `<file>.xmp` is created if the media has none, the media file itself is never modified.
+// The metadata of the sidecar is imported again afterwards, so the next scan doesn't see the sidecar as changed.
+func WriteBack(db *gorm.DB, media *models.Media, update exiftool.XMPUpdate) error {
+	enabled, err := WriteBackEnabled(db, media.AlbumID)
+	if err != nil || !enabled {
+		return err
+	}
+
+	return writeSidecar(db, media, update)
+}
+
+func writeSidecar(db *gorm.DB, media *models.Media, update exiftool.XMPUpdate) error {
+	sidecarPath := Find(media.Path)
+	if sidecarPath == nil {
+		newPath := media.Path + ".xmp"
+		sidecarPath = &newPath
+	}
+
+	if err := exif.WriteXMPSidecar(media.Path, *sidecarPath, update); err != nil {
+		return errors.Wrapf(err, "write XMP sidecar of media (%s)", media.Path)
+	}
+
+	media.SideCarPath = sidecarPath
+	media.SideCarHash = Hash(sidecarPath)
+	if err := db.Model(media).UpdateColumns(map[string]any{
+		"side_car_path": media.SideCarPath,
+		"side_car_hash": media.SideCarHash,
+	}).Error; err != nil {
+		return errors.Wrapf(err, "update sidecar of media (%s)", media.Path)
+	}
+
+	return Import(db, media)
+}
+
+// writeBackJob is an update of the XMP sidecars of media, waiting to be written
+type writeBackJob struct {
+	db       *gorm.DB
+	mediaIDs []int
+	update   func(media *models.Media) (exiftool.XMPUpdate, error)
+}
+
+// Sidecars are written by a single background worker, in the order of the edits,
+// as exiftool can take a while for many media
+var (
+	writeBackJobs        = make(chan writeBackJob, 100)
+	startWriteBackWorker sync.Once
+)
+
+// WriteBackMedia queues the update to be written to the XMP sidecars of the given media, like WriteBack.
+// It returns without waiting for the sidecars to be written, errors are logged
+// rather than returned, since the edits are already saved in the database.
+func WriteBackMedia(db *gorm.DB, mediaIDs []int, update func(media *models.Media) (exiftool.XMPUpdate, error)) {
+	startWriteBackWorker.Do(func() {
+		go func() {
+			for job := range writeBackJobs {
+				writeBackMedia(job.db, job.mediaIDs, job.update)
+			}
+		}()
+	})
+
+	// The context of the request is cancelled once the edit has been returned
+	writeBackJobs <- writeBackJob{
+		db:       db.WithContext(context.Background()),
+		mediaIDs: mediaIDs,
+		update:   update,
+	}
+}
+
+func writeBackMedia(db *gorm.DB, mediaIDs []int, update func(media *models.Media) (exiftool.XMPUpdate, error)) {
+	var media []*models.Media
+	if err := db.Where("id IN ?", mediaIDs).Find(&media).Error; err != nil {
+		log.Warn(db.Statement.Context, "Failed to get media to write XMP sidecars", "error", err)
+		return
+	}
+
+	enabledAlbums := make(map[int]bool)
+	for _, m := range media {
+		enabled, checked := enabledAlbums[m.AlbumID]
+		if !checked {
+			var err error
+			if enabled, err = WriteBackEnabled(db, m.AlbumID); err != nil {
+				log.Warn(db.Statement.Context, "Failed to check XMP writeback of album", "album_id", m.AlbumID, "error", err)
+			}
+			enabledAlbums[m.AlbumID] = enabled
+		}
+
+		if !enabled {
+			continue
+		}
+
+		values, err := update(m)
+		if err != nil {
+			log.Warn(db.Statement.Context, "Failed to get values to write to XMP sidecar", "media", m.Path, "error", err)
+			continue
+		}
+
+		if err := writeSidecar(db, m, values); err != nil {
+			log.Warn(db.Statement.Context, "Failed to write XMP sidecar", "media", m.Path, "error", err)
+		}
+	}
+}
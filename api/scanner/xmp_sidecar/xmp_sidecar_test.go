@@ -0,0 +1,111 @@
+package xmp_sidecar
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/photoview/photoview/api/graphql/models"
+	"github.com/photoview/photoview/api/scanner/externaltools/exiftool"
+	"github.com/photoview/photoview/api/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	test_utils.IntegrationTestRun(m)
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name    string
+		sidecar string
+	}{
+		{"FullFilename", "photo.cr2.xmp"},
+		{"FullFilenameUpper", "photo.cr2.XMP"},
+		{"BaseFilename", "photo.xmp"},
+		{"BaseFilenameUpper", "photo.XMP"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			mediaPath := filepath.Join(dir, "photo.cr2")
+			sidecarPath := filepath.Join(dir, tc.sidecar)
+
+			if err := os.WriteFile(sidecarPath, []byte("<x:xmpmeta/>"), 0644); err != nil {
+				t.Fatalf("write sidecar %q error: %v", sidecarPath, err)
+			}
+
+			got := Find(mediaPath)
+			if got == nil {
+				t.Fatalf("Find(%q) = nil, want: %q", mediaPath, sidecarPath)
+			}
+
+			// Case insensitive file systems find the sidecar with any case
+			if !sameFile(t, *got, sidecarPath) {
+				t.Errorf("Find(%q) = %q, want: %q", mediaPath, *got, sidecarPath)
+			}
+		})
+	}
+
+	t.Run("NoSidecar", func(t *testing.T) {
+		mediaPath := filepath.Join(t.TempDir(), "photo.cr2")
+		if got := Find(mediaPath); got != nil {
+			t.Errorf("Find(%q) = %q, want: nil", mediaPath, *got)
+		}
+	})
+}
+
+func sameFile(t *testing.T, a, b string) bool {
+	t.Helper()
+
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+
+	return os.SameFile(infoA, infoB)
+}
+
+func TestWriteBackEnabled(t *testing.T) {
+	db := test_utils.DatabaseTest(t)
+
+	root := models.Album{Title: "root", Path: "/photos"}
+	require.NoError(t, db.Save(&root).Error)
+
+	child := models.Album{Title: "child", Path: "/photos/child", ParentAlbumID: &root.ID}
+	require.NoError(t, db.Save(&child).Error)
+
+	readOnly := models.Album{Title: "read-only", Path: "/read-only"}
+	require.NoError(t, db.Save(&readOnly).Error)
+
+	require.NoError(t, db.Model(&root).Update("xmp_writeback", true).Error)
+
+	for _, album := range []models.Album{root, child} {
+		enabled, err := WriteBackEnabled(db, album.ID)
+		require.NoError(t, err)
+		assert.True(t, enabled, "writeback should be enabled for %s", album.Title)
+	}
+
+	enabled, err := WriteBackEnabled(db, readOnly.ID)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	t.Run("disabled album is left untouched", func(t *testing.T) {
+		mediaPath := filepath.Join(t.TempDir(), "photo.jpg")
+		media := models.Media{Title: "photo.jpg", Path: mediaPath, AlbumID: readOnly.ID}
+		require.NoError(t, db.Save(&media).Error)
+
+		rating := 5
+		require.NoError(t, WriteBack(db, &media, exiftool.XMPUpdate{Rating: &rating}))
+
+		assert.Nil(t, media.SideCarPath)
+		assert.NoFileExists(t, mediaPath+".xmp")
+	})
+}
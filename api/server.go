@@ -16,12 +16,16 @@ import (
 	"github.com/joho/godotenv"
 
 	"github.com/photoview/photoview/api/database"
+	"github.com/photoview/photoview/api/database/migrations"
 	"github.com/photoview/photoview/api/dataloader"
 	"github.com/photoview/photoview/api/graphql/auth"
 	graphql_endpoint "github.com/photoview/photoview/api/graphql/endpoint"
 	"github.com/photoview/photoview/api/routes"
 	"github.com/photoview/photoview/api/scanner/externaltools/exif"
 	"github.com/photoview/photoview/api/scanner/face_detection"
+	"github.com/photoview/photoview/api/scanner/filesystem_watcher"
+	"github.com/photoview/photoview/api/scanner/geocoding"
+	"github.com/photoview/photoview/api/scanner/media_encoding"
 	"github.com/photoview/photoview/api/scanner/media_encoding/executable_worker"
 	"github.com/photoview/photoview/api/scanner/periodic_scanner"
 	"github.com/photoview/photoview/api/scanner/scanner_queue"
@@ -41,6 +45,9 @@ func main() {
 	terminateWorkers := executable_worker.Initialize()
 	defer terminateWorkers()
 
+	media_encoding.InitializeRenditions()
+	media_encoding.InitializeImageFormats()
+
 	devMode := utils.DevelopmentMode()
 
 	db, err := database.SetupDatabase()
@@ -59,6 +66,13 @@ func main() {
 	}
 	defer exifCleanup()
 
+	// Keywords of EXIF metadata parsed by older versions, the media files are parsed in the background
+	go func() {
+		if err := migrations.MigrateExifKeywords(db); err != nil {
+			log.Printf("Failed to run exif keywords migration: %v\n", err)
+		}
+	}()
+
 	if err := scanner_queue.InitializeScannerQueue(db); err != nil {
 		log.Panicf("Could not initialize scanner queue: %s\n", err)
 	}
@@ -67,10 +81,18 @@ func main() {
 		log.Panicf("Could not initialize periodic scanner: %s", err)
 	}
 
+	if err := filesystem_watcher.InitializeFilesystemWatcher(db); err != nil {
+		log.Panicf("Could not initialize filesystem watcher: %s", err)
+	}
+
 	if err := face_detection.InitializeFaceDetector(db); err != nil {
 		log.Panicf("Could not initialize face detector: %s\n", err)
 	}
 
+	if err := geocoding.InitializeGeocoder(); err != nil {
+		log.Panicf("Could not initialize geocoder: %s\n", err)
+	}
+
 	rootRouter := mux.NewRouter()
 	rootRouter.Use(dataloader.Middleware(db))
 	rootRouter.Use(auth.Middleware(db))
@@ -148,6 +170,7 @@ func setupGracefulShutdown(svr *http.Server) {
 		defer cancel()
 
 		// Shutdown scanners in correct order
+		filesystem_watcher.ShutdownFilesystemWatcher()
 		periodic_scanner.ShutdownPeriodicScanner()
 		scanner_queue.CloseScannerQueue()
 
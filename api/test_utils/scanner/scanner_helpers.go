@@ -21,7 +21,7 @@ func RunScannerOnUser(t *testing.T, db *gorm.DB, user *models.User) {
 		return
 	}
 
-	if !assert.NoError(t, scanner_queue.AddUserToQueue(user)) {
+	if !assert.NoError(t, scanner_queue.AddUserToQueue(user, models.ScannerJobPriorityNormal)) {
 		return
 	}
 
@@ -40,7 +40,7 @@ func RunScannerAll(t *testing.T, db *gorm.DB) {
 		return
 	}
 
-	if !assert.NoError(t, scanner_queue.AddAllToQueue()) {
+	if !assert.NoError(t, scanner_queue.AddAllToQueue(models.ScannerJobPriorityNormal)) {
 		return
 	}
 
@@ -20,6 +20,7 @@ const (
 	EnvMediaCachePath            EnvironmentVariable = "PHOTOVIEW_MEDIA_CACHE"
 	EnvFaceRecognitionModelsPath EnvironmentVariable = "PHOTOVIEW_FACE_RECOGNITION_MODELS_PATH"
 	EnvMediaProbeTimeout         EnvironmentVariable = "PHOTOVIEW_MEDIA_PROBE_TIMEOUT"
+	EnvGeoNamesPath              EnvironmentVariable = "PHOTOVIEW_GEONAMES_PATH"
 )
 
 // Network related
@@ -44,6 +45,13 @@ const (
 	EnvDisableVideoEncoding      EnvironmentVariable = "PHOTOVIEW_DISABLE_VIDEO_ENCODING"
 	EnvDisableRawProcessing      EnvironmentVariable = "PHOTOVIEW_DISABLE_RAW_PROCESSING"
 	EnvVideoHardwareAcceleration EnvironmentVariable = "PHOTOVIEW_VIDEO_HARDWARE_ACCELERATION"
+	EnvRenditions                EnvironmentVariable = "PHOTOVIEW_RENDITIONS"
+	EnvImageFormats              EnvironmentVariable = "PHOTOVIEW_IMAGE_FORMATS"
+	EnvVideoHLS                  EnvironmentVariable = "PHOTOVIEW_VIDEO_HLS"
+	EnvVideoPreviews             EnvironmentVariable = "PHOTOVIEW_VIDEO_PREVIEWS"
+	EnvDisableMotionPhotos       EnvironmentVariable = "PHOTOVIEW_DISABLE_MOTION_PHOTOS"
+	EnvDisableMediaStacks        EnvironmentVariable = "PHOTOVIEW_DISABLE_MEDIA_STACKS"
+	EnvStackTimeWindow           EnvironmentVariable = "PHOTOVIEW_STACK_TIME_WINDOW"
 )
 
 // GetName returns the name of the environment variable itself
@@ -94,6 +102,19 @@ func MediaProbeTimeout() time.Duration {
 	return 5 * time.Second
 }
 
+// StackTimeWindow returns the max time between two photos of the same camera to stack them as a burst,
+// when they have no burst or sequence identifiers. Defaults to 1 second if PHOTOVIEW_STACK_TIME_WINDOW is not set or invalid.
+// The value is interpreted as seconds, 0 disables stacking by time.
+func StackTimeWindow() time.Duration {
+	if val := EnvStackTimeWindow.GetValue(); val != "" {
+		if seconds, err := strconv.ParseFloat(val, 64); err == nil && seconds >= 0 {
+			return time.Duration(seconds * float64(time.Second))
+		}
+		log.Warn(nil, "Invalid PHOTOVIEW_STACK_TIME_WINDOW value, using default 1s", "value", val)
+	}
+	return time.Second
+}
+
 // UIPath returns the value from where the static UI files are located if SERVE_UI=1
 func UIPath() string {
 	if path := EnvUIPath.GetValue(); path != "" {
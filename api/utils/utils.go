@@ -63,6 +63,15 @@ func FaceRecognitionModelsPath() string {
 	return EnvFaceRecognitionModelsPath.GetValue()
 }
 
+// GeoNamesPath returns the directory of the GeoNames dataset used to resolve places from coordinates
+func GeoNamesPath() string {
+	if EnvGeoNamesPath.GetValue() == "" {
+		return path.Join("data", "geonames")
+	}
+
+	return EnvGeoNamesPath.GetValue()
+}
+
 // IsDirSymlink checks that the given path is a symlink and resolves to a
 // directory.
 func IsDirSymlink(linkPath string) (bool, error) {
@@ -38,6 +38,9 @@ LIBS=(
   "liblapack-dev:${DEB_HOST_ARCH}"
   "libjpeg62-turbo-dev:${DEB_HOST_ARCH}"
 
+  # extracting the GeoNames dataset
+  unzip
+
   # tools for development
   reflex
   sqlite3
@@ -0,0 +1,27 @@
+#!/bin/bash
+set -euo pipefail
+
+# Pins the GeoNames snapshot of the given date (YYYYMMDD), or the one already pinned in the Dockerfile,
+# and records the checksums of its files that the Docker build verifies
+cd "$(dirname "$0")/.."
+
+snapshot="${1:-$(sed -n 's/^ARG GEONAMES_SNAPSHOT=//p' Dockerfile)}"
+files=(cities15000.zip countryInfo.txt admin1CodesASCII.txt)
+
+download_dir="$(mktemp -d)"
+trap 'rm -rf "${download_dir}"' EXIT
+
+for file in "${files[@]}"; do
+  echo "Downloading ${file} of snapshot ${snapshot}..."
+  curl -fsSL -o "${download_dir}/${file}" \
+    "https://web.archive.org/web/${snapshot}id_/https://download.geonames.org/export/dump/${file}"
+done
+
+{
+  echo "# Checksums of the GeoNames snapshot ${snapshot}, written by scripts/update_geonames_snapshot.sh"
+  (cd "${download_dir}" && sha256sum "${files[@]}")
+} > scripts/geonames.sha256
+sed -i "s/^ARG GEONAMES_SNAPSHOT=.*/ARG GEONAMES_SNAPSHOT=${snapshot}/" Dockerfile
+
+echo "Pinned GeoNames snapshot ${snapshot}:"
+cat scripts/geonames.sha256